@@ -0,0 +1,79 @@
+// migrate-categories 将商品的自由文本分类映射到分类表。
+//
+// 用法:
+//
+//	go run ./cmd/migrate-categories [-config configs/config.yaml] [-map mapping.json] [-create] [-dry-run]
+//
+// mapping.json 为分类文本到分类ID的映射，例如 {"电子产品": 3, "书": 5}。
+// 没有映射的文本按名称匹配唯一的同名分类；仍未匹配时，指定 -create 会新建同名的一级分类，否则保留为未映射。
+package main
+
+import (
+	"campus/internal/bootstrap"
+	"campus/internal/config"
+	"campus/internal/modules/category/repositories"
+	"campus/internal/modules/category/services"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	configPath := flag.String("config", filepath.Join("configs", "config.yaml"), "配置文件路径")
+	mappingPath := flag.String("map", "", "分类文本到分类ID的映射文件（JSON）")
+	create := flag.Bool("create", false, "没有同名分类时新建一级分类")
+	dryRun := flag.Bool("dry-run", false, "只输出迁移报告，不修改数据")
+	flag.Parse()
+
+	opts := &services.MigrateOptions{Create: *create, DryRun: *dryRun}
+	if *mappingPath != "" {
+		data, err := os.ReadFile(*mappingPath)
+		if err != nil {
+			exitf("读取映射文件失败: %v", err)
+		}
+		if err := json.Unmarshal(data, &opts.Mapping); err != nil {
+			exitf("解析映射文件失败: %v", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		exitf("加载配置失败: %v", err)
+	}
+	bootstrap.SetConfig(cfg)
+	bootstrap.InitLogger()
+
+	// 初始化数据库时会自动迁移表结构，创建分类表和商品的分类ID字段
+	if err := bootstrap.InitDatabase(); err != nil {
+		exitf("数据库初始化失败: %v", err)
+	}
+	defer bootstrap.CloseDatabase()
+
+	service := services.NewCategoryService(repositories.NewCategoryRepository(bootstrap.GetDB()))
+	report, err := service.MigrateProductCategories(opts)
+	if err != nil {
+		exitf("迁移失败: %v", err)
+	}
+
+	for _, item := range report.Items {
+		switch {
+		case item.Reason != "":
+			fmt.Printf("[跳过] %q (%d件商品): %s\n", item.Text, item.Products, item.Reason)
+		case item.Created:
+			fmt.Printf("[新建] %q (%d件商品) -> %s #%d\n", item.Text, item.Products, item.Category, item.CategoryID)
+		default:
+			fmt.Printf("[映射] %q (%d件商品) -> %s #%d\n", item.Text, item.Products, item.Category, item.CategoryID)
+		}
+	}
+	if *dryRun {
+		fmt.Print("试运行，未修改数据。")
+	}
+	fmt.Printf("共迁移%d件商品，%d件商品未能映射\n", report.Migrated, report.Skipped)
+}
+
+func exitf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
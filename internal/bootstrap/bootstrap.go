@@ -3,6 +3,7 @@ package bootstrap
 import (
 	"campus/internal/config"
 	"campus/internal/utils/logger"
+	"campus/internal/utils/pagination"
 	"campus/internal/websocket"
 	"fmt"
 	"github.com/casbin/casbin/v2"
@@ -22,6 +23,9 @@ var (
 
 	// 全局WebSocket管理器
 	wsManager *websocket.Manager
+
+	// 应用关闭时执行的清理函数，按注册顺序执行
+	shutdownHooks []func()
 )
 
 // Bootstrap 初始化应用
@@ -35,6 +39,7 @@ func Bootstrap(configPath string) error {
 
 	// 设置全局配置
 	SetConfig(cfg)
+	pagination.SetSecret(cfg.Pagination.CursorSecret)
 
 	// 初始化日志系统
 	InitLogger()
@@ -57,6 +62,9 @@ func Bootstrap(configPath string) error {
 		logger.Errorf("权限初始化失败: %v", err)
 	}
 
+	// 初始化定时任务调度器
+	InitScheduler()
+
 	// 初始化消息系统
 	if err := InitMessaging(); err != nil {
 		logger.Errorf("消息系统初始化失败: %v", err)
@@ -69,6 +77,14 @@ func Bootstrap(configPath string) error {
 
 // Shutdown 优雅关闭应用
 func Shutdown() error {
+	// 先停止定时任务，避免任务继续使用数据库连接
+	StopScheduler()
+
+	// 各模块的清理函数可能需要写入数据库，在关闭数据库连接前执行
+	for _, hook := range shutdownHooks {
+		hook()
+	}
+
 	// 关闭数据库连接
 	if err := CloseDatabase(); err != nil {
 		logger.Errorf("关闭数据库连接失败: %v", err)
@@ -82,6 +98,11 @@ func Shutdown() error {
 	return nil
 }
 
+// RegisterShutdownHook 注册应用关闭时执行的清理函数
+func RegisterShutdownHook(hook func()) {
+	shutdownHooks = append(shutdownHooks, hook)
+}
+
 // GetDB 获取全局数据库连接
 func GetDB() *gorm.DB {
 	return db
@@ -4,6 +4,8 @@ import (
 	"campus/internal/database"
 	"campus/internal/models"
 	"campus/internal/utils/logger"
+
+	"gorm.io/gorm"
 )
 
 // InitDatabase 初始化数据库
@@ -33,6 +35,10 @@ func InitDatabase() error {
 
 // AutoMigrateModels 自动迁移数据库表结构
 func AutoMigrateModels() error {
+	if err := clearEmptyMessageClientIDs(GetDB()); err != nil {
+		return err
+	}
+
 	// 迁移表结构
 	if err := database.AutoMigrate(
 		GetDB(),
@@ -48,6 +54,17 @@ func AutoMigrateModels() error {
 		&models.ProductImage{},
 		&models.Favorite{},
 		&models.OrderLog{},
+		&models.JobLease{},
+		&models.Payment{},
+		&models.Dispute{},
+		&models.DisputeEvidence{},
+		&models.Offer{},
+		&models.Category{},
+		&models.ProductModeration{},
+		&models.ProductDailyView{},
+		&models.ProductRevision{},
+		&models.DeviceReadCursor{},
+		&models.MessageEvent{},
 	); err != nil {
 		return err
 	}
@@ -62,6 +79,15 @@ func AutoMigrateModels() error {
 	return nil
 }
 
+// clearEmptyMessageClientIDs 消息的客户端ID改为可空并按发送者建立唯一索引，
+// 建立索引前将旧数据中未传入客户端ID的空字符串置为NULL
+func clearEmptyMessageClientIDs(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.Message{}, "ClientID") {
+		return nil
+	}
+	return db.Unscoped().Model(&models.Message{}).Where("client_id = ?", "").Update("client_id", nil).Error
+}
+
 // CloseDatabase 关闭数据库连接
 func CloseDatabase() error {
 	if db == nil {
@@ -7,6 +7,9 @@ import (
 	"errors"
 )
 
+// 全局消息发布者，消息模块和需要推送通知的模块共用
+var messagePublisher *rabbitMQ.Publisher
+
 // InitMessaging initializes the rabbitMQ system, including WebSocket manager and the RabbitMQ consumer.
 func InitMessaging() error {
 	config := GetConfig()
@@ -24,6 +27,18 @@ func InitMessaging() error {
 	rabbitURL := config.RabbitMQ.URL
 	go rabbitMQ.StartConsumer(rabbitURL, wsManager)
 
+	// 3. Create the shared RabbitMQ publisher
+	publisher, err := rabbitMQ.NewPublisher(rabbitURL)
+	if err != nil {
+		return err
+	}
+	messagePublisher = publisher
+
 	logger.Info("消息系统初始化成功")
 	return nil
 }
+
+// GetMessagePublisher 获取消息发布者
+func GetMessagePublisher() *rabbitMQ.Publisher {
+	return messagePublisher
+}
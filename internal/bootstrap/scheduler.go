@@ -0,0 +1,28 @@
+package bootstrap
+
+import (
+	"campus/internal/scheduler"
+	"campus/internal/utils/logger"
+)
+
+// 全局定时任务调度器，各模块在注册路由时注册自己的任务
+var jobScheduler *scheduler.Scheduler
+
+// InitScheduler 初始化并启动定时任务调度器
+func InitScheduler() {
+	jobScheduler = scheduler.NewScheduler(GetDB())
+	jobScheduler.Start()
+	logger.Info("定时任务调度器初始化成功")
+}
+
+// GetScheduler 获取定时任务调度器
+func GetScheduler() *scheduler.Scheduler {
+	return jobScheduler
+}
+
+// StopScheduler 停止定时任务调度器
+func StopScheduler() {
+	if jobScheduler != nil {
+		jobScheduler.Stop()
+	}
+}
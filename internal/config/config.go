@@ -8,12 +8,18 @@ import (
 
 // Config 应用配置结构体
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Upload   UploadConfig
-	RabbitMQ *RabbitMQConfig
-	Log      LogConfig
+	Server     ServerConfig
+	Database   DatabaseConfig
+	JWT        JWTConfig
+	Upload     UploadConfig
+	RabbitMQ   *RabbitMQConfig
+	Log        LogConfig
+	Order      OrderConfig
+	Payment    PaymentConfig
+	Pagination PaginationConfig
+	Moderation ModerationConfig
+	Views      ViewConfig
+	Message    MessageConfig
 }
 
 // ServerConfig 服务器配置
@@ -59,6 +65,48 @@ type RabbitMQConfig struct {
 	Port     string
 }
 
+// OrderConfig 订单超时配置
+type OrderConfig struct {
+	SellerHandleTimeout time.Duration // 卖家未处理超时自动取消
+	PaymentTimeout      time.Duration // 待付款超时自动取消
+	AutoConfirmAfter    time.Duration // 发货后超时自动确认收货
+	OfferExpireAfter    time.Duration // 议价出价未回应的有效期
+	JobInterval         time.Duration // 超时任务执行间隔
+}
+
+// PaymentConfig 支付配置
+type PaymentConfig struct {
+	Provider       string // 支付渠道，目前支持 local
+	LocalSecret    string // 本地模拟渠道的回调签名密钥
+	EnableSimulate bool   // 是否开放模拟支付接口，仅用于开发和测试环境
+}
+
+// PaginationConfig 分页配置
+type PaginationConfig struct {
+	CursorSecret string // 分页游标签名密钥
+}
+
+// ModerationConfig 商品审核配置
+type ModerationConfig struct {
+	BannedKeywords    []string // 自动预审的违禁词
+	PriceOutlierRatio float64  // 价格高于同类商品中位数的该倍数或低于其倒数时视为异常
+	PriceMinSamples   int      // 同类在售商品少于该数量时不判断价格异常
+}
+
+// ViewConfig 商品浏览统计和热门排行配置
+type ViewConfig struct {
+	DedupWindow    time.Duration // 同一用户在该时间内重复浏览同一商品只计一次
+	FlushInterval  time.Duration // 内存中的浏览计数写入数据库的间隔
+	HotWindow      time.Duration // 热门排行只考虑该时间内发布的商品
+	HotGravity     float64       // 热度随发布时间衰减的指数，越大衰减越快
+	FavoriteWeight float64       // 一次收藏相当于多少次浏览
+}
+
+// MessageConfig 聊天消息配置
+type MessageConfig struct {
+	WithdrawWindow time.Duration // 消息发送后可以撤回的时间
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level  string
@@ -113,6 +161,96 @@ func LoadConfig(configPath string) (*Config, error) {
 	config.Upload.AllowedTypes = v.GetString("upload.allowed_types")
 	config.Upload.MaxSize = v.GetInt("upload.max_size")
 
+	// 订单配置
+	config.Order.SellerHandleTimeout = time.Duration(v.GetInt("order.seller_handle_timeout")) * time.Hour
+	if config.Order.SellerHandleTimeout == 0 {
+		config.Order.SellerHandleTimeout = 48 * time.Hour // 默认48小时
+	}
+
+	config.Order.PaymentTimeout = time.Duration(v.GetInt("order.payment_timeout")) * time.Minute
+	if config.Order.PaymentTimeout == 0 {
+		config.Order.PaymentTimeout = 30 * time.Minute // 默认30分钟
+	}
+
+	config.Order.AutoConfirmAfter = time.Duration(v.GetInt("order.auto_confirm_days")) * 24 * time.Hour
+	if config.Order.AutoConfirmAfter == 0 {
+		config.Order.AutoConfirmAfter = 7 * 24 * time.Hour // 默认7天
+	}
+
+	config.Order.OfferExpireAfter = time.Duration(v.GetInt("order.offer_expire_hours")) * time.Hour
+	if config.Order.OfferExpireAfter == 0 {
+		config.Order.OfferExpireAfter = 24 * time.Hour // 默认24小时
+	}
+
+	config.Order.JobInterval = time.Duration(v.GetInt("order.job_interval")) * time.Second
+	if config.Order.JobInterval == 0 {
+		config.Order.JobInterval = time.Minute // 默认每分钟执行一次
+	}
+
+	// 支付配置
+	config.Payment.Provider = v.GetString("payment.provider")
+	if config.Payment.Provider == "" {
+		config.Payment.Provider = "local" // 默认使用本地模拟支付
+	}
+
+	// 回调签名密钥必须单独配置，不复用JWT密钥
+	config.Payment.LocalSecret = v.GetString("payment.local_secret")
+	if config.Payment.Provider == "local" && config.Payment.LocalSecret == "" {
+		return nil, fmt.Errorf("未配置本地支付回调签名密钥 payment.local_secret")
+	}
+	config.Payment.EnableSimulate = v.GetBool("payment.enable_simulate")
+
+	// 分页配置
+	// 游标签名密钥必须单独配置，不复用其他密钥
+	config.Pagination.CursorSecret = v.GetString("pagination.cursor_secret")
+	if config.Pagination.CursorSecret == "" {
+		return nil, fmt.Errorf("未配置分页游标签名密钥 pagination.cursor_secret")
+	}
+
+	// 商品审核配置
+	config.Moderation.BannedKeywords = v.GetStringSlice("moderation.banned_keywords")
+	config.Moderation.PriceOutlierRatio = v.GetFloat64("moderation.price_outlier_ratio")
+	if config.Moderation.PriceOutlierRatio <= 1 {
+		config.Moderation.PriceOutlierRatio = 10 // 默认偏离中位数10倍
+	}
+
+	config.Moderation.PriceMinSamples = v.GetInt("moderation.price_min_samples")
+	if config.Moderation.PriceMinSamples == 0 {
+		config.Moderation.PriceMinSamples = 5 // 默认至少5件同类商品
+	}
+
+	// 浏览统计配置
+	config.Views.DedupWindow = time.Duration(v.GetInt("views.dedup_window")) * time.Minute
+	if config.Views.DedupWindow == 0 {
+		config.Views.DedupWindow = 30 * time.Minute // 默认30分钟
+	}
+
+	config.Views.FlushInterval = time.Duration(v.GetInt("views.flush_interval")) * time.Second
+	if config.Views.FlushInterval == 0 {
+		config.Views.FlushInterval = 30 * time.Second // 默认每30秒写入一次
+	}
+
+	config.Views.HotWindow = time.Duration(v.GetInt("views.hot_window_days")) * 24 * time.Hour
+	if config.Views.HotWindow == 0 {
+		config.Views.HotWindow = 30 * 24 * time.Hour // 默认30天
+	}
+
+	config.Views.HotGravity = v.GetFloat64("views.hot_gravity")
+	if config.Views.HotGravity <= 0 {
+		config.Views.HotGravity = 1.5
+	}
+
+	config.Views.FavoriteWeight = v.GetFloat64("views.favorite_weight")
+	if config.Views.FavoriteWeight <= 0 {
+		config.Views.FavoriteWeight = 5 // 默认一次收藏相当于5次浏览
+	}
+
+	// 聊天消息配置
+	config.Message.WithdrawWindow = time.Duration(v.GetInt("message.withdraw_window")) * time.Second
+	if config.Message.WithdrawWindow == 0 {
+		config.Message.WithdrawWindow = 2 * time.Minute // 默认2分钟内可以撤回
+	}
+
 	// 日志配置
 	config.Log.Level = v.GetString("log.level")
 	if config.Log.Level == "" {
@@ -0,0 +1,14 @@
+package models
+
+import "gorm.io/gorm"
+
+// Category 商品分类，ParentID为空表示一级分类
+type Category struct {
+	gorm.Model
+	Name      string     `gorm:"size:50;not null;index" json:"name"`
+	ParentID  *uint      `gorm:"index" json:"parent_id"`
+	Children  []Category `gorm:"foreignKey:ParentID" json:"children,omitempty"`
+	Icon      string     `gorm:"size:255" json:"icon"`
+	SortOrder int        `gorm:"default:0" json:"sort_order"` // 同级分类按排序值升序展示
+	IsEnabled bool       `gorm:"index" json:"is_enabled"`     // 停用的分类及其子分类不再展示，也不能用于发布商品
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// DeviceReadCursor 用户在各设备上已读到的消息位置，多设备同时在线时各设备分别记录
+type DeviceReadCursor struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	UserID        uint      `gorm:"not null;uniqueIndex:idx_user_device" json:"user_id"`
+	DeviceID      string    `gorm:"size:64;not null;uniqueIndex:idx_user_device" json:"device_id"`
+	LastMessageID uint      `gorm:"not null;default:0" json:"last_message_id"` // 已读到的最后一条消息ID
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// 售后状态枚举
+const (
+	DisputeStatusOpen      = "待卖家回应"
+	DisputeStatusResponded = "待平台处理"
+	DisputeStatusRefunding = "退款中" // 平台已决定退款，等待支付渠道退款完成
+	DisputeStatusResolved  = "已处理"
+)
+
+// 售后处理结果
+const (
+	DisputeResolutionRefund  = "refund"  // 全额退款
+	DisputeResolutionPartial = "partial" // 部分退款
+	DisputeResolutionReject  = "reject"  // 驳回
+)
+
+// Dispute 订单售后申请模型
+type Dispute struct {
+	gorm.Model
+	OrderID          uint              `gorm:"not null;index" json:"order_id"`
+	BuyerID          uint              `gorm:"not null;index" json:"buyer_id"`
+	SellerID         uint              `gorm:"not null;index" json:"seller_id"`
+	Reason           string            `gorm:"size:500;not null" json:"reason"`
+	OrderStatus      string            `gorm:"size:20" json:"order_status"` // 发起售后前的订单状态，驳回时恢复
+	Status           string            `gorm:"size:20;default:待卖家回应" json:"status"`
+	SellerResponse   string            `gorm:"size:500" json:"seller_response"`
+	RespondedAt      *time.Time        `json:"responded_at"`
+	Resolution       string            `gorm:"size:20" json:"resolution"` // 取值见 DisputeResolution* 常量
+	RefundAmount     float64           `json:"refund_amount"`
+	ResolutionRemark string            `gorm:"size:500" json:"resolution_remark"`
+	ResolvedBy       uint              `json:"resolved_by"`
+	ResolvedAt       *time.Time        `json:"resolved_at"`
+	Evidences        []DisputeEvidence `gorm:"foreignKey:DisputeID" json:"evidences"`
+}
+
+// DisputeEvidence 售后凭证图片
+type DisputeEvidence struct {
+	gorm.Model
+	DisputeID uint   `gorm:"not null;index" json:"dispute_id"`
+	UserID    uint   `gorm:"not null" json:"user_id"` // 上传凭证的用户（买家或卖家）
+	ImageURL  string `gorm:"size:255;not null" json:"image_url"`
+}
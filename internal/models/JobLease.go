@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// JobLease 定时任务租约，多实例部署时保证同一任务同一时间只在一个实例上执行
+type JobLease struct {
+	Name      string    `gorm:"primaryKey;size:64" json:"name"` // 任务名称
+	Holder    string    `gorm:"size:128" json:"holder"`         // 当前持有租约的实例
+	ExpiresAt time.Time `json:"expires_at"`                     // 租约过期时间
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -15,18 +15,21 @@ const (
 // Message 消息模型
 type Message struct {
 	gorm.Model
-	SenderID    uint      `gorm:"not null;index" json:"sender_id"`           // 发送者ID
-	Sender      User      `gorm:"foreignKey:SenderID" json:"sender"`         // 发送者
-	ReceiverID  uint      `gorm:"not null;index" json:"receiver_id"`         // 接收者ID
-	Receiver    User      `gorm:"foreignKey:ReceiverID" json:"receiver"`     // 接收者
-	Content     string    `gorm:"size:1000;not null" json:"content"`         // 消息内容
-	Type        string    `gorm:"size:20;not null;default:text" json:"type"` // 消息类型
-	IsRead      bool      `gorm:"default:false" json:"is_read"`              // 是否已读
-	ReadTime    time.Time `gorm:"default:null" json:"read_time"`             // 阅读时间
-	ProductID   uint      `gorm:"index" json:"product_id"`                   // 相关商品ID
-	Product     Product   `gorm:"foreignKey:ProductID" json:"product"`       // 相关商品
-	IsDeleted   bool      `gorm:"default:false" json:"is_deleted"`           // 软删除标记
-	IsWithdrawn bool      `gorm:"default:false" json:"is_withdrawn"`         // 是否已撤回
+	SenderID          uint      `gorm:"not null;index;uniqueIndex:idx_message_sender_client" json:"sender_id"`    // 发送者ID
+	Sender            User      `gorm:"foreignKey:SenderID" json:"sender"`                                        // 发送者
+	ReceiverID        uint      `gorm:"not null;index" json:"receiver_id"`                                        // 接收者ID
+	Receiver          User      `gorm:"foreignKey:ReceiverID" json:"receiver"`                                    // 接收者
+	Content           string    `gorm:"size:1000;not null" json:"content"`                                        // 消息内容
+	Type              string    `gorm:"size:20;not null;default:text" json:"type"`                                // 消息类型
+	IsRead            bool      `gorm:"default:false" json:"is_read"`                                             // 是否已读
+	ReadTime          time.Time `gorm:"default:null" json:"read_time"`                                            // 阅读时间
+	ProductID         uint      `gorm:"index" json:"product_id"`                                                  // 相关商品ID
+	Product           Product   `gorm:"foreignKey:ProductID" json:"product"`                                      // 相关商品
+	IsDeleted         bool      `gorm:"default:false" json:"is_deleted"`                                          // 管理员删除标记，删除后会话双方都不可见
+	DeletedBySender   bool      `gorm:"default:false" json:"-"`                                                   // 发送者已删除，只对发送者隐藏
+	DeletedByReceiver bool      `gorm:"default:false" json:"-"`                                                   // 接收者已删除，只对接收者隐藏
+	IsWithdrawn       bool      `gorm:"default:false" json:"is_withdrawn"`                                        // 是否已撤回
+	ClientID          *string   `gorm:"size:64;uniqueIndex:idx_message_sender_client" json:"client_id,omitempty"` // 客户端生成的消息ID，同一发送者唯一，用于重发去重；未传入时为NULL
 }
 
 // TableName 指定表名
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// 消息变更事件类型
+const (
+	MessageEventWithdrawn = "withdrawn" // 消息已撤回
+	MessageEventDeleted   = "deleted"   // 消息已删除
+)
+
+// MessageEvent 消息撤回、删除等变更事件，每个需要同步的用户一条。
+// 事件ID作为变更序号，离线的设备重连时补发之后的事件
+type MessageEvent struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"` // 需要同步该事件的用户
+	MessageID  uint      `gorm:"not null" json:"message_id"`    // 变更的消息ID
+	SenderID   uint      `gorm:"not null" json:"sender_id"`     // 消息的发送者ID
+	ReceiverID uint      `gorm:"not null" json:"receiver_id"`   // 消息的接收者ID
+	Type       string    `gorm:"size:20;not null" json:"type"`  // 事件类型
+	OperatorID uint      `gorm:"not null" json:"operator_id"`   // 执行撤回或删除的用户ID，管理员删除时为0
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// 议价状态枚举
+const (
+	OfferStatusPending   = "待回应" // 等待对方回应
+	OfferStatusCountered = "已还价" // 对方已还价，由新的出价替代
+	OfferStatusAccepted  = "已接受" // 对方接受出价，已生成订单
+	OfferStatusRejected  = "已拒绝" // 对方拒绝出价
+	OfferStatusExpired   = "已过期" // 超时未回应
+	OfferStatusWithdrawn = "已撤回" // 出价方撤回
+)
+
+// Offer 议价模型，买家出价、卖家还价各为一条记录，通过ParentID串联
+type Offer struct {
+	gorm.Model
+	ProductID   uint       `gorm:"not null;index" json:"product_id"`
+	Product     Product    `gorm:"foreignKey:ProductID" json:"product"`
+	BuyerID     uint       `gorm:"not null;index" json:"buyer_id"`
+	SellerID    uint       `gorm:"not null;index" json:"seller_id"`
+	ProposerID  uint       `gorm:"not null" json:"proposer_id"` // 出价方，买家出价或卖家还价
+	ParentID    *uint      `gorm:"index" json:"parent_id"`      // 被还价的出价ID，首次出价为空
+	Price       float64    `gorm:"not null" json:"price"`
+	Message     string     `gorm:"size:200" json:"message"`
+	Status      string     `gorm:"size:20;default:待回应;index" json:"status"` // 取值见 OfferStatus* 常量
+	ExpiresAt   time.Time  `gorm:"index" json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	OrderID     *uint      `json:"order_id"` // 接受后生成的订单ID
+}
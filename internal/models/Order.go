@@ -5,6 +5,20 @@ import (
 	"time"
 )
 
+// 订单状态枚举
+const (
+	OrderStatusPending       = "卖家未处理" // 买家已下单，等待卖家处理
+	OrderStatusAccepted      = "卖家已同意" // 卖家同意交易
+	OrderStatusRejected      = "卖家已拒绝" // 卖家拒绝交易
+	OrderStatusAwaitPayment  = "待付款"   // 等待买家付款
+	OrderStatusAwaitDelivery = "待发货"   // 买家已付款，等待卖家发货
+	OrderStatusAwaitReceipt  = "待收货"   // 卖家已发货，等待买家确认收货
+	OrderStatusCompleted     = "已完成"   // 交易完成
+	OrderStatusCancelled     = "已取消"   // 订单已取消
+	OrderStatusDisputed      = "售后中"   // 买家发起售后，等待处理
+	OrderStatusRefunded      = "已退款"   // 售后退款完成
+)
+
 // Order 订单模型
 type Order struct {
 	gorm.Model
@@ -14,18 +28,24 @@ type Order struct {
 	Seller       User       `gorm:"foreignKey:SellerID" json:"seller"`
 	ProductID    uint       `gorm:"not null;index" json:"product_id"`
 	Product      Product    `gorm:"foreignKey:ProductID" json:"product"`
-	Status       string     `gorm:"size:20;default:卖家未处理" json:"status"` // pending, completed, cancelled
+	Status       string     `gorm:"size:20;default:卖家未处理" json:"status"` // 取值见 OrderStatus* 常量
+	AgreedPrice  float64    `json:"agreed_price"`                        // 成交价快照：下单时的商品价格或议价结果
+	AwaitPayTime *time.Time `json:"await_pay_time"`                      // 进入待付款状态的时间，付款超时从此时开始计算
 	PayTime      *time.Time `json:"pay_time"`
 	DeliveryTime *time.Time `json:"delivery_time"`
 	CompleteTime *time.Time `json:"complete_time"`
 	Remark       string     `json:"remark"`
 }
 
-// OrderLog 订单日志模型
+// OrderLog 订单日志模型，记录订单的每一次变更
 type OrderLog struct {
 	gorm.Model
-	OrderID  uint   `json:"order_id"`
-	Action   string `json:"action"`
-	Operator string `json:"operator"`
-	Remark   string `json:"remark"`
+	OrderID      uint   `gorm:"index" json:"order_id"`
+	Action       string `json:"action"`
+	Operator     string `json:"operator"`                     // 操作方名称：买家、卖家、管理员、系统
+	OperatorID   uint   `json:"operator_id"`                  // 操作用户ID，系统操作为0
+	OperatorRole string `gorm:"size:20" json:"operator_role"` // 操作方角色：buyer, seller, admin, system
+	FromStatus   string `gorm:"size:20" json:"from_status"`   // 变更前状态
+	ToStatus     string `gorm:"size:20" json:"to_status"`     // 变更后状态
+	Remark       string `json:"remark"`
 }
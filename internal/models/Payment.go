@@ -0,0 +1,32 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// 支付单状态枚举
+const (
+	PaymentStatusPending      = "待支付"
+	PaymentStatusPaid         = "已支付"
+	PaymentStatusFailed       = "支付失败"
+	PaymentStatusRefunded     = "已退款"
+	PaymentStatusPartRefunded = "部分退款"
+)
+
+// Payment 支付单模型，订单的每一次支付尝试对应一条记录
+type Payment struct {
+	gorm.Model
+	OrderID         uint       `gorm:"not null;index" json:"order_id"`
+	UserID          uint       `gorm:"not null;index" json:"user_id"`       // 付款人
+	Provider        string     `gorm:"size:20;not null" json:"provider"`    // 支付渠道
+	TradeNo         string     `gorm:"size:64;uniqueIndex" json:"trade_no"` // 平台交易号
+	ProviderTradeNo string     `gorm:"size:64" json:"provider_trade_no"`    // 渠道交易号
+	Amount          float64    `gorm:"not null" json:"amount"`
+	Status          string     `gorm:"size:20;default:待支付" json:"status"` // 取值见 PaymentStatus* 常量
+	PaidAt          *time.Time `json:"paid_at"`
+	RefundNo        string     `gorm:"size:64" json:"refund_no"`
+	RefundAmount    float64    `json:"refund_amount"`
+	RefundedAt      *time.Time `json:"refunded_at"`
+	FailReason      string     `json:"fail_reason"`
+}
@@ -5,17 +5,35 @@ import (
 	"time"
 )
 
+// 商品状态枚举
+const (
+	ProductStatusOnSale    = "售卖中"
+	ProductStatusOffShelf  = "已下架"
+	ProductStatusReviewing = "审核中"
+	ProductStatusReserved  = "交易中" // 已被订单预订，等待交易结束
+	ProductStatusSold      = "已售出"
+	ProductStatusRejected  = "审核未通过"
+)
+
+// ProductStatusInTrade 交易中或已售出的商品状态由订单流程维护，不能直接修改
+func ProductStatusInTrade(status string) bool {
+	return status == ProductStatusReserved || status == ProductStatusSold
+}
+
 // Product 商品模型
 type Product struct {
 	gorm.Model
 	Title         string         `gorm:"size:100;not null;index" json:"title"`
 	Description   string         `gorm:"size:1000" json:"description"`
 	Price         float64        `gorm:"not null" json:"price"`
-	ProductImages []ProductImage `gorm:"size:1000" json:"images"` // JSON string of image URLs
+	ProductImages []ProductImage `gorm:"size:1000" json:"images"`  // JSON string of image URLs
+	CategoryID    *uint          `gorm:"index" json:"category_id"` // 所属分类，Category为该分类名称的冗余
 	Category      string         `gorm:"size:50;index" json:"category"`
 	Condition     string         `gorm:"size:20" json:"condition"` // new, like_new, good, fair, poor
 	UserID        uint           `gorm:"not null;index" json:"user_id"`
 	User          User           `gorm:"foreignKey:UserID" json:"user"`
-	Status        string         `gorm:"size:20;default:available" json:"status"` // 售卖中, 已下架, 审核中
+	Status        string         `gorm:"size:20;default:审核中" json:"status"` // 取值见 ProductStatus* 常量
 	SoldAt        time.Time      `json:"sold_at"`
+	ViewCount     int64          `gorm:"not null;default:0" json:"view_count"`   // 浏览次数，同一用户在去重窗口内只计一次
+	ListedPrice   float64        `gorm:"not null;default:0" json:"listed_price"` // 最近一次在售时的价格，用于判断降价
 }
@@ -0,0 +1,9 @@
+package models
+
+// ProductDailyView 商品每日浏览次数，用于统计一段时间内的浏览量
+type ProductDailyView struct {
+	ID        uint   `gorm:"primarykey" json:"id"`
+	ProductID uint   `gorm:"not null;uniqueIndex:idx_product_day" json:"product_id"`
+	Day       string `gorm:"size:10;not null;uniqueIndex:idx_product_day;index" json:"day"` // 格式为2006-01-02
+	Views     int64  `gorm:"not null;default:0" json:"views"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// 商品审核状态
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// ProductModeration 商品审核记录，商品发布或编辑后进入审核队列时生成，同一商品同时只有一条待审核记录
+type ProductModeration struct {
+	gorm.Model
+	ProductID  uint       `gorm:"not null;index" json:"product_id"`
+	Product    Product    `gorm:"foreignKey:ProductID" json:"product"`
+	Status     string     `gorm:"size:20;not null;index" json:"status"` // 取值见 Moderation* 常量
+	Flags      string     `gorm:"size:1000" json:"flags"`               // 自动预审发现的问题，多条以换行分隔
+	Reason     string     `gorm:"size:200" json:"reason"`               // 驳回原因
+	ReviewerID uint       `json:"reviewer_id"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// ProductRevision 商品修改记录，只保存发生变化的字段
+type ProductRevision struct {
+	gorm.Model
+	ProductID uint   `gorm:"not null;index" json:"product_id"`
+	EditorID  uint   `json:"editor_id"`                // 修改人，卖家或管理员
+	Changes   string `gorm:"type:text" json:"changes"` // 发生变化的字段，RevisionChange数组的JSON
+}
+
+// RevisionChange 单个字段的修改前后的值
+type RevisionChange struct {
+	Field string      `json:"field"` // title, description, price, images, category, status
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
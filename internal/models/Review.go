@@ -2,17 +2,30 @@ package models
 
 import (
 	"gorm.io/gorm"
+	"time"
 )
 
-// Review 评价模型
+// 评价方角色
+const (
+	ReviewRoleBuyer  = "buyer"  // 买家评价卖家
+	ReviewRoleSeller = "seller" // 卖家评价买家
+)
+
+// Review 评价模型，买卖双方在订单完成后各可评价对方一次
 type Review struct {
 	gorm.Model
-	UserID     uint    `gorm:"not null;index" json:"user_id"`
-	User       User    `gorm:"foreignKey:UserID" json:"user"`
-	ProductID  uint    `gorm:"not null;index" json:"product_id"`
-	Product    Product `gorm:"foreignKey:ProductID" json:"product"`
-	Rating     int     `gorm:"not null" json:"rating"` // 1-5
-	Content    string  `gorm:"size:500" json:"content"`
-	ReviewerID uint    `gorm:"not null;index" json:"reviewer_id"`
-	Reviewer   User    `gorm:"foreignKey:ReviewerID" json:"reviewer"`
+	OrderID      uint       `gorm:"uniqueIndex:idx_review_order_reviewer" json:"order_id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"` // 被评价用户
+	User         User       `gorm:"foreignKey:UserID" json:"user"`
+	ProductID    uint       `gorm:"not null;index" json:"product_id"`
+	Product      Product    `gorm:"foreignKey:ProductID" json:"product"`
+	Rating       int        `gorm:"not null" json:"rating"` // 1-5
+	Content      string     `gorm:"size:500" json:"content"`
+	ReviewerID   uint       `gorm:"not null;index;uniqueIndex:idx_review_order_reviewer" json:"reviewer_id"`
+	Reviewer     User       `gorm:"foreignKey:ReviewerID" json:"reviewer"`
+	ReviewerRole string     `gorm:"size:20" json:"reviewer_role"` // 取值见 ReviewRole* 常量
+	IsHidden     bool       `gorm:"default:false;index" json:"is_hidden"`
+	HiddenReason string     `gorm:"size:200" json:"hidden_reason"`
+	HiddenBy     uint       `json:"hidden_by"`
+	HiddenAt     *time.Time `json:"hidden_at"`
 }
@@ -1,6 +1,10 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // User 用户模型
 type User struct {
@@ -14,5 +18,8 @@ type User struct {
 	Roles       []Role `gorm:"many2many:user_roles" json:"roles,omitempty"` // 用户拥有的所有角色
 	Description string `gorm:"size:500" json:"description"`
 	Status      string `gorm:"size:20;default:'正常'" json:"status"` // 用户状态：正常、禁用
+	LastSeenAt  *time.Time `gorm:"default:null" json:"-"`             // 最后在线时间，所有WebSocket连接断开时更新，只向会话中的用户公开
 	ProductCount int    `gorm:"-" json:"product_count"`            // 产品数量，非持久化字段，需要在查询时计算
+	Reputation   float64 `gorm:"-" json:"reputation"`              // 信誉评分（收到评价的平均分），非持久化字段
+	ReviewCount  int64   `gorm:"-" json:"review_count"`            // 收到的评价数量，非持久化字段
 }
@@ -0,0 +1,19 @@
+package api
+
+// CreateCategoryRequest 创建分类请求
+type CreateCategoryRequest struct {
+	Name      string `json:"name" binding:"required,max=50"`
+	ParentID  *uint  `json:"parent_id"` // 为空表示一级分类
+	Icon      string `json:"icon" binding:"max=255"`
+	SortOrder int    `json:"sort_order"`
+	IsEnabled *bool  `json:"is_enabled"` // 默认启用
+}
+
+// UpdateCategoryRequest 更新分类请求，为空的字段不修改
+type UpdateCategoryRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=50"`
+	ParentID  *uint   `json:"parent_id"` // 传入0表示移动为一级分类
+	Icon      *string `json:"icon" binding:"omitempty,max=255"`
+	SortOrder *int    `json:"sort_order"`
+	IsEnabled *bool   `json:"is_enabled"`
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"campus/internal/models"
+	"sort"
+	"time"
+)
+
+// CategoryResponse 分类信息，Children为按排序值排列的子分类
+type CategoryResponse struct {
+	ID        uint                `json:"id"`
+	Name      string              `json:"name"`
+	ParentID  *uint               `json:"parent_id"`
+	Icon      string              `json:"icon"`
+	SortOrder int                 `json:"sort_order"`
+	IsEnabled bool                `json:"is_enabled"`
+	Children  []*CategoryResponse `json:"children,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// CategoryMigrationItem 一个自由文本分类的迁移结果
+type CategoryMigrationItem struct {
+	Text       string `json:"text"`             // 商品原有的分类文本
+	Products   int64  `json:"products"`         // 使用该文本的商品数量
+	CategoryID uint   `json:"category_id"`      // 映射到的分类，未映射或试运行新建时为0
+	Category   string `json:"category"`         // 映射到的分类名称
+	Created    bool   `json:"created"`          // 是否为该文本新建了分类
+	Reason     string `json:"reason,omitempty"` // 未映射的原因
+}
+
+// CategoryMigrationReport 自由文本分类迁移报告
+type CategoryMigrationReport struct {
+	Items    []CategoryMigrationItem `json:"items"`
+	Migrated int64                   `json:"migrated"` // 已关联分类的商品数量
+	Skipped  int64                   `json:"skipped"`  // 未能映射的商品数量
+}
+
+func ConvertToCategoryResponse(category *models.Category) *CategoryResponse {
+	return &CategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		ParentID:  category.ParentID,
+		Icon:      category.Icon,
+		SortOrder: category.SortOrder,
+		IsEnabled: category.IsEnabled,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+	}
+}
+
+// BuildCategoryTree 将分类列表组装为树，同级分类按排序值和ID升序排列。
+// 父分类不在列表中的分类会被丢弃，因此只传入启用的分类时，停用分类的子分类也不会出现
+func BuildCategoryTree(categories []*models.Category) []*CategoryResponse {
+	nodes := make(map[uint]*CategoryResponse, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = ConvertToCategoryResponse(c)
+	}
+
+	roots := make([]*CategoryResponse, 0)
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	sortCategories(roots)
+	return roots
+}
+
+func sortCategories(list []*CategoryResponse) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].SortOrder != list[j].SortOrder {
+			return list[i].SortOrder < list[j].SortOrder
+		}
+		return list[i].ID < list[j].ID
+	})
+	for _, c := range list {
+		sortCategories(c.Children)
+	}
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"campus/internal/modules/category/api"
+	"campus/internal/modules/category/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type CategoryController struct {
+	service services.CategoryService
+}
+
+func NewCategoryController(srv services.CategoryService) *CategoryController {
+	return &CategoryController{
+		service: srv,
+	}
+}
+
+// ListCategories 获取启用的分类树
+func (c *CategoryController) ListCategories(ctx *gin.Context) {
+	categories, err := c.service.ListCategories()
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, categories)
+}
+
+// AdminListCategories 管理员获取完整分类树
+func (c *CategoryController) AdminListCategories(ctx *gin.Context) {
+	categories, err := c.service.AdminListCategories()
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, categories)
+}
+
+// GetCategory 管理员获取分类详情
+func (c *CategoryController) GetCategory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效分类ID", err))
+		return
+	}
+
+	category, err := c.service.GetCategory(uint(id))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, category)
+}
+
+// CreateCategory 管理员创建分类
+func (c *CategoryController) CreateCategory(ctx *gin.Context) {
+	var req api.CreateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	category, err := c.service.CreateCategory(&req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "创建成功", category)
+}
+
+// UpdateCategory 管理员更新分类
+func (c *CategoryController) UpdateCategory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效分类ID", err))
+		return
+	}
+
+	var req api.UpdateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	category, err := c.service.UpdateCategory(uint(id), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "更新成功", category)
+}
+
+// DeleteCategory 管理员删除分类
+func (c *CategoryController) DeleteCategory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效分类ID", err))
+		return
+	}
+
+	if err := c.service.DeleteCategory(uint(id)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "删除成功", nil)
+}
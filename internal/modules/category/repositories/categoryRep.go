@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"gorm.io/gorm"
+)
+
+// CategoryText 商品的自由文本分类及使用该文本的商品数量
+type CategoryText struct {
+	Text  string
+	Count int64
+}
+
+type CategoryRepository interface {
+	Create(category *models.Category) error
+	GetByID(id uint) (*models.Category, error)
+	// List 获取全部分类，enabledOnly为true时只返回启用的分类
+	List(enabledOnly bool) ([]*models.Category, error)
+	// FindByName 按名称查找分类，不区分大小写
+	FindByName(name string) ([]*models.Category, error)
+	// ExistsSibling 判断parentID下是否已有同名分类，excludeID为需要排除的分类自身
+	ExistsSibling(name string, parentID *uint, excludeID uint) (bool, error)
+	// Update 保存分类的全部可修改字段，并同步更新商品冗余的分类名称
+	Update(category *models.Category) error
+	Delete(id uint) error
+	CountChildren(id uint) (int64, error)
+	CountProducts(id uint) (int64, error)
+	// UncategorizedTexts 统计尚未关联分类的商品使用的自由文本分类
+	UncategorizedTexts() ([]CategoryText, error)
+	// AssignProducts 将分类文本为text且尚未关联分类的商品关联到category，返回更新的商品数量
+	AssignProducts(text string, category *models.Category) (int64, error)
+}
+
+type CategoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &CategoryRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *CategoryRepositoryImpl) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *CategoryRepositoryImpl) GetByID(id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, id).Error
+	return &category, err
+}
+
+func (r *CategoryRepositoryImpl) List(enabledOnly bool) ([]*models.Category, error) {
+	var categories []*models.Category
+	query := r.db.Model(&models.Category{})
+	if enabledOnly {
+		query = query.Where("is_enabled = ?", true)
+	}
+	err := query.Order("sort_order ASC, id ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepositoryImpl) FindByName(name string) ([]*models.Category, error) {
+	var categories []*models.Category
+	err := r.db.Where("LOWER(name) = LOWER(?)", name).Order("id ASC").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepositoryImpl) ExistsSibling(name string, parentID *uint, excludeID uint) (bool, error) {
+	var count int64
+	query := r.db.Model(&models.Category{}).Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *CategoryRepositoryImpl) Update(category *models.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(category).
+			Select("name", "parent_id", "icon", "sort_order", "is_enabled").
+			Updates(category).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Product{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+}
+
+func (r *CategoryRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&models.Category{}, id).Error
+}
+
+func (r *CategoryRepositoryImpl) CountChildren(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *CategoryRepositoryImpl) CountProducts(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *CategoryRepositoryImpl) UncategorizedTexts() ([]CategoryText, error) {
+	var texts []CategoryText
+	err := r.db.Model(&models.Product{}).
+		Select("category AS text, COUNT(*) AS count").
+		Where("category_id IS NULL AND category <> ''").
+		Group("category").
+		Order("count DESC").
+		Scan(&texts).Error
+	return texts, err
+}
+
+func (r *CategoryRepositoryImpl) AssignProducts(text string, category *models.Category) (int64, error) {
+	result := r.db.Model(&models.Product{}).
+		Where("category_id IS NULL AND category = ?", text).
+		Updates(map[string]interface{}{
+			"category_id": category.ID,
+			"category":    category.Name,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,43 @@
+package category
+
+import (
+	"campus/internal/bootstrap"
+	"campus/internal/middleware"
+	"campus/internal/modules/category/controllers"
+	"campus/internal/modules/category/repositories"
+	"campus/internal/modules/category/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 注册category模块的所有路由
+func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
+	categoryRep := repositories.NewCategoryRepository(bootstrap.GetDB())
+	categoryController := controllers.NewCategoryController(services.NewCategoryService(categoryRep))
+
+	// 分类路由 - 发布和浏览商品时使用，无需认证
+	api.GET("/categories", categoryController.ListCategories)
+
+	// 管理员分类路由 - 需要管理员权限
+	adminCategoryGroup := api.Group("/admin/categories")
+	adminCategoryGroup.Use(middleware.JWTAuth())
+	adminCategoryGroup.Use(middleware.AuthorizeByRole("admin"))
+	registerAdminCategoryRoutes(adminCategoryGroup, categoryController)
+}
+
+// registerAdminCategoryRoutes 注册管理员分类相关路由
+func registerAdminCategoryRoutes(router *gin.RouterGroup, controller *controllers.CategoryController) {
+	// 获取完整分类树，包括停用的分类
+	router.GET("", middleware.AuthorizePermission("/api/v1/admin/categories", "GET"), controller.AdminListCategories)
+
+	// 获取分类详情
+	router.GET("/:id", middleware.AuthorizePermission("/api/v1/admin/categories/:id", "GET"), controller.GetCategory)
+
+	// 创建分类
+	router.POST("", middleware.AuthorizePermission("/api/v1/admin/categories", "POST"), controller.CreateCategory)
+
+	// 更新分类，包括调整层级、排序和启用状态
+	router.PUT("/:id", middleware.AuthorizePermission("/api/v1/admin/categories/:id", "PUT"), controller.UpdateCategory)
+
+	// 删除分类
+	router.DELETE("/:id", middleware.AuthorizePermission("/api/v1/admin/categories/:id", "DELETE"), controller.DeleteCategory)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/category/api"
+	"campus/internal/modules/category/repositories"
+	"campus/internal/utils/errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CategoryService interface {
+	// ListCategories 获取启用的分类树，用于发布和筛选商品
+	ListCategories() ([]*api.CategoryResponse, error)
+
+	// 管理员接口
+	AdminListCategories() ([]*api.CategoryResponse, error)
+	GetCategory(id uint) (*api.CategoryResponse, error)
+	CreateCategory(req *api.CreateCategoryRequest) (*api.CategoryResponse, error)
+	UpdateCategory(id uint, req *api.UpdateCategoryRequest) (*api.CategoryResponse, error)
+	DeleteCategory(id uint) error
+
+	// MigrateProductCategories 将商品的自由文本分类映射到分类表
+	MigrateProductCategories(opts *MigrateOptions) (*api.CategoryMigrationReport, error)
+}
+
+// MigrateOptions 自由文本分类迁移选项
+type MigrateOptions struct {
+	Mapping map[string]uint // 分类文本到分类ID的映射，优先于按名称匹配
+	Create  bool            // 没有同名分类时新建同名的一级分类
+	DryRun  bool            // 只生成报告，不修改数据
+}
+
+type CategoryServiceImpl struct {
+	repository repositories.CategoryRepository
+}
+
+func NewCategoryService(categoryRep repositories.CategoryRepository) CategoryService {
+	return &CategoryServiceImpl{
+		repository: categoryRep,
+	}
+}
+
+func (s *CategoryServiceImpl) ListCategories() ([]*api.CategoryResponse, error) {
+	categories, err := s.repository.List(true)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取分类列表失败", err)
+	}
+	return api.BuildCategoryTree(categories), nil
+}
+
+// AdminListCategories 获取包括停用分类在内的完整分类树
+func (s *CategoryServiceImpl) AdminListCategories() ([]*api.CategoryResponse, error) {
+	categories, err := s.repository.List(false)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取分类列表失败", err)
+	}
+	return api.BuildCategoryTree(categories), nil
+}
+
+func (s *CategoryServiceImpl) GetCategory(id uint) (*api.CategoryResponse, error) {
+	category, err := s.getCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	return api.ConvertToCategoryResponse(category), nil
+}
+
+func (s *CategoryServiceImpl) CreateCategory(req *api.CreateCategoryRequest) (*api.CategoryResponse, error) {
+	category := &models.Category{
+		Name:      strings.TrimSpace(req.Name),
+		ParentID:  req.ParentID,
+		Icon:      req.Icon,
+		SortOrder: req.SortOrder,
+		IsEnabled: req.IsEnabled == nil || *req.IsEnabled,
+	}
+	if category.Name == "" {
+		return nil, errors.NewBadRequestError("分类名称不能为空", nil)
+	}
+	if category.ParentID != nil {
+		if _, err := s.getParent(*category.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkSiblingName(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Create(category); err != nil {
+		return nil, errors.NewInternalServerError("创建分类失败", err)
+	}
+	return api.ConvertToCategoryResponse(category), nil
+}
+
+// UpdateCategory 更新分类。修改名称时同步更新商品的分类名称，修改父分类时不允许形成环
+func (s *CategoryServiceImpl) UpdateCategory(id uint, req *api.UpdateCategoryRequest) (*api.CategoryResponse, error) {
+	category, err := s.getCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+		if category.Name == "" {
+			return nil, errors.NewBadRequestError("分类名称不能为空", nil)
+		}
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			if err := s.checkParent(category.ID, *req.ParentID); err != nil {
+				return nil, err
+			}
+			parentID := *req.ParentID
+			category.ParentID = &parentID
+		}
+	}
+	if req.Icon != nil {
+		category.Icon = *req.Icon
+	}
+	if req.SortOrder != nil {
+		category.SortOrder = *req.SortOrder
+	}
+	if req.IsEnabled != nil {
+		category.IsEnabled = *req.IsEnabled
+	}
+	if err := s.checkSiblingName(category); err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.Update(category); err != nil {
+		return nil, errors.NewInternalServerError("更新分类失败", err)
+	}
+	return api.ConvertToCategoryResponse(category), nil
+}
+
+// DeleteCategory 删除分类，存在子分类或商品的分类不能删除，可以改为停用
+func (s *CategoryServiceImpl) DeleteCategory(id uint) error {
+	if _, err := s.getCategory(id); err != nil {
+		return err
+	}
+
+	children, err := s.repository.CountChildren(id)
+	if err != nil {
+		return errors.NewInternalServerError("查询子分类失败", err)
+	}
+	if children > 0 {
+		return errors.NewConflictError("该分类下还有子分类，不能删除", nil)
+	}
+
+	products, err := s.repository.CountProducts(id)
+	if err != nil {
+		return errors.NewInternalServerError("查询分类商品失败", err)
+	}
+	if products > 0 {
+		return errors.NewConflictError(fmt.Sprintf("该分类下还有%d件商品，不能删除，可以停用该分类", products), nil)
+	}
+
+	if err := s.repository.Delete(id); err != nil {
+		return errors.NewInternalServerError("删除分类失败", err)
+	}
+	return nil
+}
+
+// MigrateProductCategories 逐个处理尚未关联分类的商品分类文本：
+// 先查映射表，再按名称匹配唯一的分类，都没有时按选项新建一级分类，否则保留为未映射
+func (s *CategoryServiceImpl) MigrateProductCategories(opts *MigrateOptions) (*api.CategoryMigrationReport, error) {
+	texts, err := s.repository.UncategorizedTexts()
+	if err != nil {
+		return nil, errors.NewInternalServerError("统计商品分类失败", err)
+	}
+
+	report := &api.CategoryMigrationReport{Items: make([]api.CategoryMigrationItem, 0, len(texts))}
+	created := make(map[string]*models.Category) // 本次迁移新建的分类，按小写名称索引
+	for _, t := range texts {
+		item := api.CategoryMigrationItem{Text: t.Text, Products: t.Count}
+		name := strings.TrimSpace(t.Text)
+
+		category, reason, err := s.matchCategory(name, opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		if category == nil && reason == "" {
+			category = created[strings.ToLower(name)]
+		}
+		if category == nil && reason == "" {
+			if !opts.Create {
+				reason = "没有同名分类"
+			} else {
+				category = &models.Category{Name: name, IsEnabled: true}
+				if !opts.DryRun {
+					if err := s.repository.Create(category); err != nil {
+						return nil, errors.NewInternalServerError("创建分类失败", err)
+					}
+				}
+				created[strings.ToLower(name)] = category
+				item.Created = true
+			}
+		}
+
+		if category == nil {
+			item.Reason = reason
+			report.Skipped += t.Count
+			report.Items = append(report.Items, item)
+			continue
+		}
+
+		item.CategoryID = category.ID
+		item.Category = category.Name
+		if !opts.DryRun {
+			if _, err := s.repository.AssignProducts(t.Text, category); err != nil {
+				return nil, errors.NewInternalServerError("关联商品分类失败", err)
+			}
+		}
+		report.Migrated += t.Count
+		report.Items = append(report.Items, item)
+	}
+	return report, nil
+}
+
+// matchCategory 按映射表或名称查找分类文本对应的分类，无法确定时返回原因
+func (s *CategoryServiceImpl) matchCategory(name string, mapping map[string]uint) (*models.Category, string, error) {
+	if name == "" {
+		return nil, "分类文本为空", nil
+	}
+	if id, ok := mapping[name]; ok {
+		category, err := s.repository.GetByID(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, fmt.Sprintf("映射的分类%d不存在", id), nil
+			}
+			return nil, "", errors.NewInternalServerError("查询分类失败", err)
+		}
+		return category, "", nil
+	}
+
+	categories, err := s.repository.FindByName(name)
+	if err != nil {
+		return nil, "", errors.NewInternalServerError("查询分类失败", err)
+	}
+	if len(categories) > 1 {
+		return nil, "存在多个同名分类，请在映射表中指定", nil
+	}
+	if len(categories) == 1 {
+		return categories[0], "", nil
+	}
+	return nil, "", nil
+}
+
+// checkParent 校验父分类存在，且不是分类自身或其子孙分类
+func (s *CategoryServiceImpl) checkParent(id, parentID uint) error {
+	for current := parentID; ; {
+		if current == id {
+			return errors.NewBadRequestError("不能将分类移动到自身或其子分类下", nil)
+		}
+		parent, err := s.getParent(current)
+		if err != nil {
+			return err
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		current = *parent.ParentID
+	}
+}
+
+func (s *CategoryServiceImpl) checkSiblingName(category *models.Category) error {
+	exists, err := s.repository.ExistsSibling(category.Name, category.ParentID, category.ID)
+	if err != nil {
+		return errors.NewInternalServerError("查询分类失败", err)
+	}
+	if exists {
+		return errors.NewConflictError("同级分类中已存在该名称", nil)
+	}
+	return nil
+}
+
+func (s *CategoryServiceImpl) getParent(id uint) (*models.Category, error) {
+	parent, err := s.repository.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewBadRequestError("父分类不存在", err)
+		}
+		return nil, errors.NewInternalServerError("查询父分类失败", err)
+	}
+	return parent, nil
+}
+
+func (s *CategoryServiceImpl) getCategory(id uint) (*models.Category, error) {
+	category, err := s.repository.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("分类", err)
+		}
+		return nil, errors.NewInternalServerError("查询分类失败", err)
+	}
+	return category, nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/category/api"
+	"campus/internal/modules/category/repositories"
+	"campus/internal/utils/testdb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*CategoryServiceImpl, *gorm.DB) {
+	db := testdb.New(t, &models.User{}, &models.Category{}, &models.Product{})
+
+	seller := models.User{Username: "seller", Password: "x", Email: "seller@example.com"}
+	require.NoError(t, db.Create(&seller).Error)
+	for _, text := range []string{"数码", "数码", "Books", "电子产品", "其他", ""} {
+		require.NoError(t, db.Create(&models.Product{Title: "商品", Price: 1, Category: text, UserID: seller.ID}).Error)
+	}
+
+	return &CategoryServiceImpl{repository: repositories.NewCategoryRepository(db)}, db
+}
+
+func createCategory(t *testing.T, s *CategoryServiceImpl, name string, parentID *uint) *api.CategoryResponse {
+	category, err := s.CreateCategory(&api.CreateCategoryRequest{Name: name, ParentID: parentID})
+	require.NoError(t, err)
+	return category
+}
+
+func productCategories(t *testing.T, db *gorm.DB) map[string]*uint {
+	var products []models.Product
+	require.NoError(t, db.Order("id ASC").Find(&products).Error)
+	out := make(map[string]*uint)
+	for _, p := range products {
+		out[p.Category] = p.CategoryID
+	}
+	return out
+}
+
+func TestMigrateProductCategories(t *testing.T) {
+	s, db := newTestService(t)
+	digital := createCategory(t, s, "数码", nil)
+	books := createCategory(t, s, "books", nil)
+
+	// 试运行不修改数据
+	report, err := s.MigrateProductCategories(&MigrateOptions{
+		Mapping: map[string]uint{"电子产品": digital.ID},
+		Create:  true,
+		DryRun:  true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), report.Migrated)
+	assert.Nil(t, productCategories(t, db)["数码"])
+
+	report, err = s.MigrateProductCategories(&MigrateOptions{
+		Mapping: map[string]uint{"电子产品": digital.ID},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), report.Migrated)
+	assert.Equal(t, int64(1), report.Skipped)
+
+	// 名称匹配不区分大小写，商品的分类名称统一为分类表中的名称
+	categories := productCategories(t, db)
+	assert.Equal(t, books.ID, *categories["books"])
+	assert.Equal(t, digital.ID, *categories["数码"])
+	assert.NotContains(t, categories, "电子产品")
+	assert.Nil(t, categories["其他"])
+	assert.Nil(t, categories[""])
+
+	// 再次迁移只处理剩余的文本
+	report, err = s.MigrateProductCategories(&MigrateOptions{Create: true})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 1)
+	assert.True(t, report.Items[0].Created)
+	assert.NotNil(t, productCategories(t, db)["其他"])
+}
+
+func TestUpdateCategoryHierarchy(t *testing.T) {
+	s, db := newTestService(t)
+	digital := createCategory(t, s, "数码", nil)
+	phones := createCategory(t, s, "手机", &digital.ID)
+	_, err := s.MigrateProductCategories(&MigrateOptions{})
+	require.NoError(t, err)
+
+	// 不能移动到自己的子分类下
+	_, err = s.UpdateCategory(digital.ID, &api.UpdateCategoryRequest{ParentID: &phones.ID})
+	assert.Error(t, err)
+
+	// 同级分类不能重名
+	_, err = s.CreateCategory(&api.CreateCategoryRequest{Name: "手机", ParentID: &digital.ID})
+	assert.Error(t, err)
+
+	// 修改名称同步更新商品的分类名称
+	name := "数码产品"
+	_, err = s.UpdateCategory(digital.ID, &api.UpdateCategoryRequest{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, digital.ID, *productCategories(t, db)["数码产品"])
+
+	// 停用的分类及其子分类不出现在前台分类树中
+	disabled := false
+	_, err = s.UpdateCategory(digital.ID, &api.UpdateCategoryRequest{IsEnabled: &disabled})
+	require.NoError(t, err)
+	tree, err := s.ListCategories()
+	require.NoError(t, err)
+	assert.Empty(t, tree)
+
+	tree, err = s.AdminListCategories()
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Len(t, tree[0].Children, 1)
+	assert.Equal(t, "手机", tree[0].Children[0].Name)
+
+	// 有子分类或商品的分类不能删除
+	assert.Error(t, s.DeleteCategory(digital.ID))
+	assert.NoError(t, s.DeleteCategory(phones.ID))
+}
@@ -13,4 +13,10 @@ type LatestProductsRequest struct {
 // ActivitiesRequest 系统活动请求
 type ActivitiesRequest struct {
 	Limit int `form:"limit" json:"limit"` // 限制数量
-} 
\ No newline at end of file
+}
+
+// ProductViewStatsRequest 商品浏览统计请求
+type ProductViewStatsRequest struct {
+	Days  int `form:"days" json:"days"`   // 统计最近多少天的浏览量
+	Limit int `form:"limit" json:"limit"` // 限制数量
+}
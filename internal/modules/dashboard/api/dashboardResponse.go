@@ -61,4 +61,16 @@ type ActivityItem struct {
 }
 
 // ActivitiesResponse 系统活动响应
-type ActivitiesResponse []ActivityItem 
\ No newline at end of file
+type ActivitiesResponse []ActivityItem
+
+// ProductViewStatsItem 商品浏览统计项
+type ProductViewStatsItem struct {
+	ID          uint   `json:"id"`          // 商品ID
+	Title       string `json:"title"`       // 商品标题
+	Status      string `json:"status"`      // 商品状态
+	TotalViews  int64  `json:"totalViews"`  // 累计浏览次数
+	RecentViews int64  `json:"recentViews"` // 统计期间的浏览次数
+}
+
+// ProductViewStatsResponse 商品浏览统计响应，按统计期间的浏览次数降序
+type ProductViewStatsResponse []ProductViewStatsItem
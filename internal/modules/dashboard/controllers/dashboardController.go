@@ -105,3 +105,30 @@ func (c *DashboardController) GetActivities(ctx *gin.Context) {
 	}
 	response.SuccessWithMessage(ctx, "获取成功", activities)
 }
+
+// GetProductViewStats 获取商品浏览统计
+func (c *DashboardController) GetProductViewStats(ctx *gin.Context) {
+	var req api.ProductViewStatsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	// 默认统计最近7天，最多一年
+	days := req.Days
+	if days <= 0 || days > 365 {
+		days = 7
+	}
+	// 默认返回10条
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	stats, err := c.service.GetProductViewStats(days, limit)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+	response.SuccessWithMessage(ctx, "获取成功", stats)
+}
@@ -29,4 +29,7 @@ func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
 	
 	// 系统活动
 	dashboardGroup.GET("/activities", middleware.AuthorizePermission("/api/v1/admin/dashboard/activities", "GET"), dashboardController.GetActivities)
+
+	// 商品浏览统计
+	dashboardGroup.GET("/product-views", middleware.AuthorizePermission("/api/v1/admin/dashboard/product-views", "GET"), dashboardController.GetProductViewStats)
 } 
\ No newline at end of file
@@ -7,6 +7,7 @@ import (
 	"campus/internal/utils/errors"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 	"gorm.io/gorm"
 )
@@ -23,6 +24,8 @@ type DashboardService interface {
 	GetLatestProducts(limit int) (api.LatestProductsResponse, error)
 	// 获取系统活动
 	GetActivities(limit int) (api.ActivitiesResponse, error)
+	// 获取最近days天浏览最多的商品
+	GetProductViewStats(days, limit int) (api.ProductViewStatsResponse, error)
 }
 
 // dashboardService 仪表盘服务实现
@@ -213,39 +216,76 @@ func (s *dashboardService) GetProductTrend(timeRange string) (api.ProductTrendRe
 	return result, nil
 }
 
-// GetCategoryStats 获取商品分类统计
+// GetCategoryStats 获取商品分类统计，子分类的商品计入所属的一级分类，未关联分类的商品计入“未分类”
 func (s *dashboardService) GetCategoryStats() (api.CategoryStatsResponse, error) {
-	var result api.CategoryStatsResponse
-
-	// 查询所有分类及其商品数量
-	rows, err := s.db.Model(&models.Product{}).
-		Select("category, COUNT(*) as count").
-		Where("category != ''").
-		Group("category").
-		Order("count DESC").
-		Rows()
-	
+	var rows []struct {
+		CategoryID *uint
+		Count      int
+	}
+	err := s.db.Model(&models.Product{}).
+		Select("category_id, COUNT(*) AS count").
+		Group("category_id").
+		Scan(&rows).Error
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取分类统计失败", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var category string
-		var count int
-		if err := rows.Scan(&category, &count); err != nil {
-			return nil, errors.NewInternalServerError("解析分类统计失败", err)
+	var categories []models.Category
+	if err := s.db.Unscoped().Find(&categories).Error; err != nil {
+		return nil, errors.NewInternalServerError("获取分类列表失败", err)
+	}
+	byID := make(map[uint]models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	// 按一级分类汇总，保持首次出现的顺序以便相同数量时结果稳定
+	counts := make(map[string]int)
+	var names []string
+	for _, row := range rows {
+		name := uncategorizedName
+		if row.CategoryID != nil {
+			if root, ok := rootCategory(byID, *row.CategoryID); ok {
+				name = root.Name
+			}
 		}
-		
+		if _, ok := counts[name]; !ok {
+			names = append(names, name)
+		}
+		counts[name] += row.Count
+	}
+
+	result := make(api.CategoryStatsResponse, 0, len(names))
+	for _, name := range names {
 		result = append(result, api.CategoryStatsItem{
-			Name:  category,
-			Value: count,
+			Name:  name,
+			Value: counts[name],
 		})
 	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Value > result[j].Value
+	})
 
 	return result, nil
 }
 
+// 未关联分类的商品在分类统计中的名称
+const uncategorizedName = "未分类"
+
+// rootCategory 沿父分类向上查找一级分类
+func rootCategory(byID map[uint]models.Category, id uint) (models.Category, bool) {
+	category, ok := byID[id]
+	// 层级数不会超过分类总数，限制循环次数以防数据中存在环
+	for depth := 0; ok && category.ParentID != nil && depth < len(byID); depth++ {
+		parent, found := byID[*category.ParentID]
+		if !found {
+			break
+		}
+		category = parent
+	}
+	return category, ok
+}
+
 // GetLatestProducts 获取最新商品
 func (s *dashboardService) GetLatestProducts(limit int) (api.LatestProductsResponse, error) {
 	var result api.LatestProductsResponse
@@ -394,4 +434,23 @@ func getTimeAgo(t time.Time) string {
 	default:
 		return t.Format("2006-01-02")
 	}
-} 
\ No newline at end of file
+}
+
+// GetProductViewStats 获取最近days天浏览最多的商品，浏览次数按天统计，不包含尚未写入数据库的计数
+func (s *dashboardService) GetProductViewStats(days, limit int) (api.ProductViewStatsResponse, error) {
+	since := time.Now().AddDate(0, 0, -(days - 1)).Format("2006-01-02")
+
+	result := api.ProductViewStatsResponse{}
+	err := s.db.Table("product_daily_views AS v").
+		Select("products.id, products.title, products.status, products.view_count AS total_views, SUM(v.views) AS recent_views").
+		Joins("JOIN products ON products.id = v.product_id AND products.deleted_at IS NULL").
+		Where("v.day >= ?", since).
+		Group("products.id, products.title, products.status, products.view_count").
+		Order("recent_views DESC").
+		Limit(limit).
+		Scan(&result).Error
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取商品浏览统计失败", err)
+	}
+	return result, nil
+}
@@ -1,11 +1,20 @@
 package api
 
+import (
+	"campus/internal/utils/pagination"
+	"errors"
+)
+
+// ErrUnalignedOffset 未传入游标时offset必须是limit的整数倍
+var ErrUnalignedOffset = errors.New("offset必须是limit的整数倍")
+
 // SendMessageRequest 发送消息请求
 type SendMessageRequest struct {
 	ReceiverID uint   `json:"receiver_id" binding:"required"`                     // 接收者ID
 	Content    string `json:"content" binding:"required"`                         // 消息内容
 	ProductID  uint   `json:"product_id,omitempty"`                               // 商品ID（可选）
 	Type       string `json:"type" binding:"omitempty,oneof=text system product"` // 消息类型
+	ClientID   string `json:"client_id,omitempty" binding:"omitempty,max=64"`     // 客户端生成的消息ID，重发时相同ID的消息只保存一次
 }
 
 // MarkReadRequest 标记消息已读请求
@@ -13,11 +22,45 @@ type MarkReadRequest struct {
 	MessageIDs []uint `json:"message_ids"` // 消息ID列表，为空则标记所有
 }
 
-// MessageQueryParams 消息查询参数
+// ReadUpToRequest 通过WebSocket标记已读请求
+type ReadUpToRequest struct {
+	ContactID     uint `json:"contact_id" binding:"required"`      // 联系人ID
+	LastMessageID uint `json:"last_message_id" binding:"required"` // 该消息及之前收到的消息标记为已读
+}
+
+// TypingRequest 通过WebSocket发送输入状态请求
+type TypingRequest struct {
+	ContactID uint `json:"contact_id" binding:"required"` // 联系人ID
+	Typing    bool `json:"typing"`                        // 是否正在输入，停止输入时为false
+}
+
+// PresenceSubscribeRequest 通过WebSocket订阅在线状态请求，每次订阅替换之前的订阅
+type PresenceSubscribeRequest struct {
+	UserIDs []uint `json:"user_ids" binding:"max=200"` // 联系人ID列表，只能订阅有过消息往来的用户
+}
+
+// UpdateReadCursorRequest 更新设备已读位置请求，设备ID取自路径
+type UpdateReadCursorRequest struct {
+	LastMessageID uint `json:"last_message_id" binding:"required"` // 已读到的最后一条消息ID
+}
+
+// MessageQueryParams 消息查询参数，联系人ID取自路径
 type MessageQueryParams struct {
-	ContactID uint `form:"contact_id" binding:"required"` // 联系人ID
-	Limit     int  `form:"limit,default=20"`              // 每页消息数量
-	Offset    int  `form:"offset,default=0"`              // 偏移量
+	Cursor string `form:"cursor"`                                   // 分页游标，传入时使用游标分页
+	Limit  uint   `form:"limit,default=20" binding:"min=1,max=100"` // 每页消息数量
+	Offset uint   `form:"offset,default=0"`                         // 偏移量，未传入游标时按limit换算为页码，必须是limit的整数倍
+}
+
+// Params 解析分页参数。未传入游标时保持原有的limit/offset分页，offset不是limit的整数倍时返回 ErrUnalignedOffset
+func (q MessageQueryParams) Params() (pagination.Params, error) {
+	var page uint
+	if q.Cursor == "" {
+		if q.Offset%q.Limit != 0 {
+			return pagination.Params{}, ErrUnalignedOffset
+		}
+		page = q.Offset/q.Limit + 1
+	}
+	return pagination.NewParams(q.Cursor, page, q.Limit)
 }
 
 // WebSocketAuthRequest WebSocket认证请求
@@ -38,6 +81,7 @@ type DeleteConversationRequest struct {
 
 // AdminMessageListRequest 管理员获取消息列表请求
 type AdminMessageListRequest struct {
+	Cursor    string `json:"cursor" form:"cursor"` // 分页游标，传入时使用游标分页
 	Page      uint   `json:"page" form:"page"`
 	Size      uint   `json:"size" form:"size"`
 	Search    string `json:"search" form:"search"`
@@ -57,6 +101,7 @@ type AdminConversationListRequest struct {
 type AdminMessageHistoryRequest struct {
 	User1ID   uint   `json:"user1_id" form:"user1_id" binding:"required"` // 用户1的ID
 	User2ID   uint   `json:"user2_id" form:"user2_id" binding:"required"` // 用户2的ID
+	Cursor    string `json:"cursor" form:"cursor"`                        // 分页游标，传入时使用游标分页
 	Page      uint   `json:"page" form:"page"`                            // 页码
 	Size      uint   `json:"size" form:"size"`                            // 每页数量
 	StartDate string `json:"start_date" form:"start_date"`                // 开始日期
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageQueryParamsOffset(t *testing.T) {
+	p, err := MessageQueryParams{Limit: 20, Offset: 40}.Params()
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.Page)
+	assert.Equal(t, 40, p.Offset())
+
+	// offset不是limit的整数倍时无法换算为页码
+	_, err = MessageQueryParams{Limit: 20, Offset: 30}.Params()
+	assert.ErrorIs(t, err, ErrUnalignedOffset)
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"time"
 )
 
@@ -11,26 +12,54 @@ type MessageResponse struct {
 	SenderID   uint      `json:"sender_id"`            // 发送者ID
 	ReceiverID uint      `json:"receiver_id"`          // 接收者ID
 	Content    string    `json:"content"`              // 内容
+	Type       string    `json:"type"`                 // 消息类型
 	IsRead     bool      `json:"is_read"`              // 是否已读
 	CreatedAt  time.Time `json:"created_at"`           // 创建时间
 	ProductID  uint      `json:"product_id,omitempty"` // 商品ID
+	ClientID   string    `json:"client_id,omitempty"`  // 客户端生成的消息ID
+	Withdrawn  bool      `json:"is_withdrawn"`         // 是否已撤回，已撤回的消息不返回内容
+}
+
+// ReadReceipt 已读回执，推送给消息的发送者。未指定LastMessageID和MessageIDs时表示全部已读
+type ReadReceipt struct {
+	ReaderID      uint      `json:"reader_id"`                 // 阅读者ID
+	LastMessageID uint      `json:"last_message_id,omitempty"` // 该消息及之前的消息已读
+	MessageIDs    []uint    `json:"message_ids,omitempty"`     // 已读的消息ID
+	ReadAt        time.Time `json:"read_at"`                   // 阅读时间
+}
+
+// TypingEvent 输入状态，转发给会话的另一方
+type TypingEvent struct {
+	UserID uint `json:"user_id"` // 正在输入的用户ID
+	Typing bool `json:"typing"`  // 是否正在输入
+}
+
+// MessageEvent 消息被撤回或删除的事件。撤回推送给会话双方，用户删除只推送给删除者自己的设备
+type MessageEvent struct {
+	EventID    uint `json:"event_id"`    // 变更序号，重连时通过last_event_id传入以补发之后的事件
+	MessageID  uint `json:"message_id"`  // 消息ID
+	SenderID   uint `json:"sender_id"`   // 发送者ID
+	ReceiverID uint `json:"receiver_id"` // 接收者ID
+	OperatorID uint `json:"operator_id"` // 撤回或删除消息的用户ID
 }
 
 // ContactResponse 联系人响应
 type ContactResponse struct {
-	ID           uint      `json:"id"`                      // 用户ID
-	Username     string    `json:"username"`                // 用户名
-	Avatar       string    `json:"avatar"`                  // 头像
-	LastMessage  string    `json:"last_message"`            // 最后一条消息
-	LastTime     time.Time `json:"last_time"`               // 最后消息时间
-	UnreadCount  int       `json:"unread_count"`            // 未读消息数
-	ProductCount int       `json:"product_count,omitempty"` // 商品数量
+	ID           uint       `json:"id"`                      // 用户ID
+	Username     string     `json:"username"`                // 用户名
+	Avatar       string     `json:"avatar"`                  // 头像
+	LastMessage  string     `json:"last_message"`            // 最后一条消息
+	LastTime     time.Time  `json:"last_time"`               // 最后消息时间
+	UnreadCount  int        `json:"unread_count"`            // 未读消息数
+	ProductCount int        `json:"product_count,omitempty"` // 商品数量
+	Online       bool       `json:"online"`                  // 是否在线
+	LastSeen     *time.Time `json:"last_seen,omitempty"`     // 离线时为最后在线时间
 }
 
 // MessageListResponse 消息列表响应
 type MessageListResponse struct {
-	Total    int               `json:"total"`    // 总消息数
 	Messages []MessageResponse `json:"messages"` // 消息列表
+	pagination.Info
 }
 
 // ContactListResponse 联系人列表响应
@@ -38,6 +67,20 @@ type ContactListResponse struct {
 	Contacts []ContactResponse `json:"contacts"` // 联系人列表
 }
 
+// DeviceResponse 设备响应
+type DeviceResponse struct {
+	DeviceID      string     `json:"device_id"`              // 设备ID
+	Online        bool       `json:"online"`                 // 是否在线
+	ConnectedAt   *time.Time `json:"connected_at,omitempty"` // 本次连接时间
+	LastMessageID uint       `json:"last_message_id"`        // 已读到的最后一条消息ID
+	UpdatedAt     *time.Time `json:"updated_at,omitempty"`   // 已读位置更新时间
+}
+
+// DeviceListResponse 设备列表响应
+type DeviceListResponse struct {
+	Devices []DeviceResponse `json:"devices"` // 设备列表
+}
+
 // ConversationResponse 会话响应
 type ConversationResponse struct {
 	ID       uint   `json:"id"`       // 会话ID
@@ -96,8 +139,8 @@ type AdminMessageItem struct {
 
 // AdminMessageListResponse 管理员消息列表响应
 type AdminMessageListResponse struct {
-	Total int64              `json:"total"`
-	List  []AdminMessageItem `json:"list"`
+	List []AdminMessageItem `json:"list"`
+	pagination.Info
 }
 
 // AdminConversationItem 管理员会话列表项
@@ -137,21 +180,54 @@ type AdminMessageHistoryItem struct {
 
 // AdminMessageHistoryResponse 管理员消息历史响应
 type AdminMessageHistoryResponse struct {
-	Total int64                     `json:"total"`
-	List  []AdminMessageHistoryItem `json:"list"`
+	List []AdminMessageHistoryItem `json:"list"`
+	pagination.Info
 }
 
-// ToMessageResponse 将Message模型转换为响应
+// ToMessageResponse 将Message模型转换为响应，已撤回的消息不返回内容和关联商品
 func ToMessageResponse(msg *models.Message) MessageResponse {
+	if msg.IsWithdrawn {
+		return MessageResponse{
+			ID:         msg.ID,
+			SenderID:   msg.SenderID,
+			ReceiverID: msg.ReceiverID,
+			Type:       msg.Type,
+			IsRead:     msg.IsRead,
+			CreatedAt:  msg.CreatedAt,
+			ClientID:   clientID(msg),
+			Withdrawn:  true,
+		}
+	}
 	return MessageResponse{
 		ID:         msg.ID,
 		SenderID:   msg.SenderID,
 		ReceiverID: msg.ReceiverID,
 		Content:    msg.Content,
+		Type:       msg.Type,
 		IsRead:     msg.IsRead,
 		CreatedAt:  msg.CreatedAt,
 		ProductID:  msg.ProductID,
+		ClientID:   clientID(msg),
+	}
+}
+
+// ToMessageEvent 将消息变更事件转换为推送数据
+func ToMessageEvent(event *models.MessageEvent) MessageEvent {
+	return MessageEvent{
+		EventID:    event.ID,
+		MessageID:  event.MessageID,
+		SenderID:   event.SenderID,
+		ReceiverID: event.ReceiverID,
+		OperatorID: event.OperatorID,
+	}
+}
+
+// clientID 返回消息的客户端ID，未设置时为空字符串
+func clientID(msg *models.Message) string {
+	if msg.ClientID == nil {
+		return ""
 	}
+	return *msg.ClientID
 }
 
 // ToMessageResponseList 将消息模型列表转换为响应
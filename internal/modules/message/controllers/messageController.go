@@ -5,6 +5,7 @@ import (
 	"campus/internal/modules/message/services"
 	"campus/internal/utils/errors"
 	"campus/internal/utils/response"
+	"campus/internal/websocket"
 	"github.com/gin-gonic/gin"
 	"strconv"
 )
@@ -65,11 +66,23 @@ func (c *MessageController) GetMessages(ctx *gin.Context) {
 	}
 
 	// 获取分页参数
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	var query api.MessageQueryParams
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+	params, err := query.Params()
+	if err == api.ErrUnalignedOffset {
+		response.HandleError(ctx, errors.NewValidationError("offset必须是limit的整数倍", err))
+		return
+	}
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
 
 	// 请求消息列表
-	result, err := c.service.GetMessagesByContact(userID.(uint), uint(contactID), limit, offset)
+	result, err := c.service.GetMessagesByContact(userID.(uint), uint(contactID), params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -219,6 +232,99 @@ func (c *MessageController) GetLastMessage(ctx *gin.Context) {
 	response.Success(ctx, message)
 }
 
+// GetDevices 获取当前用户的设备列表
+func (c *MessageController) GetDevices(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	result, err := c.service.GetDevices(userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, result)
+}
+
+// UpdateReadCursor 更新设备的已读位置
+func (c *MessageController) UpdateReadCursor(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	deviceID := ctx.Param("deviceId")
+	if !websocket.ValidDeviceID(deviceID) {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的设备ID", nil))
+		return
+	}
+
+	var req api.UpdateReadCursorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	if err := c.service.UpdateReadCursor(userID.(uint), deviceID, &req); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "已读位置已更新", nil)
+}
+
+// WithdrawMessage 撤回自己发送的消息
+func (c *MessageController) WithdrawMessage(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	messageID, err := strconv.ParseUint(ctx.Param("messageId"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的消息ID", err))
+		return
+	}
+
+	if err := c.service.WithdrawMessage(userID.(uint), uint(messageID)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "消息已撤回", nil)
+}
+
+// DeleteUserMessage 删除会话中的消息
+func (c *MessageController) DeleteUserMessage(ctx *gin.Context) {
+	// 获取当前用户ID
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	messageID, err := strconv.ParseUint(ctx.Param("messageId"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的消息ID", err))
+		return
+	}
+
+	if err := c.service.DeleteMessageByUser(userID.(uint), uint(messageID)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "删除成功", nil)
+}
+
 // GetAdminMessageList 管理员获取消息列表
 func (c *MessageController) GetAdminMessageList(ctx *gin.Context) {
 	var req api.AdminMessageListRequest
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"campus/internal/modules/message/api"
+	"campus/internal/modules/message/services"
+	"campus/internal/utils/errors"
+	"campus/internal/websocket"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// typingInterval 同一会话中相同的输入状态在该间隔内只转发一次，状态变化立即转发
+	typingInterval = 3 * time.Second
+	// maxTypingEntries 节流记录超过该数量时清理过期记录
+	maxTypingEntries = 10000
+)
+
+// PresenceSubscriber 管理连接的在线状态订阅，由websocket.Manager实现
+type PresenceSubscriber interface {
+	Subscribe(c *websocket.Connection, userIDs []uint)
+}
+
+type typingKey struct {
+	from, to uint
+}
+
+type typingState struct {
+	typing bool
+	at     time.Time
+}
+
+// SocketHandler 处理WebSocket客户端帧，聊天消息和已读状态与REST接口使用相同的消息服务
+type SocketHandler struct {
+	service  services.MessageService
+	presence PresenceSubscriber
+	now      func() time.Time
+
+	mu     sync.Mutex
+	typing map[typingKey]typingState // 最近一次转发的输入状态
+}
+
+// NewSocketHandler 创建WebSocket帧处理器
+func NewSocketHandler(service services.MessageService, presence PresenceSubscriber) *SocketHandler {
+	return &SocketHandler{
+		service:  service,
+		presence: presence,
+		now:      time.Now,
+		typing:   make(map[typingKey]typingState),
+	}
+}
+
+// HandleFrame 按帧类型分发客户端帧
+func (h *SocketHandler) HandleFrame(c *websocket.Connection, frame *websocket.Frame) {
+	switch frame.Type {
+	case websocket.FrameMessageSend:
+		h.sendMessage(c, frame)
+	case websocket.FrameRead:
+		h.markRead(c, frame)
+	case websocket.FrameTyping:
+		h.relayTyping(c, frame)
+	case websocket.FramePresenceSub:
+		h.subscribePresence(c, frame)
+	default:
+		c.SendError(frame.ID, errors.NewBadRequestError("不支持的帧类型", nil))
+	}
+}
+
+// sendMessage 保存并推送聊天消息，帧ID作为客户端消息ID去重，保存后返回message.ack
+func (h *SocketHandler) sendMessage(c *websocket.Connection, frame *websocket.Frame) {
+	if frame.ID == "" {
+		c.SendError(frame.ID, errors.NewBadRequestError("消息帧缺少ID", nil))
+		return
+	}
+
+	var req api.SendMessageRequest
+	if err := bindFrame(frame, &req); err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+	req.ClientID = frame.ID
+
+	result, err := h.service.SendMessage(c.UserID, req)
+	if err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+	c.SendFrame(websocket.FrameMessageAck, frame.ID, result)
+}
+
+// markRead 标记联系人发来的消息已读到指定位置，并更新该设备的已读位置
+func (h *SocketHandler) markRead(c *websocket.Connection, frame *websocket.Frame) {
+	var req api.ReadUpToRequest
+	if err := bindFrame(frame, &req); err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+
+	if err := h.service.ReadUpTo(c.UserID, c.DeviceID, &req); err != nil {
+		c.SendError(frame.ID, err)
+	}
+}
+
+// relayTyping 转发输入状态，被节流的帧直接丢弃
+func (h *SocketHandler) relayTyping(c *websocket.Connection, frame *websocket.Frame) {
+	var req api.TypingRequest
+	if err := bindFrame(frame, &req); err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+	if !h.allowTyping(c.UserID, req.ContactID, req.Typing) {
+		return
+	}
+
+	if err := h.service.SendTyping(c.UserID, &req); err != nil {
+		c.SendError(frame.ID, err)
+	}
+}
+
+// allowTyping 节流同一会话中重复的输入状态
+func (h *SocketHandler) allowTyping(from, to uint, typing bool) bool {
+	now := h.now()
+	key := typingKey{from: from, to: to}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if last, ok := h.typing[key]; ok && last.typing == typing && now.Sub(last.at) < typingInterval {
+		return false
+	}
+	h.typing[key] = typingState{typing: typing, at: now}
+
+	if len(h.typing) > maxTypingEntries {
+		for k, state := range h.typing {
+			if now.Sub(state.at) >= typingInterval {
+				delete(h.typing, k)
+			}
+		}
+	}
+	return true
+}
+
+// subscribePresence 订阅联系人的在线状态，没有消息往来的用户被忽略。
+// 先订阅再返回当前状态，订阅期间发生的变化不会被当前状态覆盖为过期数据
+func (h *SocketHandler) subscribePresence(c *websocket.Connection, frame *websocket.Frame) {
+	var req api.PresenceSubscribeRequest
+	if err := bindFrame(frame, &req); err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+
+	contactIDs, err := h.service.FilterContacts(c.UserID, req.UserIDs)
+	if err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+	h.presence.Subscribe(c, contactIDs)
+
+	presence, err := h.service.GetPresence(contactIDs)
+	if err != nil {
+		c.SendError(frame.ID, err)
+		return
+	}
+	c.SendFrame(websocket.FramePresence, frame.ID, presence)
+}
+
+// bindFrame 解析帧数据并按binding标签校验
+func bindFrame(frame *websocket.Frame, obj interface{}) error {
+	if err := json.Unmarshal(frame.Data, obj); err != nil {
+		return errors.NewValidationError("请求参数错误", err)
+	}
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return errors.NewValidationError("请求参数错误", err)
+	}
+	return nil
+}
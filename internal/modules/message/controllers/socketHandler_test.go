@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowTypingThrottlesRepeatedState(t *testing.T) {
+	h := NewSocketHandler(nil, nil)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	assert.True(t, h.allowTyping(1, 2, true))
+	assert.False(t, h.allowTyping(1, 2, true))
+	// 其他会话和反方向不受影响
+	assert.True(t, h.allowTyping(1, 3, true))
+	assert.True(t, h.allowTyping(2, 1, true))
+	// 状态变化立即转发
+	assert.True(t, h.allowTyping(1, 2, false))
+	assert.True(t, h.allowTyping(1, 2, true))
+
+	now = now.Add(typingInterval)
+	assert.True(t, h.allowTyping(1, 2, true))
+}
@@ -2,8 +2,10 @@ package repositories
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -12,40 +14,73 @@ type MessageRepository interface {
 	// Create 创建消息
 	Create(message *models.Message) error
 
-	// GetMessages 获取消息列表
-	GetMessages(userID, contactID uint, limit, offset int) ([]models.Message, int64, error)
+	// GetMessages 获取消息列表，不包含管理员删除和用户自己删除的消息
+	GetMessages(userID, contactID uint, p pagination.Params) ([]models.Message, pagination.Info, error)
 
-	// MarkAsRead 标记特定消息为已读
-	MarkAsRead(messageIDs []uint, userID uint) error
+	// MarkAsRead 标记特定消息为已读，返回新标记的消息数
+	MarkAsRead(messageIDs []uint, userID uint) (int64, error)
 
-	// MarkAllAsRead 标记用户与联系人间的所有消息为已读
-	MarkAllAsRead(userID, contactID uint) error
+	// MarkAllAsRead 标记用户与联系人间的所有消息为已读，返回新标记的消息数
+	MarkAllAsRead(userID, contactID uint) (int64, error)
 
-	// GetContactList 获取联系人列表
+	// MarkReadUpTo 标记联系人发来的lastMessageID及之前的消息为已读，返回新标记的消息数
+	MarkReadUpTo(userID, contactID, lastMessageID uint) (int64, error)
+
+	// GetContactList 获取联系人列表，最后一条消息已撤回时显示撤回提示
 	GetContactList(userID uint) ([]models.User, []int64, []string, []float64, []uint, error)
 
 	// GetUnreadCount 获取未读消息数
 	GetUnreadCount(userID uint) (int64, error)
 
-	// Delete 删除消息（软删除）
-	Delete(messageID, userID uint) error
+	// Delete 为用户删除消息，只对该用户隐藏，返回需要同步给该用户的删除事件
+	Delete(messageID, userID uint) ([]models.MessageEvent, error)
+
+	// DeleteForAll 管理员删除消息，会话双方都不可见，返回需要同步给双方的删除事件
+	DeleteForAll(messageID uint) ([]models.MessageEvent, error)
 
-	// Withdraw 撤回消息
-	Withdraw(messageID, userID uint) error
+	// Withdraw 撤回发送者在since之后发送的消息，返回需要同步给双方的撤回事件，消息不满足条件时返回空
+	Withdraw(messageID, userID uint, since time.Time) ([]models.MessageEvent, error)
+
+	// GetEventsSince 按ID升序获取afterEventID之后用户的消息变更事件，只包含maxMessageID及之前的消息
+	GetEventsSince(userID, afterEventID, maxMessageID uint, limit int) ([]models.MessageEvent, error)
 
 	// GetByID 获取单个消息
 	GetByID(messageID uint) (*models.Message, error)
 
-	// GetLastMessage 获取最后一条消息
+	// GetByClientID 根据客户端生成的消息ID获取发送者的消息
+	GetByClientID(senderID uint, clientID string) (*models.Message, error)
+
+	// GetLastMessage 获取最后一条对用户可见的消息
 	GetLastMessage(userID, contactID uint) (*models.Message, error)
 
+	// GetMessagesSince 按ID升序获取afterID之后用户收发的可见消息和全员系统消息，用于重连补发
+	GetMessagesSince(userID, afterID uint, limit int) ([]models.Message, error)
+
+	// FilterContacts 从userIDs中筛选与用户有过消息往来的用户
+	FilterContacts(userID uint, userIDs []uint) ([]uint, error)
+
+	// SetLastSeen 保存用户的最后在线时间
+	SetLastSeen(userID uint, at time.Time) error
+
+	// GetLastSeen 获取用户的最后在线时间，从未记录的用户不在结果中
+	GetLastSeen(userIDs []uint) (map[uint]time.Time, error)
+
+	// AdvanceReadCursor 将设备的已读位置前移到messageID，已读位置只前移不后退
+	AdvanceReadCursor(userID uint, deviceID string, messageID uint) error
+
+	// GetReadCursors 获取用户各设备的已读位置
+	GetReadCursors(userID uint) ([]models.DeviceReadCursor, error)
+
 	// 管理员接口
-	GetMessagesForAdmin(search, msgType, startDate, endDate string, page, pageSize uint) ([]models.Message, int64, error)
+	GetMessagesForAdmin(search, msgType, startDate, endDate string, p pagination.Params) ([]models.Message, pagination.Info, error)
 	GetConversationsForAdmin(search string, page, pageSize uint) ([]models.Conversation, int64, error)
-	GetMessageHistoryForAdmin(user1ID, user2ID uint, page, pageSize uint) ([]models.Message, int64, error)
-	CreateSystemMessage(receiverID uint, content, title string) error
+	GetMessageHistoryForAdmin(user1ID, user2ID uint, p pagination.Params) ([]models.Message, pagination.Info, error)
+	CreateSystemMessage(receiverID uint, content, title string) (*models.Message, error)
 }
 
+// WithdrawnPreview 最后一条消息已撤回时联系人列表显示的内容
+const WithdrawnPreview = "[消息已撤回]"
+
 // messageRepository 消息仓库实现
 type messageRepository struct {
 	db *gorm.DB
@@ -58,57 +93,80 @@ func NewMessageRepository(db *gorm.DB) MessageRepository {
 	}
 }
 
+// visibleCondition 消息对用户可见的查询条件，参数为两次用户ID：未被管理员删除，也未被该用户删除，
+// 会话另一方删除的消息仍然可见。prefix为表别名前缀
+func visibleCondition(prefix string) string {
+	return fmt.Sprintf("%[1]sis_deleted = false AND NOT (%[1]ssender_id = ? AND %[1]sdeleted_by_sender = true) "+
+		"AND NOT (%[1]sreceiver_id = ? AND %[1]sdeleted_by_receiver = true)", prefix)
+}
+
 // Create 创建消息
 func (r *messageRepository) Create(message *models.Message) error {
 	return r.db.Create(message).Error
 }
 
 // GetMessages 获取消息列表
-func (r *messageRepository) GetMessages(userID, contactID uint, limit, offset int) ([]models.Message, int64, error) {
-	var messages []models.Message
-	var total int64
-
+func (r *messageRepository) GetMessages(userID, contactID uint, p pagination.Params) ([]models.Message, pagination.Info, error) {
 	// 查询条件：用户和联系人之间的消息
-	condition := r.db.Where(
+	query := r.db.Model(&models.Message{}).Where(
 		"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 		userID, contactID, contactID, userID,
-	)
+	).Where(visibleCondition(""), userID, userID)
 
-	// 计算总记录数
-	if err := condition.Model(&models.Message{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+	return paginateMessages(query, p)
+}
+
+// paginateMessages 按发送时间倒序分页查询消息，page/size模式下同时统计总数
+func paginateMessages(query *gorm.DB, p pagination.Params) ([]models.Message, pagination.Info, error) {
+	var messages []models.Message
+	var total int64
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
 	}
 
-	// 查询消息列表
-	if err := condition.
-		Order("created_at DESC").
-		Limit(limit).
-		Offset(offset).
-		Find(&messages).Error; err != nil {
-		return nil, 0, err
+	if err := pagination.Apply(query, p, "").Find(&messages).Error; err != nil {
+		return nil, pagination.Info{}, err
 	}
 
-	return messages, total, nil
+	messages, info := pagination.Trim(messages, p, total, func(message models.Message) pagination.Cursor {
+		return pagination.ModelCursor(message.Model)
+	})
+	return messages, info, nil
 }
 
 // MarkAsRead 标记特定消息为已读
-func (r *messageRepository) MarkAsRead(messageIDs []uint, userID uint) error {
-	return r.db.Model(&models.Message{}).
+func (r *messageRepository) MarkAsRead(messageIDs []uint, userID uint) (int64, error) {
+	result := r.db.Model(&models.Message{}).
 		Where("id IN ? AND receiver_id = ? AND is_read = ?", messageIDs, userID, false).
 		Updates(map[string]interface{}{
 			"is_read":   true,
 			"read_time": time.Now(),
-		}).Error
+		})
+	return result.RowsAffected, result.Error
 }
 
 // MarkAllAsRead 标记用户与联系人间的所有消息为已读
-func (r *messageRepository) MarkAllAsRead(userID, contactID uint) error {
-	return r.db.Model(&models.Message{}).
+func (r *messageRepository) MarkAllAsRead(userID, contactID uint) (int64, error) {
+	result := r.db.Model(&models.Message{}).
 		Where("receiver_id = ? AND sender_id = ? AND is_read = ?", userID, contactID, false).
 		Updates(map[string]interface{}{
 			"is_read":   true,
 			"read_time": time.Now(),
-		}).Error
+		})
+	return result.RowsAffected, result.Error
+}
+
+// MarkReadUpTo 标记联系人发来的lastMessageID及之前的消息为已读
+func (r *messageRepository) MarkReadUpTo(userID, contactID, lastMessageID uint) (int64, error) {
+	result := r.db.Model(&models.Message{}).
+		Where("receiver_id = ? AND sender_id = ? AND id <= ? AND is_read = ?", userID, contactID, lastMessageID, false).
+		Updates(map[string]interface{}{
+			"is_read":   true,
+			"read_time": time.Now(),
+		})
+	return result.RowsAffected, result.Error
 }
 
 func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64, []string, []float64, []uint, error) {
@@ -126,6 +184,7 @@ func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64,
 			userID,
 		).
 		Where("sender_id = ? OR receiver_id = ?", userID, userID).
+		Where(visibleCondition(""), userID, userID).
 		Group("contact_id").
 		Having("contact_id != ?", userID).
 		Order("last_time DESC").
@@ -176,6 +235,8 @@ func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64,
 	r.db.Model(&models.Message{}).
 		Select("sender_id, count(*) as count").
 		Where("receiver_id = ? AND is_read = ? AND sender_id IN ?", userID, false, contactIDs).
+		Where(visibleCondition(""), userID, userID).
+		Where("is_withdrawn = ?", false).
 		Group("sender_id").
 		Scan(&unreads)
 
@@ -197,11 +258,13 @@ func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64,
             FROM messages
             WHERE (sender_id = ? OR receiver_id = ?)
                 AND (sender_id IN ? OR receiver_id IN ?)
+                AND `+visibleCondition("")+`
             GROUP BY contact_id
         ) m2 ON (m1.sender_id = m2.contact_id OR m1.receiver_id = m2.contact_id)
             AND m1.created_at = m2.max_time
+            AND `+visibleCondition("m1.")+`
         `,
-		userID, userID, userID, contactIDs, contactIDs,
+		userID, userID, userID, contactIDs, contactIDs, userID, userID, userID, userID,
 	).Scan(&latestMessages)
 
 	// 填充最后一条消息数据
@@ -215,6 +278,9 @@ func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64,
 
 		if idx, exists := userIndex[contactID]; exists {
 			lastMessages[idx] = msg.Content
+			if msg.IsWithdrawn {
+				lastMessages[idx] = WithdrawnPreview
+			}
 			lastTimes[idx] = float64(msg.CreatedAt.Unix())
 			productIDs[idx] = msg.ProductID
 		}
@@ -277,28 +343,119 @@ func (r *messageRepository) GetContactList(userID uint) ([]models.User, []int64,
 //	return users, unreadCounts, lastMessages, lastTimes, productIDs, nil
 //}
 
-// GetUnreadCount 获取未读消息数
+// GetUnreadCount 获取未读消息数，已撤回和已删除的消息不计入
 func (r *messageRepository) GetUnreadCount(userID uint) (int64, error) {
 	var count int64
 	err := r.db.Model(&models.Message{}).
 		Where("receiver_id = ? AND is_read = ?", userID, false).
+		Where(visibleCondition(""), userID, userID).
+		Where("is_withdrawn = ?", false).
 		Count(&count).Error
 	return count, err
 }
 
-// Delete 删除消息（软删除）
-func (r *messageRepository) Delete(messageID, userID uint) error {
-	return r.db.Model(&models.Message{}).
-		Where("id = ? AND (sender_id = ? OR receiver_id = ?)", messageID, userID, userID).
-		Update("is_deleted", true).Error
+// Delete 为用户删除消息，用户是发送者时标记发送者已删除，是接收者时标记接收者已删除
+func (r *messageRepository) Delete(messageID, userID uint) ([]models.MessageEvent, error) {
+	var events []models.MessageEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var message models.Message
+		if err := tx.First(&message, messageID).Error; err != nil {
+			return err
+		}
+		updates := map[string]interface{}{}
+		if message.SenderID == userID {
+			updates["deleted_by_sender"] = true
+		}
+		if message.ReceiverID == userID {
+			updates["deleted_by_receiver"] = true
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(&message).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		var err error
+		events, err = createMessageEvents(tx, &message, []uint{userID}, models.MessageEventDeleted, userID)
+		return err
+	})
+	return events, err
+}
+
+// DeleteForAll 管理员删除消息（软删除）
+func (r *messageRepository) DeleteForAll(messageID uint) ([]models.MessageEvent, error) {
+	var events []models.MessageEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var message models.Message
+		if err := tx.First(&message, messageID).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&message).Where("is_deleted = ?", false).Update("is_deleted", true)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var err error
+		events, err = createMessageEvents(tx, &message, []uint{message.SenderID, message.ReceiverID}, models.MessageEventDeleted, 0)
+		return err
+	})
+	return events, err
+}
+
+// Withdraw 撤回消息，撤回时间由调用方计算，不依赖数据库的时间函数
+func (r *messageRepository) Withdraw(messageID, userID uint, since time.Time) ([]models.MessageEvent, error) {
+	var events []models.MessageEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Message{}).
+			Where("id = ? AND sender_id = ? AND created_at > ?", messageID, userID, since).
+			Where("is_deleted = ? AND is_withdrawn = ?", false, false).
+			Update("is_withdrawn", true)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		var message models.Message
+		if err := tx.First(&message, messageID).Error; err != nil {
+			return err
+		}
+		var err error
+		events, err = createMessageEvents(tx, &message, []uint{message.SenderID, message.ReceiverID}, models.MessageEventWithdrawn, userID)
+		return err
+	})
+	return events, err
+}
+
+// createMessageEvents 在事务中为每个用户写入一条消息变更事件，全员系统消息的接收者（ID为0）不写入
+func createMessageEvents(tx *gorm.DB, message *models.Message, userIDs []uint, eventType string, operatorID uint) ([]models.MessageEvent, error) {
+	events := make([]models.MessageEvent, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if userID == 0 {
+			continue
+		}
+		events = append(events, models.MessageEvent{
+			UserID:     userID,
+			MessageID:  message.ID,
+			SenderID:   message.SenderID,
+			ReceiverID: message.ReceiverID,
+			Type:       eventType,
+			OperatorID: operatorID,
+		})
+	}
+	if len(events) == 0 {
+		return events, nil
+	}
+	return events, tx.Create(&events).Error
 }
 
-// Withdraw 撤回消息
-func (r *messageRepository) Withdraw(messageID, userID uint) error {
-	return r.db.Model(&models.Message{}).
-		Where("id = ? AND sender_id = ? AND created_at > DATE_SUB(NOW(), INTERVAL 2 MINUTE)",
-			messageID, userID).
-		Update("is_withdrawn", true).Error
+// GetEventsSince 按ID升序获取用户的消息变更事件
+func (r *messageRepository) GetEventsSince(userID, afterEventID, maxMessageID uint, limit int) ([]models.MessageEvent, error) {
+	var events []models.MessageEvent
+	err := r.db.Where("user_id = ? AND id > ? AND message_id <= ?", userID, afterEventID, maxMessageID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
 }
 
 // GetByID 获取单个消息
@@ -308,6 +465,13 @@ func (r *messageRepository) GetByID(messageID uint) (*models.Message, error) {
 	return &message, err
 }
 
+// GetByClientID 根据客户端生成的消息ID获取发送者的消息
+func (r *messageRepository) GetByClientID(senderID uint, clientID string) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Where("sender_id = ? AND client_id = ?", senderID, clientID).First(&message).Error
+	return &message, err
+}
+
 // GetLastMessage 获取最后一条消息
 func (r *messageRepository) GetLastMessage(userID, contactID uint) (*models.Message, error) {
 	var message models.Message
@@ -317,16 +481,80 @@ func (r *messageRepository) GetLastMessage(userID, contactID uint) (*models.Mess
 	err := r.db.Where(
 		"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 		userID, contactID, contactID, userID,
-	).Order("created_at DESC").First(&message).Error
+	).Where(visibleCondition(""), userID, userID).Order("created_at DESC").First(&message).Error
 
 	return &message, err
 }
 
-// GetMessagesForAdmin 管理员获取消息列表
-func (r *messageRepository) GetMessagesForAdmin(search, msgType, startDate, endDate string, page, pageSize uint) ([]models.Message, int64, error) {
+// GetMessagesSince 按ID升序获取afterID之后的消息
+func (r *messageRepository) GetMessagesSince(userID, afterID uint, limit int) ([]models.Message, error) {
 	var messages []models.Message
-	var total int64
+	err := r.db.Where("id > ?", afterID).
+		Where("sender_id = ? OR receiver_id = ? OR (sender_id = 0 AND receiver_id = 0)", userID, userID).
+		Where(visibleCondition(""), userID, userID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// FilterContacts 从userIDs中筛选与用户有过消息往来的用户
+func (r *messageRepository) FilterContacts(userID uint, userIDs []uint) ([]uint, error) {
+	if len(userIDs) == 0 {
+		return []uint{}, nil
+	}
+	var contactIDs []uint
+	err := r.db.Model(&models.Message{}).
+		Select("DISTINCT CASE WHEN sender_id = ? THEN receiver_id ELSE sender_id END AS contact_id", userID).
+		Where("(sender_id = ? AND receiver_id IN ?) OR (receiver_id = ? AND sender_id IN ?)",
+			userID, userIDs, userID, userIDs).
+		Scan(&contactIDs).Error
+	return contactIDs, err
+}
+
+// SetLastSeen 保存用户的最后在线时间，不更新用户的修改时间
+func (r *messageRepository) SetLastSeen(userID uint, at time.Time) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("last_seen_at", at).Error
+}
 
+// GetLastSeen 获取用户的最后在线时间
+func (r *messageRepository) GetLastSeen(userIDs []uint) (map[uint]time.Time, error) {
+	var users []models.User
+	err := r.db.Select("id", "last_seen_at").
+		Where("id IN ? AND last_seen_at IS NOT NULL", userIDs).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lastSeen := make(map[uint]time.Time, len(users))
+	for _, user := range users {
+		lastSeen[user.ID] = *user.LastSeenAt
+	}
+	return lastSeen, nil
+}
+
+// AdvanceReadCursor 将设备的已读位置前移到messageID
+func (r *messageRepository) AdvanceReadCursor(userID uint, deviceID string, messageID uint) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.DeviceReadCursor{UserID: userID, DeviceID: deviceID}).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Model(&models.DeviceReadCursor{}).
+		Where("user_id = ? AND device_id = ? AND last_message_id < ?", userID, deviceID, messageID).
+		Update("last_message_id", messageID).Error
+}
+
+// GetReadCursors 获取用户各设备的已读位置
+func (r *messageRepository) GetReadCursors(userID uint) ([]models.DeviceReadCursor, error) {
+	var cursors []models.DeviceReadCursor
+	err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&cursors).Error
+	return cursors, err
+}
+
+// GetMessagesForAdmin 管理员获取消息列表
+func (r *messageRepository) GetMessagesForAdmin(search, msgType, startDate, endDate string, p pagination.Params) ([]models.Message, pagination.Info, error) {
 	query := r.db.Model(&models.Message{})
 
 	// 添加搜索条件
@@ -358,18 +586,7 @@ func (r *messageRepository) GetMessagesForAdmin(search, msgType, startDate, endD
 		}
 	}
 
-	// 计算总记录数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(int(offset)).Limit(int(pageSize)).Find(&messages).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return messages, total, nil
+	return paginateMessages(query, p)
 }
 
 // GetConversationsForAdmin 管理员获取会话列表
@@ -454,32 +671,18 @@ func (r *messageRepository) GetConversationsForAdmin(search string, page, pageSi
 }
 
 // GetMessageHistoryForAdmin 管理员获取会话消息历史
-func (r *messageRepository) GetMessageHistoryForAdmin(user1ID, user2ID uint, page, pageSize uint) ([]models.Message, int64, error) {
-	var messages []models.Message
-	var total int64
-
+func (r *messageRepository) GetMessageHistoryForAdmin(user1ID, user2ID uint, p pagination.Params) ([]models.Message, pagination.Info, error) {
 	// 查询条件：两个用户之间的消息
 	query := r.db.Model(&models.Message{}).Where(
 		"(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)",
 		user1ID, user2ID, user2ID, user1ID,
 	)
 
-	// 计算总记录数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 分页查询
-	offset := (page - 1) * pageSize
-	if err := query.Order("created_at DESC").Offset(int(offset)).Limit(int(pageSize)).Find(&messages).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return messages, total, nil
+	return paginateMessages(query, p)
 }
 
 // CreateSystemMessage 创建系统消息
-func (r *messageRepository) CreateSystemMessage(receiverID uint, content, title string) error {
+func (r *messageRepository) CreateSystemMessage(receiverID uint, content, title string) (*models.Message, error) {
 	// 构建系统消息
 	message := models.Message{
 		SenderID:   0, // 系统消息的发送者ID为0
@@ -493,7 +696,10 @@ func (r *messageRepository) CreateSystemMessage(receiverID uint, content, title
 		message.Content = fmt.Sprintf("[%s] %s", title, content)
 	}
 
-	return r.db.Create(&message).Error
+	if err := r.db.Create(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
 }
 
 // ToContactResponse 将查询结果转换为Contact模型
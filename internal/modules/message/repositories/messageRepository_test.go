@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/pagination"
+	"campus/internal/utils/testdb"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestRepository 使用内存SQLite创建消息仓库
+func newTestRepository(t *testing.T) (*messageRepository, *gorm.DB) {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.Message{}, &models.DeviceReadCursor{}, &models.MessageEvent{})
+
+	return &messageRepository{db: db}, db
+}
+
+func TestAdvanceReadCursorPerDevice(t *testing.T) {
+	repo, _ := newTestRepository(t)
+
+	require.NoError(t, repo.AdvanceReadCursor(1, "phone", 5))
+	require.NoError(t, repo.AdvanceReadCursor(1, "laptop", 3))
+	// 已读位置只前移不后退
+	require.NoError(t, repo.AdvanceReadCursor(1, "phone", 4))
+	require.NoError(t, repo.AdvanceReadCursor(2, "phone", 9))
+
+	cursors, err := repo.GetReadCursors(1)
+	require.NoError(t, err)
+	positions := make(map[string]uint)
+	for _, c := range cursors {
+		positions[c.DeviceID] = c.LastMessageID
+	}
+	assert.Equal(t, map[string]uint{"phone": 5, "laptop": 3}, positions)
+
+	require.NoError(t, repo.AdvanceReadCursor(1, "laptop", 8))
+	cursors, err = repo.GetReadCursors(1)
+	require.NoError(t, err)
+	require.Len(t, cursors, 2)
+	assert.Equal(t, "laptop", cursors[0].DeviceID)
+	assert.Equal(t, uint(8), cursors[0].LastMessageID)
+}
+
+func TestGetMessagesSinceIncludesSentAndSystemMessages(t *testing.T) {
+	repo, db := newTestRepository(t)
+
+	messages := []models.Message{
+		{SenderID: 2, ReceiverID: 1, Content: "在吗"},
+		{SenderID: 1, ReceiverID: 2, Content: "在"},
+		{SenderID: 2, ReceiverID: 3, Content: "别人的消息"},
+		{SenderID: 0, ReceiverID: 1, Content: "[订单] 已发货"},
+		{SenderID: 0, ReceiverID: 0, Content: "[公告] 系统维护"},
+		{SenderID: 3, ReceiverID: 1, Content: "还有吗"},
+	}
+	for i := range messages {
+		require.NoError(t, db.Create(&messages[i]).Error)
+	}
+
+	got, err := repo.GetMessagesSince(1, messages[0].ID, 3)
+	require.NoError(t, err)
+	var contents []string
+	for _, m := range got {
+		contents = append(contents, m.Content)
+	}
+	assert.Equal(t, []string{"在", "[订单] 已发货", "[公告] 系统维护"}, contents)
+
+	got, err = repo.GetMessagesSince(1, got[len(got)-1].ID, 3)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "还有吗", got[0].Content)
+}
+
+func TestFilterContactsAndLastSeen(t *testing.T) {
+	repo, db := newTestRepository(t)
+	users := []models.User{
+		{Username: "a", Password: "x", Email: "a@example.com"},
+		{Username: "b", Password: "x", Email: "b@example.com"},
+		{Username: "c", Password: "x", Email: "c@example.com"},
+		{Username: "d", Password: "x", Email: "d@example.com"},
+	}
+	for i := range users {
+		require.NoError(t, db.Create(&users[i]).Error)
+	}
+	a, b, c, d := users[0].ID, users[1].ID, users[2].ID, users[3].ID
+	for _, m := range []models.Message{
+		{SenderID: a, ReceiverID: b, Content: "你好"},
+		{SenderID: b, ReceiverID: a, Content: "你好"},
+		{SenderID: c, ReceiverID: a, Content: "在吗"},
+		{SenderID: c, ReceiverID: d, Content: "无关"},
+	} {
+		require.NoError(t, db.Create(&m).Error)
+	}
+
+	contacts, err := repo.FilterContacts(a, []uint{b, c, d})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{b, c}, contacts)
+
+	seen := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.SetLastSeen(b, seen))
+	lastSeen, err := repo.GetLastSeen([]uint{b, c})
+	require.NoError(t, err)
+	require.Len(t, lastSeen, 1)
+	assert.True(t, seen.Equal(lastSeen[b]))
+}
+
+func TestWithdrawUsesDeadlineAndDeletedMessagesAreHidden(t *testing.T) {
+	repo, db := newTestRepository(t)
+	now := time.Now()
+	old := models.Message{SenderID: 1, ReceiverID: 2, Content: "早先的消息"}
+	old.CreatedAt = now.Add(-time.Hour)
+	recent := models.Message{SenderID: 1, ReceiverID: 2, Content: "刚发的消息"}
+	deleted := models.Message{SenderID: 2, ReceiverID: 1, Content: "已删除的消息", IsDeleted: true}
+	for _, m := range []*models.Message{&old, &recent, &deleted} {
+		require.NoError(t, db.Create(m).Error)
+	}
+
+	since := now.Add(-2 * time.Minute)
+	withdrawn, err := repo.Withdraw(old.ID, 1, since)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawn)
+	// 只有发送者可以撤回
+	withdrawn, err = repo.Withdraw(recent.ID, 2, since)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawn)
+	withdrawn, err = repo.Withdraw(recent.ID, 1, since)
+	require.NoError(t, err)
+	require.Len(t, withdrawn, 2)
+	assert.Equal(t, uint(1), withdrawn[0].UserID)
+	assert.Equal(t, uint(2), withdrawn[1].UserID)
+	withdrawn, err = repo.Withdraw(recent.ID, 1, since)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawn)
+
+	params, err := pagination.NewParams("", 1, 20)
+	require.NoError(t, err)
+	messages, info, err := repo.GetMessages(1, 2, params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Total)
+	require.Len(t, messages, 2)
+	assert.Equal(t, recent.ID, messages[0].ID)
+	assert.True(t, messages[0].IsWithdrawn)
+
+	last, err := repo.GetLastMessage(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, recent.ID, last.ID)
+
+	replayed, err := repo.GetMessagesSince(1, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, replayed, 2)
+
+	// 已撤回和已删除的消息不计入未读
+	unread, err := repo.GetUnreadCount(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+}
+
+func TestClientIDUniquePerSender(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	clientID := "c-1"
+
+	require.NoError(t, repo.Create(&models.Message{SenderID: 1, ReceiverID: 2, Content: "在吗", ClientID: &clientID}))
+	// 同一发送者的客户端ID唯一，不同发送者可以相同
+	assert.Error(t, repo.Create(&models.Message{SenderID: 1, ReceiverID: 2, Content: "在吗", ClientID: &clientID}))
+	require.NoError(t, repo.Create(&models.Message{SenderID: 3, ReceiverID: 2, Content: "在吗", ClientID: &clientID}))
+
+	// 未传入客户端ID的消息保存为NULL，不受唯一索引限制
+	require.NoError(t, repo.Create(&models.Message{SenderID: 1, ReceiverID: 2, Content: "一"}))
+	require.NoError(t, repo.Create(&models.Message{SenderID: 1, ReceiverID: 2, Content: "二"}))
+
+	message, err := repo.GetByClientID(1, clientID)
+	require.NoError(t, err)
+	assert.Equal(t, "在吗", message.Content)
+}
+
+func TestDeleteHidesMessageOnlyForDeleter(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	first := models.Message{SenderID: 1, ReceiverID: 2, Content: "第一条"}
+	second := models.Message{SenderID: 1, ReceiverID: 2, Content: "第二条"}
+	require.NoError(t, repo.Create(&first))
+	require.NoError(t, repo.Create(&second))
+
+	events, err := repo.Delete(second.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.MessageEvent{ID: events[0].ID, UserID: 2, MessageID: second.ID, SenderID: 1, ReceiverID: 2,
+		Type: models.MessageEventDeleted, OperatorID: 2, CreatedAt: events[0].CreatedAt}, events[0])
+
+	// 接收者看不到已删除的消息，发送者仍然可见
+	params, err := pagination.NewParams("", 1, 20)
+	require.NoError(t, err)
+	messages, _, err := repo.GetMessages(2, 1, params)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, first.ID, messages[0].ID)
+	messages, _, err = repo.GetMessages(1, 2, params)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	last, err := repo.GetLastMessage(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, last.ID)
+	last, err = repo.GetLastMessage(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, last.ID)
+
+	unread, err := repo.GetUnreadCount(2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), unread)
+	replayed, err := repo.GetMessagesSince(2, 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, replayed, 1)
+
+	// 管理员删除后双方都不可见，并为双方各写入一条事件
+	events, err = repo.DeleteForAll(first.ID)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+	messages, _, err = repo.GetMessages(1, 2, params)
+	require.NoError(t, err)
+	assert.Len(t, messages, 1)
+}
+
+func TestGetEventsSince(t *testing.T) {
+	repo, _ := newTestRepository(t)
+	var ids []uint
+	for i := 0; i < 3; i++ {
+		message := models.Message{SenderID: 1, ReceiverID: 2, Content: "消息"}
+		require.NoError(t, repo.Create(&message))
+		ids = append(ids, message.ID)
+	}
+	since := time.Now().Add(-time.Minute)
+	withdrawn, err := repo.Withdraw(ids[0], 1, since)
+	require.NoError(t, err)
+	_, err = repo.Delete(ids[1], 1)
+	require.NoError(t, err)
+	_, err = repo.Withdraw(ids[2], 1, since)
+	require.NoError(t, err)
+
+	// 只返回该用户的事件，跳过已处理的事件和maxMessageID之后的消息
+	events, err := repo.GetEventsSince(2, 0, ids[1], 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.MessageEventWithdrawn, events[0].Type)
+
+	events, err = repo.GetEventsSince(1, withdrawn[0].ID, ids[2], 10)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.MessageEventDeleted, events[0].Type)
+	assert.Equal(t, ids[2], events[1].MessageID)
+}
@@ -6,37 +6,35 @@ import (
 	"campus/internal/modules/message/controllers"
 	"campus/internal/modules/message/repositories"
 	"campus/internal/modules/message/services"
-	"campus/internal/rabbitMQ"
 	"campus/internal/utils/errors"
-	"campus/internal/utils/logger"
 	"campus/internal/utils/response"
 	"campus/internal/websocket"
 	"github.com/gin-gonic/gin"
-	"go.uber.org/zap"
+	"strconv"
 )
 
 // RegisterRoutes 注册消息模块的路由
-func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup, wsManager *websocket.Manager, rabbitMQURL string) {
+func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup, wsManager *websocket.Manager) {
 	// --- Dependency Injection ---
 
 	// 1. Create Repository
 	db := bootstrap.GetDB()
 	messageRepo := repositories.NewMessageRepository(db)
 
-	// 2. Create RabbitMQ Publisher
-	// Note: Error handling should be more robust in a real application (e.g., panic or graceful shutdown)
-	publisher, err := rabbitMQ.NewPublisher(rabbitMQURL)
-	if err != nil {
-		logger.Fatal("Failed to create RabbitMQ publisher", zap.Error(err))
-	}
+	// 2. Create Service with the shared RabbitMQ publisher
+	messageService := services.NewMessageService(messageRepo, bootstrap.GetMessagePublisher(), wsManager, bootstrap.GetConfig().Message.WithdrawWindow)
 
-	// 3. Create Service
-	messageService := services.NewMessageService(messageRepo, publisher)
+	// 3. Replay missed messages from the database when a device reconnects
+	wsManager.SetBacklog(services.NewMessageBacklog(messageRepo))
 
 	// --- Controller and Routes Setup ---
 
 	controller := controllers.NewMessageController(messageService)
 
+	// Frames sent by clients over the WebSocket go through the same message service
+	wsManager.SetHandler(controllers.NewSocketHandler(messageService, wsManager))
+	wsManager.SetPresenceStore(messageRepo)
+
 	// Message related REST API routes - authentication required
 	messageGroup := api.Group("/messages")
 	messageGroup.Use(middleware.JWTAuth())
@@ -48,6 +46,10 @@ func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup, wsManager *websocket.Ma
 		messageGroup.PUT("/:contactId/read", controller.MarkAsRead)
 		messageGroup.GET("/unread/count", controller.GetUnreadCount)
 		messageGroup.POST("/conversation", controller.CreateConversation)
+		messageGroup.GET("/devices", controller.GetDevices)
+		messageGroup.PUT("/devices/:deviceId/cursor", controller.UpdateReadCursor)
+		messageGroup.POST("/:messageId/withdraw", controller.WithdrawMessage)
+		messageGroup.DELETE("/:messageId", controller.DeleteUserMessage)
 	}
 
 	// WebSocket route - uses a dedicated WebSocket authentication middleware
@@ -63,8 +65,43 @@ func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup, wsManager *websocket.Ma
 				return
 			}
 
+			// 客户端通过device_id参数标识设备，同一用户的多个设备可以同时在线。
+			// 未传入时由服务端生成，客户端应保存后在重连时传入
+			deviceID := c.Query("device_id")
+			if deviceID != "" && !websocket.ValidDeviceID(deviceID) {
+				response.HandleError(c, errors.NewBadRequestError("无效的设备ID", nil))
+				return
+			}
+
+			// 客户端通过last_message_id参数传入已收到的最后一条消息ID，
+			// 服务端先补发之后的聊天消息、系统通知和订单事件，再推送实时消息
+			var lastMessageID uint64
+			if value := c.Query("last_message_id"); value != "" {
+				var err error
+				if lastMessageID, err = strconv.ParseUint(value, 10, 32); err != nil {
+					response.HandleError(c, errors.NewBadRequestError("无效的消息ID", err))
+					return
+				}
+			}
+
+			// 客户端通过last_event_id参数传入已处理的最后一个变更事件ID（事件帧的event_id），
+			// 补发消息时同时补发离线期间的撤回和删除事件
+			var lastEventID uint64
+			if value := c.Query("last_event_id"); value != "" {
+				var err error
+				if lastEventID, err = strconv.ParseUint(value, 10, 32); err != nil {
+					response.HandleError(c, errors.NewBadRequestError("无效的事件ID", err))
+					return
+				}
+			}
+
 			// Upgrade the HTTP connection to a WebSocket connection
-			wsManager.HandleConnection(c.Writer, c.Request, userID.(uint))
+			wsManager.HandleConnection(c.Writer, c.Request, websocket.ConnectOptions{
+				UserID:        userID.(uint),
+				DeviceID:      deviceID,
+				LastMessageID: uint(lastMessageID),
+				LastEventID:   uint(lastEventID),
+			})
 		})
 	}
 	
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"campus/internal/modules/message/api"
+	"campus/internal/modules/message/repositories"
+	"campus/internal/websocket"
+)
+
+// messageBacklog 从数据库读取用户错过的消息和消息变更事件，编码为与实时推送相同的帧
+type messageBacklog struct {
+	repo repositories.MessageRepository
+}
+
+// NewMessageBacklog 创建重连补发的消息来源
+func NewMessageBacklog(repo repositories.MessageRepository) websocket.Backlog {
+	return &messageBacklog{repo: repo}
+}
+
+func (b *messageBacklog) Since(userID, afterID uint, limit int) ([]websocket.Delivery, error) {
+	messages, err := b.repo.GetMessagesSince(userID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]websocket.Delivery, 0, len(messages))
+	for i := range messages {
+		payload, err := websocket.EncodeFrame(websocket.FrameMessage, "", api.ToMessageResponse(&messages[i]))
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, websocket.Delivery{
+			ID:       messages[i].ID,
+			SenderID: messages[i].SenderID,
+			Payload:  payload,
+		})
+	}
+	return deliveries, nil
+}
+
+func (b *messageBacklog) EventsSince(userID, afterEventID, maxMessageID uint, limit int) ([]websocket.Delivery, error) {
+	events, err := b.repo.GetEventsSince(userID, afterEventID, maxMessageID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]websocket.Delivery, 0, len(events))
+	for i := range events {
+		payload, err := websocket.EncodeFrame(messageEventFrames[events[i].Type], "", api.ToMessageEvent(&events[i]))
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, websocket.Delivery{ID: events[i].ID, Payload: payload})
+	}
+	return deliveries, nil
+}
@@ -5,9 +5,13 @@ import (
 	"campus/internal/modules/message/api"
 	"campus/internal/modules/message/repositories"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/pagination"
+	"campus/internal/websocket"
 	"encoding/json"
 	"log"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // RabbitMQPublisher defines the interface for publishing messages to RabbitMQ.
@@ -16,17 +20,27 @@ type RabbitMQPublisher interface {
 	Publish(body []byte, contentType string) error
 }
 
+// Realtime 本实例上的WebSocket连接，用于查询在线状态和推送回执等实时事件
+type Realtime interface {
+	IsUserOnline(userID uint) bool
+	Sessions(userID uint) []websocket.Session
+	SendFrame(userID uint, frameType string, data interface{}) bool
+}
+
 // MessageService 消息服务接口
 type MessageService interface {
 	// SendMessage 发送消息
 	SendMessage(senderID uint, req api.SendMessageRequest) (*api.MessageResponse, error)
 
 	// GetMessagesByContact 获取与联系人的消息
-	GetMessagesByContact(userID, contactID uint, limit, offset int) (*api.MessageListResponse, error)
+	GetMessagesByContact(userID, contactID uint, p pagination.Params) (*api.MessageListResponse, error)
 
 	// MarkMessagesAsRead 标记消息为已读
 	MarkMessagesAsRead(userID uint, contactID uint, messageIDs []uint) error
 
+	// ReadUpTo 设备标记联系人发来的消息已读到指定位置，同时更新该设备的已读位置
+	ReadUpTo(userID uint, deviceID string, req *api.ReadUpToRequest) error
+
 	// GetContacts 获取联系人列表
 	GetContacts(userID uint) (*api.ContactListResponse, error)
 
@@ -36,6 +50,27 @@ type MessageService interface {
 	// GetLastMessage 获取与联系人的最后一条消息
 	GetLastMessage(userID, contactID uint) (*api.MessageResponse, error)
 
+	// UpdateReadCursor 更新设备的已读位置
+	UpdateReadCursor(userID uint, deviceID string, req *api.UpdateReadCursorRequest) error
+
+	// GetDevices 获取用户的在线设备和各设备的已读位置
+	GetDevices(userID uint) (*api.DeviceListResponse, error)
+
+	// FilterContacts 从userIDs中筛选与用户有过消息往来的用户，在线状态和输入状态只在这些用户之间推送
+	FilterContacts(userID uint, userIDs []uint) ([]uint, error)
+
+	// GetPresence 获取用户的在线状态
+	GetPresence(userIDs []uint) ([]websocket.Presence, error)
+
+	// SendTyping 向联系人转发输入状态
+	SendTyping(userID uint, req *api.TypingRequest) error
+
+	// WithdrawMessage 发送者在撤回时限内撤回消息
+	WithdrawMessage(userID, messageID uint) error
+
+	// DeleteMessageByUser 会话双方删除消息，只对删除者隐藏，另一方仍可见
+	DeleteMessageByUser(userID, messageID uint) error
+
 	// 管理员接口
 	GetMessagesForAdmin(req *api.AdminMessageListRequest) (*api.AdminMessageListResponse, error)
 	GetConversationsForAdmin(req *api.AdminConversationListRequest) (*api.AdminConversationListResponse, error)
@@ -46,8 +81,10 @@ type MessageService interface {
 
 // messageService 消息服务实现
 type messageService struct {
-	repo      repositories.MessageRepository // 消息仓库
-	publisher RabbitMQPublisher              // RabbitMQ a publisher
+	repo           repositories.MessageRepository // 消息仓库
+	publisher      RabbitMQPublisher              // RabbitMQ a publisher
+	realtime       Realtime                       // 本实例的WebSocket连接
+	withdrawWindow time.Duration                  // 消息发送后可以撤回的时间
 }
 
 func (s *messageService) GetUnreadCount(userID uint) (int64, error) {
@@ -55,10 +92,12 @@ func (s *messageService) GetUnreadCount(userID uint) (int64, error) {
 }
 
 // NewMessageService 创建消息服务实例
-func NewMessageService(repo repositories.MessageRepository, publisher RabbitMQPublisher) MessageService {
+func NewMessageService(repo repositories.MessageRepository, publisher RabbitMQPublisher, realtime Realtime, withdrawWindow time.Duration) MessageService {
 	return &messageService{
-		repo:      repo,
-		publisher: publisher,
+		repo:           repo,
+		publisher:      publisher,
+		realtime:       realtime,
+		withdrawWindow: withdrawWindow,
 	}
 }
 
@@ -69,17 +108,43 @@ func (s *messageService) SendMessage(senderID uint, req api.SendMessageRequest)
 		return nil, errors.NewBadRequestError("不能给自己发送消息", nil)
 	}
 
+	// 客户端重发的消息直接返回已保存的消息，不再重复推送
+	if req.ClientID != "" {
+		existing, err := s.repo.GetByClientID(senderID, req.ClientID)
+		if err == nil {
+			messageResponse := api.ToMessageResponse(existing)
+			return &messageResponse, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, errors.NewInternalServerError("查询消息失败", err)
+		}
+	}
+
 	// 创建消息
 	message := &models.Message{
 		SenderID:   senderID,
 		ReceiverID: req.ReceiverID,
 		Content:    req.Content,
 		ProductID:  req.ProductID,
+		Type:       req.Type,
 		IsRead:     false,
 	}
+	if req.ClientID != "" {
+		message.ClientID = &req.ClientID
+	}
+	if message.Type == "" {
+		message.Type = models.MessageTypeText
+	}
 
 	// 1. 保存消息到数据库
 	if err := s.repo.Create(message); err != nil {
+		// 并发重发的同一消息由唯一索引拦截，返回先保存的消息
+		if req.ClientID != "" {
+			if existing, findErr := s.repo.GetByClientID(senderID, req.ClientID); findErr == nil {
+				messageResponse := api.ToMessageResponse(existing)
+				return &messageResponse, nil
+			}
+		}
 		return nil, errors.NewInternalServerError("消息保存失败", err)
 	}
 
@@ -87,40 +152,46 @@ func (s *messageService) SendMessage(senderID uint, req api.SendMessageRequest)
 	messageResponse := api.ToMessageResponse(message)
 
 	// 2. 将消息发布到RabbitMQ，由后台消费者处理推送
-	messageJSON, err := json.Marshal(messageResponse)
+	s.publish(&messageResponse)
+
+	return &messageResponse, nil
+}
+
+// publish 将已保存的消息发布到RabbitMQ，由后台消费者推送给在线设备。
+// 消息已保存到数据库，发布失败时离线设备重连后仍会补发，因此只记录日志
+func (s *messageService) publish(message *api.MessageResponse) {
+	messageJSON, err := json.Marshal(message)
 	if err != nil {
 		log.Printf("消息序列化失败: %v", err)
-		// Even if publishing fails, the message is in DB. Return success.
-		return &messageResponse, nil
+		return
 	}
 
 	if err := s.publisher.Publish(messageJSON, "application/json"); err != nil {
 		log.Printf("通过RabbitMQ发布消息失败: %v", err)
-		// Even if publishing fails, the message is in DB. Return success.
 	} else {
 		log.Printf("消息已发布到RabbitMQ，由消费者异步处理")
 	}
-
-	return &messageResponse, nil
 }
 
 // GetMessagesByContact 获取与联系人的消息
-func (s *messageService) GetMessagesByContact(userID, contactID uint, limit, offset int) (*api.MessageListResponse, error) {
+func (s *messageService) GetMessagesByContact(userID, contactID uint, p pagination.Params) (*api.MessageListResponse, error) {
 	// 获取消息列表
-	messages, total, err := s.repo.GetMessages(userID, contactID, limit, offset)
+	messages, info, err := s.repo.GetMessages(userID, contactID, p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取消息失败", err)
 	}
 
 	// 自动标记为已读
-	if err := s.repo.MarkAllAsRead(userID, contactID); err != nil {
+	if marked, err := s.repo.MarkAllAsRead(userID, contactID); err != nil {
 		log.Printf("标记消息为已读失败: %v", err)
+	} else if marked > 0 {
+		s.sendReadReceipt(contactID, api.ReadReceipt{ReaderID: userID})
 	}
 
 	// 构建响应
 	response := &api.MessageListResponse{
-		Total:    int(total),
 		Messages: api.ToMessageResponseList(messages),
+		Info:     info,
 	}
 
 	return response, nil
@@ -128,13 +199,49 @@ func (s *messageService) GetMessagesByContact(userID, contactID uint, limit, off
 
 // MarkMessagesAsRead 标记消息为已读
 func (s *messageService) MarkMessagesAsRead(userID uint, contactID uint, messageIDs []uint) error {
+	var marked int64
+	var err error
+
 	// 如果messageIDs为空，则标记所有消息已读
 	if len(messageIDs) == 0 {
-		return s.repo.MarkAllAsRead(userID, contactID)
+		marked, err = s.repo.MarkAllAsRead(userID, contactID)
+	} else {
+		// 否则标记指定消息已读
+		marked, err = s.repo.MarkAsRead(messageIDs, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if marked > 0 {
+		s.sendReadReceipt(contactID, api.ReadReceipt{ReaderID: userID, MessageIDs: messageIDs})
+	}
+	return nil
+}
+
+// ReadUpTo 设备标记联系人发来的消息已读到指定位置
+func (s *messageService) ReadUpTo(userID uint, deviceID string, req *api.ReadUpToRequest) error {
+	marked, err := s.repo.MarkReadUpTo(userID, req.ContactID, req.LastMessageID)
+	if err != nil {
+		return errors.NewInternalServerError("标记消息已读失败", err)
+	}
+	if err := s.repo.AdvanceReadCursor(userID, deviceID, req.LastMessageID); err != nil {
+		return errors.NewInternalServerError("更新已读位置失败", err)
 	}
 
-	// 否则标记指定消息已读
-	return s.repo.MarkAsRead(messageIDs, userID)
+	if marked > 0 {
+		s.sendReadReceipt(req.ContactID, api.ReadReceipt{ReaderID: userID, LastMessageID: req.LastMessageID})
+	}
+	return nil
+}
+
+// sendReadReceipt 向消息的发送者推送已读回执，系统消息不推送
+func (s *messageService) sendReadReceipt(senderID uint, receipt api.ReadReceipt) {
+	if senderID == 0 {
+		return
+	}
+	receipt.ReadAt = time.Now()
+	s.realtime.SendFrame(senderID, websocket.FrameRead, receipt)
 }
 
 // GetContacts 获取联系人列表
@@ -148,6 +255,12 @@ func (s *messageService) GetContacts(userID uint) (*api.ContactListResponse, err
 	// 组装联系人列表
 	contacts := make([]api.ContactResponse, len(users))
 	for i, user := range users {
+		online := s.realtime.IsUserOnline(user.ID)
+		var lastSeen *time.Time
+		if !online {
+			lastSeen = user.LastSeenAt
+		}
+
 		// 将浮点数时间戳转换为time.Time，处理秒和毫秒部分
 		seconds := int64(lastTimes[i])
 		nanoseconds := int64((lastTimes[i] - float64(seconds)) * 1e9)
@@ -161,6 +274,8 @@ func (s *messageService) GetContacts(userID uint) (*api.ContactListResponse, err
 			LastTime:     lastTime,
 			UnreadCount:  int(unreadCounts[i]),
 			ProductCount: 0, // 设置默认值
+			Online:       online,
+			LastSeen:     lastSeen,
 		}
 	}
 
@@ -182,27 +297,211 @@ func (s *messageService) GetLastMessage(userID, contactID uint) (*api.MessageRes
 	return &response, nil
 }
 
+// UpdateReadCursor 更新设备的已读位置，只能前移到自己收发的消息
+func (s *messageService) UpdateReadCursor(userID uint, deviceID string, req *api.UpdateReadCursorRequest) error {
+	message, err := s.repo.GetByID(req.LastMessageID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("消息", err)
+		}
+		return errors.NewInternalServerError("查询消息失败", err)
+	}
+	if message.SenderID != userID && message.ReceiverID != userID {
+		return errors.NewForbiddenError("无权访问该消息", nil)
+	}
+
+	if err := s.repo.AdvanceReadCursor(userID, deviceID, message.ID); err != nil {
+		return errors.NewInternalServerError("更新已读位置失败", err)
+	}
+	return nil
+}
+
+// GetDevices 获取用户的在线设备和各设备的已读位置，在线设备排在前面
+func (s *messageService) GetDevices(userID uint) (*api.DeviceListResponse, error) {
+	cursors, err := s.repo.GetReadCursors(userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取设备列表失败", err)
+	}
+
+	response := &api.DeviceListResponse{Devices: make([]api.DeviceResponse, 0, len(cursors))}
+	index := make(map[string]int)
+	for _, session := range s.realtime.Sessions(userID) {
+		connectedAt := session.ConnectedAt
+		index[session.DeviceID] = len(response.Devices)
+		response.Devices = append(response.Devices, api.DeviceResponse{
+			DeviceID:    session.DeviceID,
+			Online:      true,
+			ConnectedAt: &connectedAt,
+		})
+	}
+	for _, cursor := range cursors {
+		updatedAt := cursor.UpdatedAt
+		if i, ok := index[cursor.DeviceID]; ok {
+			response.Devices[i].LastMessageID = cursor.LastMessageID
+			response.Devices[i].UpdatedAt = &updatedAt
+			continue
+		}
+		response.Devices = append(response.Devices, api.DeviceResponse{
+			DeviceID:      cursor.DeviceID,
+			LastMessageID: cursor.LastMessageID,
+			UpdatedAt:     &updatedAt,
+		})
+	}
+	return response, nil
+}
+
+// FilterContacts 从userIDs中筛选与用户有过消息往来的用户
+func (s *messageService) FilterContacts(userID uint, userIDs []uint) ([]uint, error) {
+	contactIDs, err := s.repo.FilterContacts(userID, userIDs)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询联系人失败", err)
+	}
+	return contactIDs, nil
+}
+
+// GetPresence 获取用户的在线状态，离线用户返回最后在线时间
+func (s *messageService) GetPresence(userIDs []uint) ([]websocket.Presence, error) {
+	presence := make([]websocket.Presence, 0, len(userIDs))
+	var offline []uint
+	for _, userID := range userIDs {
+		online := s.realtime.IsUserOnline(userID)
+		presence = append(presence, websocket.Presence{UserID: userID, Online: online})
+		if !online {
+			offline = append(offline, userID)
+		}
+	}
+	if len(offline) == 0 {
+		return presence, nil
+	}
+
+	lastSeen, err := s.repo.GetLastSeen(offline)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询在线状态失败", err)
+	}
+	for i := range presence {
+		if at, ok := lastSeen[presence[i].UserID]; ok && !presence[i].Online {
+			presence[i].LastSeen = &at
+		}
+	}
+	return presence, nil
+}
+
+// SendTyping 向联系人转发输入状态，只能发给有过消息往来的用户
+func (s *messageService) SendTyping(userID uint, req *api.TypingRequest) error {
+	contactIDs, err := s.FilterContacts(userID, []uint{req.ContactID})
+	if err != nil {
+		return err
+	}
+	if len(contactIDs) == 0 {
+		return errors.NewForbiddenError("只能向会话中的用户发送输入状态", nil)
+	}
+
+	s.realtime.SendFrame(req.ContactID, websocket.FrameTyping, api.TypingEvent{UserID: userID, Typing: req.Typing})
+	return nil
+}
+
+// WithdrawMessage 撤回消息，只有发送者可以在撤回时限内撤回，重复撤回直接返回成功
+func (s *messageService) WithdrawMessage(userID, messageID uint) error {
+	message, err := s.getConversationMessage(userID, messageID)
+	if err != nil {
+		return err
+	}
+	if message.SenderID != userID {
+		return errors.NewForbiddenError("只能撤回自己发送的消息", nil)
+	}
+	if message.IsWithdrawn {
+		return nil
+	}
+
+	since := time.Now().Add(-s.withdrawWindow)
+	if !message.CreatedAt.After(since) {
+		return errors.NewBadRequestError("消息已超过撤回时限", nil)
+	}
+	events, err := s.repo.Withdraw(messageID, userID, since)
+	if err != nil {
+		return errors.NewInternalServerError("撤回消息失败", err)
+	}
+	if len(events) == 0 {
+		return errors.NewConflictError("消息已超过撤回时限或已被删除", nil)
+	}
+
+	s.pushMessageEvents(events)
+	return nil
+}
+
+// DeleteMessageByUser 删除消息，会话双方都可以删除，只对删除者隐藏，另一方仍可见
+func (s *messageService) DeleteMessageByUser(userID, messageID uint) error {
+	if _, err := s.getConversationMessage(userID, messageID); err != nil {
+		return err
+	}
+	events, err := s.repo.Delete(messageID, userID)
+	if err != nil {
+		return errors.NewInternalServerError("删除消息失败", err)
+	}
+
+	s.pushMessageEvents(events)
+	return nil
+}
+
+// getConversationMessage 获取用户收发的、对用户可见的消息
+func (s *messageService) getConversationMessage(userID, messageID uint) (*models.Message, error) {
+	message, err := s.repo.GetByID(messageID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("消息", err)
+		}
+		return nil, errors.NewInternalServerError("查询消息失败", err)
+	}
+	if message.SenderID != userID && message.ReceiverID != userID {
+		return nil, errors.NewForbiddenError("无权访问该消息", nil)
+	}
+	if message.IsDeleted || (message.SenderID == userID && message.DeletedBySender) ||
+		(message.ReceiverID == userID && message.DeletedByReceiver) {
+		return nil, errors.NewNotFoundError("消息", nil)
+	}
+	return message, nil
+}
+
+// messageEventFrames 消息变更事件对应的推送帧类型
+var messageEventFrames = map[string]string{
+	models.MessageEventWithdrawn: websocket.FrameMessageWithdrawn,
+	models.MessageEventDeleted:   websocket.FrameMessageDeleted,
+}
+
+// pushMessageEvents 向事件对应用户的所有在线设备推送撤回或删除事件，操作者的其他设备也据此同步；
+// 离线的设备重连时从事件表补发
+func (s *messageService) pushMessageEvents(events []models.MessageEvent) {
+	for i := range events {
+		s.realtime.SendFrame(events[i].UserID, messageEventFrames[events[i].Type], api.ToMessageEvent(&events[i]))
+	}
+}
+
 // GetMessagesForAdmin 管理员获取消息列表
 func (s *messageService) GetMessagesForAdmin(req *api.AdminMessageListRequest) (*api.AdminMessageListResponse, error) {
-	// 设置默认值
-	if req.Page == 0 {
+	// 设置默认值，未传入游标时保持原有的page/size分页
+	if req.Cursor == "" && req.Page == 0 {
 		req.Page = 1
 	}
 	if req.Size == 0 {
 		req.Size = 10
 	}
 
+	params, err := pagination.NewParams(req.Cursor, req.Page, req.Size)
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+
 	// 获取消息列表
-	messages, total, err := s.repo.GetMessagesForAdmin(
-		req.Search, req.Type, req.StartDate, req.EndDate, req.Page, req.Size)
+	messages, info, err := s.repo.GetMessagesForAdmin(
+		req.Search, req.Type, req.StartDate, req.EndDate, params)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取消息列表失败", err)
 	}
 
 	// 构建响应
 	response := &api.AdminMessageListResponse{
-		Total: total,
-		List:  make([]api.AdminMessageItem, 0, len(messages)),
+		List: make([]api.AdminMessageItem, 0, len(messages)),
+		Info: info,
 	}
 
 	// 填充消息数据
@@ -302,24 +601,29 @@ func (s *messageService) GetConversationsForAdmin(req *api.AdminConversationList
 
 // GetMessageHistoryForAdmin 管理员获取会话消息历史
 func (s *messageService) GetMessageHistoryForAdmin(req *api.AdminMessageHistoryRequest) (*api.AdminMessageHistoryResponse, error) {
-	// 设置默认值
-	if req.Page == 0 {
+	// 设置默认值，未传入游标时保持原有的page/size分页
+	if req.Cursor == "" && req.Page == 0 {
 		req.Page = 1
 	}
 	if req.Size == 0 {
 		req.Size = 20
 	}
 
+	params, err := pagination.NewParams(req.Cursor, req.Page, req.Size)
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+
 	// 获取消息历史
-	messages, total, err := s.repo.GetMessageHistoryForAdmin(req.User1ID, req.User2ID, req.Page, req.Size)
+	messages, info, err := s.repo.GetMessageHistoryForAdmin(req.User1ID, req.User2ID, params)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取消息历史失败", err)
 	}
 
 	// 构建响应
 	response := &api.AdminMessageHistoryResponse{
-		Total: total,
-		List:  make([]api.AdminMessageHistoryItem, 0, len(messages)),
+		List: make([]api.AdminMessageHistoryItem, 0, len(messages)),
+		Info: info,
 	}
 
 	// 填充消息数据
@@ -360,6 +664,9 @@ func (s *messageService) GetMessageHistoryForAdmin(req *api.AdminMessageHistoryR
 
 // SendSystemMessage 管理员发送系统消息
 func (s *messageService) SendSystemMessage(req *api.AdminSendSystemMessageRequest) error {
+	var message *models.Message
+	var err error
+
 	// 如果receiverID为0，表示发送给所有用户
 	if req.ReceiverID == 0 {
 		// 查询所有用户ID
@@ -382,11 +689,19 @@ func (s *messageService) SendSystemMessage(req *api.AdminSendSystemMessageReques
 		// }
 
 		// 简化处理，创建一个接收者ID为0的系统消息，表示发送给所有用户
-		return s.repo.CreateSystemMessage(0, req.Content, req.Title)
+		message, err = s.repo.CreateSystemMessage(0, req.Content, req.Title)
+	} else {
+		// 发送给特定用户
+		message, err = s.repo.CreateSystemMessage(req.ReceiverID, req.Content, req.Title)
+	}
+	if err != nil {
+		return err
 	}
 
-	// 发送给特定用户
-	return s.repo.CreateSystemMessage(req.ReceiverID, req.Content, req.Title)
+	// 推送给在线设备，全员系统消息推送给所有在线设备
+	messageResponse := api.ToMessageResponse(message)
+	s.publish(&messageResponse)
+	return nil
 }
 
 // DeleteMessage 删除消息
@@ -397,10 +712,12 @@ func (s *messageService) DeleteMessage(messageID uint) error {
 		return errors.NewNotFoundError("消息", err)
 	}
 
-	// 删除消息（管理员可以删除任何消息）
-	if err := s.repo.Delete(messageID, 0); err != nil {
+	// 删除消息（管理员可以删除任何消息），会话双方都不再可见
+	events, err := s.repo.DeleteForAll(messageID)
+	if err != nil {
 		return errors.NewInternalServerError("删除消息失败", err)
 	}
 
+	s.pushMessageEvents(events)
 	return nil
 }
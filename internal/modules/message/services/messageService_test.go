@@ -0,0 +1,271 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/message/api"
+	"campus/internal/modules/message/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/testdb"
+	"campus/internal/websocket"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type countingPublisher struct {
+	published int
+}
+
+func (p *countingPublisher) Publish(body []byte, contentType string) error {
+	p.published++
+	return nil
+}
+
+type sentFrame struct {
+	userID    uint
+	frameType string
+	data      interface{}
+}
+
+type recordingRealtime struct {
+	frames []sentFrame
+	online map[uint]bool
+}
+
+func (r *recordingRealtime) IsUserOnline(userID uint) bool {
+	return r.online[userID]
+}
+
+func (r *recordingRealtime) Sessions(userID uint) []websocket.Session {
+	return nil
+}
+
+func (r *recordingRealtime) SendFrame(userID uint, frameType string, data interface{}) bool {
+	r.frames = append(r.frames, sentFrame{userID: userID, frameType: frameType, data: data})
+	return true
+}
+
+// newTestService 使用内存SQLite创建消息服务
+func newTestService(t *testing.T) (MessageService, *gorm.DB, *countingPublisher, *recordingRealtime) {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.Message{}, &models.DeviceReadCursor{}, &models.MessageEvent{})
+
+	publisher := &countingPublisher{}
+	realtime := &recordingRealtime{online: map[uint]bool{}}
+	return NewMessageService(repositories.NewMessageRepository(db), publisher, realtime, 2*time.Minute), db, publisher, realtime
+}
+
+func TestSendMessageDeduplicatesClientID(t *testing.T) {
+	service, db, publisher, _ := newTestService(t)
+	req := api.SendMessageRequest{ReceiverID: 2, Content: "还在吗", ClientID: "c-1"}
+
+	first, err := service.SendMessage(1, req)
+	require.NoError(t, err)
+	retry, err := service.SendMessage(1, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ID, retry.ID)
+	assert.Equal(t, "c-1", retry.ClientID)
+	assert.Equal(t, 1, publisher.published)
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	// 不同发送者可以使用相同的客户端ID
+	other, err := service.SendMessage(3, req)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID, other.ID)
+}
+
+// racingRepository 模拟并发重发：去重查询时另一个请求尚未保存消息
+type racingRepository struct {
+	repositories.MessageRepository
+	missed bool
+}
+
+func (r *racingRepository) GetByClientID(senderID uint, clientID string) (*models.Message, error) {
+	if !r.missed {
+		r.missed = true
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.MessageRepository.GetByClientID(senderID, clientID)
+}
+
+func TestSendMessageConcurrentRetryReturnsSaved(t *testing.T) {
+	_, db, _, _ := newTestService(t)
+	clientID := "c-1"
+	saved := &models.Message{SenderID: 1, ReceiverID: 2, Content: "还在吗", Type: models.MessageTypeText, ClientID: &clientID}
+	require.NoError(t, db.Create(saved).Error)
+
+	publisher := &countingPublisher{}
+	repo := &racingRepository{MessageRepository: repositories.NewMessageRepository(db)}
+	service := NewMessageService(repo, publisher, &recordingRealtime{online: map[uint]bool{}}, 2*time.Minute)
+
+	// 唯一索引拦截重复保存，返回先保存的消息
+	retry, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "还在吗", ClientID: clientID})
+	require.NoError(t, err)
+	assert.Equal(t, saved.ID, retry.ID)
+	assert.Equal(t, clientID, retry.ClientID)
+	assert.Zero(t, publisher.published)
+	var count int64
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestReadUpToSendsReceiptAndAdvancesCursor(t *testing.T) {
+	service, db, _, realtime := newTestService(t)
+	var ids []uint
+	for _, content := range []string{"一", "二", "三"} {
+		msg, err := service.SendMessage(2, api.SendMessageRequest{ReceiverID: 1, Content: content})
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+
+	require.NoError(t, service.ReadUpTo(1, "phone", &api.ReadUpToRequest{ContactID: 2, LastMessageID: ids[1]}))
+	var unread int64
+	db.Model(&models.Message{}).Where("is_read = ?", false).Count(&unread)
+	assert.Equal(t, int64(1), unread)
+
+	require.Len(t, realtime.frames, 1)
+	assert.Equal(t, uint(2), realtime.frames[0].userID)
+	assert.Equal(t, websocket.FrameRead, realtime.frames[0].frameType)
+	receipt := realtime.frames[0].data.(api.ReadReceipt)
+	assert.Equal(t, uint(1), receipt.ReaderID)
+	assert.Equal(t, ids[1], receipt.LastMessageID)
+
+	devices, err := service.GetDevices(1)
+	require.NoError(t, err)
+	require.Len(t, devices.Devices, 1)
+	assert.Equal(t, ids[1], devices.Devices[0].LastMessageID)
+
+	// 没有新标记的消息时不重复推送回执
+	require.NoError(t, service.ReadUpTo(1, "laptop", &api.ReadUpToRequest{ContactID: 2, LastMessageID: ids[0]}))
+	assert.Len(t, realtime.frames, 1)
+}
+
+func TestTypingAndPresenceOnlyBetweenContacts(t *testing.T) {
+	service, db, _, realtime := newTestService(t)
+	_, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "你好"})
+	require.NoError(t, err)
+
+	require.NoError(t, service.SendTyping(2, &api.TypingRequest{ContactID: 1, Typing: true}))
+	require.Len(t, realtime.frames, 1)
+	assert.Equal(t, uint(1), realtime.frames[0].userID)
+	assert.Equal(t, api.TypingEvent{UserID: 2, Typing: true}, realtime.frames[0].data)
+
+	// 没有消息往来的用户不能发送输入状态
+	err = service.SendTyping(3, &api.TypingRequest{ContactID: 1, Typing: true})
+	assert.True(t, errors.IsForbidden(err))
+	assert.Len(t, realtime.frames, 1)
+
+	seen := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Create(&models.User{Username: "u2", Password: "x", Email: "u2@example.com"}).Error)
+	require.NoError(t, db.Model(&models.User{}).Where("username = ?", "u2").Update("last_seen_at", seen).Error)
+	var u2 models.User
+	require.NoError(t, db.Where("username = ?", "u2").First(&u2).Error)
+	realtime.online[7] = true
+
+	presence, err := service.GetPresence([]uint{7, u2.ID})
+	require.NoError(t, err)
+	require.Len(t, presence, 2)
+	assert.Equal(t, websocket.Presence{UserID: 7, Online: true}, presence[0])
+	assert.False(t, presence[1].Online)
+	require.NotNil(t, presence[1].LastSeen)
+	assert.True(t, seen.Equal(*presence[1].LastSeen))
+}
+
+func TestWithdrawMessageNotifiesBothParties(t *testing.T) {
+	service, db, _, realtime := newTestService(t)
+	sent, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "发错了"})
+	require.NoError(t, err)
+
+	// 接收者不能撤回
+	err = service.WithdrawMessage(2, sent.ID)
+	assert.True(t, errors.IsForbidden(err))
+
+	require.NoError(t, service.WithdrawMessage(1, sent.ID))
+	require.Len(t, realtime.frames, 2)
+	for i, userID := range []uint{1, 2} {
+		assert.Equal(t, userID, realtime.frames[i].userID)
+		assert.Equal(t, websocket.FrameMessageWithdrawn, realtime.frames[i].frameType)
+		event := realtime.frames[i].data.(api.MessageEvent)
+		assert.NotZero(t, event.EventID)
+		assert.Equal(t, api.MessageEvent{EventID: event.EventID, MessageID: sent.ID, SenderID: 1, ReceiverID: 2, OperatorID: 1}, event)
+	}
+
+	// 重复撤回直接返回成功，不再推送
+	require.NoError(t, service.WithdrawMessage(1, sent.ID))
+	assert.Len(t, realtime.frames, 2)
+
+	last, err := service.GetLastMessage(2, 1)
+	require.NoError(t, err)
+	assert.True(t, last.Withdrawn)
+	assert.Empty(t, last.Content)
+
+	// 超过撤回时限
+	late, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "很早以前"})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Message{}).Where("id = ?", late.ID).
+		Update("created_at", time.Now().Add(-3*time.Minute)).Error)
+	err = service.WithdrawMessage(1, late.ID)
+	assert.True(t, errors.IsBadRequest(err))
+}
+
+func TestDeleteMessageByUserHidesMessage(t *testing.T) {
+	service, _, _, realtime := newTestService(t)
+	first, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "第一条"})
+	require.NoError(t, err)
+	second, err := service.SendMessage(2, api.SendMessageRequest{ReceiverID: 1, Content: "第二条"})
+	require.NoError(t, err)
+
+	// 会话之外的用户不能删除
+	err = service.DeleteMessageByUser(3, second.ID)
+	assert.True(t, errors.IsForbidden(err))
+
+	// 接收者也可以删除，只同步到删除者自己的设备
+	require.NoError(t, service.DeleteMessageByUser(1, second.ID))
+	require.Len(t, realtime.frames, 1)
+	assert.Equal(t, uint(1), realtime.frames[0].userID)
+	assert.Equal(t, websocket.FrameMessageDeleted, realtime.frames[0].frameType)
+	event := realtime.frames[0].data.(api.MessageEvent)
+	assert.Equal(t, api.MessageEvent{EventID: event.EventID, MessageID: second.ID, SenderID: 2, ReceiverID: 1, OperatorID: 1}, event)
+
+	err = service.DeleteMessageByUser(1, second.ID)
+	assert.True(t, errors.IsNotFound(err))
+
+	// 删除者看不到该消息，会话另一方仍然可见
+	last, err := service.GetLastMessage(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, last.ID)
+	last, err = service.GetLastMessage(2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, last.ID)
+
+	unread, err := service.GetUnreadCount(1)
+	require.NoError(t, err)
+	assert.Zero(t, unread)
+}
+
+func TestBacklogReplaysWithdrawToOfflineDevice(t *testing.T) {
+	service, db, _, _ := newTestService(t)
+	sent, err := service.SendMessage(1, api.SendMessageRequest{ReceiverID: 2, Content: "发错了"})
+	require.NoError(t, err)
+
+	// 接收者已收到消息后离线，期间发送者撤回
+	require.NoError(t, service.WithdrawMessage(1, sent.ID))
+
+	backlog := NewMessageBacklog(repositories.NewMessageRepository(db))
+	deliveries, err := backlog.EventsSince(2, 0, sent.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	payload := string(deliveries[0].Payload)
+	assert.Contains(t, payload, websocket.FrameMessageWithdrawn)
+	assert.Contains(t, payload, `"event_id":`)
+
+	// 已处理的事件不再补发
+	deliveries, err = backlog.EventsSince(2, deliveries[0].ID, sent.ID, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"campus/internal/models"
+	"time"
+)
+
+// DisputeResponse 售后申请信息
+type DisputeResponse struct {
+	ID               uint       `json:"id"`
+	OrderID          uint       `json:"order_id"`
+	BuyerID          uint       `json:"buyer_id"`
+	SellerID         uint       `json:"seller_id"`
+	Reason           string     `json:"reason"`
+	BuyerEvidences   []string   `json:"buyer_evidences"`
+	Status           string     `json:"status"`
+	SellerResponse   string     `json:"seller_response,omitempty"`
+	SellerEvidences  []string   `json:"seller_evidences"`
+	RespondedAt      *time.Time `json:"responded_at,omitempty"`
+	Resolution       string     `json:"resolution,omitempty"`
+	RefundAmount     float64    `json:"refund_amount,omitempty"`
+	ResolutionRemark string     `json:"resolution_remark,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// DisputeListResponse 售后申请列表
+type DisputeListResponse struct {
+	Total int64              `json:"total"`
+	List  []*DisputeResponse `json:"list"`
+}
+
+func ConvertToDisputeResponse(dispute *models.Dispute) *DisputeResponse {
+	resp := &DisputeResponse{
+		ID:               dispute.ID,
+		OrderID:          dispute.OrderID,
+		BuyerID:          dispute.BuyerID,
+		SellerID:         dispute.SellerID,
+		Reason:           dispute.Reason,
+		BuyerEvidences:   []string{},
+		Status:           dispute.Status,
+		SellerResponse:   dispute.SellerResponse,
+		SellerEvidences:  []string{},
+		RespondedAt:      dispute.RespondedAt,
+		Resolution:       dispute.Resolution,
+		RefundAmount:     dispute.RefundAmount,
+		ResolutionRemark: dispute.ResolutionRemark,
+		ResolvedAt:       dispute.ResolvedAt,
+		CreatedAt:        dispute.CreatedAt,
+	}
+
+	// 按上传人区分买卖双方的凭证
+	for _, e := range dispute.Evidences {
+		if e.UserID == dispute.SellerID {
+			resp.SellerEvidences = append(resp.SellerEvidences, e.ImageURL)
+		} else {
+			resp.BuyerEvidences = append(resp.BuyerEvidences, e.ImageURL)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"campus/internal/models"
+	"time"
+)
+
+// OfferResponse 出价信息
+type OfferResponse struct {
+	ID           uint       `json:"id"`
+	ProductID    uint       `json:"product_id"`
+	ProductTitle string     `json:"product_title"`
+	ListPrice    float64    `json:"list_price"` // 商品标价
+	BuyerID      uint       `json:"buyer_id"`
+	SellerID     uint       `json:"seller_id"`
+	ProposerID   uint       `json:"proposer_id"`
+	ParentID     *uint      `json:"parent_id,omitempty"`
+	Price        float64    `json:"price"`
+	Message      string     `json:"message,omitempty"`
+	Status       string     `json:"status"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty"`
+	OrderID      *uint      `json:"order_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// OfferListResponse 出价记录列表
+type OfferListResponse struct {
+	Total int64            `json:"total"`
+	List  []*OfferResponse `json:"list"`
+}
+
+func ConvertToOfferResponse(offer *models.Offer) *OfferResponse {
+	return &OfferResponse{
+		ID:           offer.ID,
+		ProductID:    offer.ProductID,
+		ProductTitle: offer.Product.Title,
+		ListPrice:    offer.Product.Price,
+		BuyerID:      offer.BuyerID,
+		SellerID:     offer.SellerID,
+		ProposerID:   offer.ProposerID,
+		ParentID:     offer.ParentID,
+		Price:        offer.Price,
+		Message:      offer.Message,
+		Status:       offer.Status,
+		ExpiresAt:    offer.ExpiresAt,
+		RespondedAt:  offer.RespondedAt,
+		OrderID:      offer.OrderID,
+		CreatedAt:    offer.CreatedAt,
+	}
+}
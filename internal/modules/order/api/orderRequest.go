@@ -1,26 +1,37 @@
 package api
 
+import "campus/internal/utils/pagination"
+
+// CreateOrderRequest 创建订单请求，买家取自登录用户，卖家取自商品发布者
 type CreateOrderRequest struct {
-	BuyerID   uint `json:"buyer_id" binding:"required"`
-	SellerID  uint `json:"seller_id" binding:"required"`
 	ProductID uint `json:"product_id" binding:"required"`
 	//Price     float64 `json:"price" binding:"required"`
 }
 
 type UpdateOrderStatusRequest struct {
-	// 明确状态可选值为卖家已同意或卖家已拒绝
-	Status string `json:"status" binding:"required,oneof=卖家已同意 卖家已拒绝"`
+	// 买卖双方可发起的状态变更，是否允许由订单状态机根据当前状态和身份校验
+	Status string `json:"status" binding:"required,oneof=卖家已同意 卖家已拒绝 待付款 待收货 已完成 已取消"`
 	Remark string `json:"remark"` // 备注信息，可选
 }
 
+// GetUserOrdersRequest 查询当前登录用户的订单，用户取自JWT
 type GetUserOrdersRequest struct {
-	UserID uint `json:"user_id" form:"user_id" binding:"required"`
-	Page   uint `json:"page" form:"page" binding:"required,min=1"`
-	Size   uint `json:"size" form:"size" binding:"required,min=1,max=100"`
+	pagination.Request
+}
+
+// UserOrderListRequest 按买家或卖家身份查询订单列表请求
+type UserOrderListRequest struct {
+	pagination.Request
+	Status    string `json:"status" form:"status"`
+	StartDate string `json:"start_date" form:"start_date"` // 格式 2006-01-02
+	EndDate   string `json:"end_date" form:"end_date"`     // 格式 2006-01-02，包含当天
+	// 只有created_desc支持游标分页
+	Sort string `json:"sort" form:"sort" binding:"omitempty,oneof=created_desc created_asc updated_desc"`
 }
 
 // AdminOrderListRequest 管理员获取订单列表请求
 type AdminOrderListRequest struct {
+	Cursor    string `json:"cursor" form:"cursor"` // 分页游标，传入时使用游标分页
 	Page      uint   `json:"page" form:"page"`
 	PageSize  uint   `json:"pageSize" form:"pageSize"`
 	Search    string `json:"search" form:"search"`
@@ -29,8 +40,64 @@ type AdminOrderListRequest struct {
 	EndDate   string `json:"endDate" form:"endDate"`
 }
 
+// AdminOrderExportRequest 管理员导出订单请求，筛选条件与订单列表一致
+type AdminOrderExportRequest struct {
+	Search    string `json:"search" form:"search"`
+	Status    string `json:"status" form:"status"`
+	StartDate string `json:"startDate" form:"startDate"`
+	EndDate   string `json:"endDate" form:"endDate"`
+	Format    string `json:"format" form:"format" binding:"omitempty,oneof=csv xlsx jsonl"` // 默认csv
+}
+
 // AdminUpdateOrderStatusRequest 管理员更新订单状态请求
 type AdminUpdateOrderStatusRequest struct {
 	Status string `json:"status" binding:"required"`
 	Remark string `json:"remark"` // 备注信息，可选
 }
+
+// OpenDisputeRequest 买家申请售后请求，凭证图片通过上传接口获取地址
+type OpenDisputeRequest struct {
+	Reason    string   `json:"reason" binding:"required,max=500"`
+	Evidences []string `json:"evidences" binding:"max=9,dive,startswith=/static/"`
+}
+
+// RespondDisputeRequest 卖家回应售后请求
+type RespondDisputeRequest struct {
+	Response  string   `json:"response" binding:"required,max=500"`
+	Evidences []string `json:"evidences" binding:"max=9,dive,startswith=/static/"`
+}
+
+// AdminDisputeListRequest 管理员获取售后列表请求
+type AdminDisputeListRequest struct {
+	Page     uint   `json:"page" form:"page"`
+	PageSize uint   `json:"pageSize" form:"pageSize"`
+	Status   string `json:"status" form:"status"`
+}
+
+// ResolveDisputeRequest 管理员处理售后请求
+type ResolveDisputeRequest struct {
+	Resolution   string  `json:"resolution" binding:"required,oneof=refund partial reject"`
+	RefundAmount float64 `json:"refundAmount"` // 部分退款金额，resolution为partial时必填
+	Remark       string  `json:"remark" binding:"max=500"`
+}
+
+// CreateOfferRequest 买家对商品出价请求
+type CreateOfferRequest struct {
+	ProductID uint    `json:"product_id" binding:"required"`
+	Price     float64 `json:"price" binding:"required,gt=0"`
+	Message   string  `json:"message" binding:"max=200"`
+}
+
+// CounterOfferRequest 对出价还价请求
+type CounterOfferRequest struct {
+	Price   float64 `json:"price" binding:"required,gt=0"`
+	Message string  `json:"message" binding:"max=200"`
+}
+
+// OfferListRequest 按买家或卖家身份查询出价记录请求
+type OfferListRequest struct {
+	Page   uint   `json:"page" form:"page" binding:"required,min=1"`
+	Size   uint   `json:"size" form:"size" binding:"required,min=1,max=100"`
+	Role   string `json:"role" form:"role" binding:"omitempty,oneof=buyer seller"` // 默认buyer
+	Status string `json:"status" form:"status"`
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"time"
 )
 
@@ -26,6 +27,7 @@ func ConvertToOrderResponse(order *models.Order) *OrderResponse {
 		SellerID:     order.SellerID,
 		ProductID:    order.ProductID,
 		Status:       order.Status,
+		Price:        order.AgreedPrice,
 		PayTime:      order.PayTime,
 		DeliveryTime: order.DeliveryTime,
 		CompleteTime: order.CompleteTime,
@@ -36,21 +38,19 @@ func ConvertToOrderResponse(order *models.Order) *OrderResponse {
 
 type OrderListResponse struct {
 	Orders []*OrderResponse `json:"orders"`
-	Total  uint             `json:"total"`
-	Page   uint             `json:"page"`
-	Size   uint             `json:"size"`
+	pagination.Info
+	// StatusCounts 各状态订单数量，用于前端角标展示
+	StatusCounts map[string]int64 `json:"status_counts,omitempty"`
 }
 
-func ConvertToOrderListResponse(orders []*models.Order, total, page, size uint) *OrderListResponse {
+func ConvertToOrderListResponse(orders []*models.Order, info pagination.Info) *OrderListResponse {
 	var orderResponses []*OrderResponse
 	for _, order := range orders {
 		orderResponses = append(orderResponses, ConvertToOrderResponse(order))
 	}
 	return &OrderListResponse{
 		Orders: orderResponses,
-		Total:  total,
-		Page:   page,
-		Size:   size,
+		Info:   info,
 	}
 }
 
@@ -70,16 +70,20 @@ type AdminOrderItem struct {
 
 // AdminOrderListResponse 管理员订单列表响应
 type AdminOrderListResponse struct {
-	Total int64            `json:"total"`
-	List  []AdminOrderItem `json:"list"`
+	List []AdminOrderItem `json:"list"`
+	pagination.Info
 }
 
 // OrderLogItem 订单日志项
 type OrderLogItem struct {
-	Action   string    `json:"action"`
-	Time     time.Time `json:"time"`
-	Operator string    `json:"operator"`
-	Remark   string    `json:"remark,omitempty"`
+	Action       string    `json:"action"`
+	Time         time.Time `json:"time"`
+	Operator     string    `json:"operator"`
+	OperatorID   uint      `json:"operatorId"`
+	OperatorRole string    `json:"operatorRole"`
+	FromStatus   string    `json:"fromStatus,omitempty"`
+	ToStatus     string    `json:"toStatus,omitempty"`
+	Remark       string    `json:"remark,omitempty"`
 }
 
 // AdminOrderDetailResponse 管理员订单详情响应
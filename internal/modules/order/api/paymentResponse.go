@@ -0,0 +1,46 @@
+package api
+
+import (
+	"campus/internal/models"
+	"time"
+)
+
+// PaymentIntentResponse 创建支付单响应
+type PaymentIntentResponse struct {
+	TradeNo   string            `json:"trade_no"`
+	Provider  string            `json:"provider"`
+	Amount    float64           `json:"amount"`
+	Status    string            `json:"status"`
+	PayParams map[string]string `json:"pay_params"` // 客户端拉起支付所需的参数
+}
+
+// PaymentResponse 支付单信息
+type PaymentResponse struct {
+	ID           uint       `json:"id"`
+	OrderID      uint       `json:"order_id"`
+	TradeNo      string     `json:"trade_no"`
+	Provider     string     `json:"provider"`
+	Amount       float64    `json:"amount"`
+	Status       string     `json:"status"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+	RefundAmount float64    `json:"refund_amount,omitempty"`
+	RefundedAt   *time.Time `json:"refunded_at,omitempty"`
+	FailReason   string     `json:"fail_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func ConvertToPaymentResponse(payment *models.Payment) *PaymentResponse {
+	return &PaymentResponse{
+		ID:           payment.ID,
+		OrderID:      payment.OrderID,
+		TradeNo:      payment.TradeNo,
+		Provider:     payment.Provider,
+		Amount:       payment.Amount,
+		Status:       payment.Status,
+		PaidAt:       payment.PaidAt,
+		RefundAmount: payment.RefundAmount,
+		RefundedAt:   payment.RefundedAt,
+		FailReason:   payment.FailReason,
+		CreatedAt:    payment.CreatedAt,
+	}
+}
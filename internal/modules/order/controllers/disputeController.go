@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type DisputeController struct {
+	service services.DisputeService
+}
+
+func NewDisputeController(srv services.DisputeService) *DisputeController {
+	return &DisputeController{
+		service: srv,
+	}
+}
+
+// OpenDispute 买家申请售后
+func (c *DisputeController) OpenDispute(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	var req api.OpenDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	dispute, err := c.service.OpenDispute(uint(id), userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "售后申请已提交", dispute)
+}
+
+// RespondDispute 卖家回应售后
+func (c *DisputeController) RespondDispute(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	var req api.RespondDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	dispute, err := c.service.RespondDispute(uint(id), userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "售后回应已提交", dispute)
+}
+
+// GetOrderDisputes 买卖双方查看订单售后记录
+func (c *DisputeController) GetOrderDisputes(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	disputes, err := c.service.GetOrderDisputes(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, disputes)
+}
+
+// AdminListDisputes 管理员获取售后列表
+func (c *DisputeController) AdminListDisputes(ctx *gin.Context) {
+	var req api.AdminDisputeListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	result, err := c.service.AdminListDisputes(&req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "获取成功", result)
+}
+
+// AdminGetOrderDisputes 管理员获取订单售后记录
+func (c *DisputeController) AdminGetOrderDisputes(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	disputes, err := c.service.AdminGetOrderDisputes(uint(id))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "获取成功", disputes)
+}
+
+// ResolveDispute 管理员处理售后
+func (c *DisputeController) ResolveDispute(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	var req api.ResolveDisputeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	dispute, err := c.service.ResolveDispute(uint(id), adminID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "售后处理成功", dispute)
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type OfferController struct {
+	service services.OfferService
+}
+
+func NewOfferController(srv services.OfferService) *OfferController {
+	return &OfferController{
+		service: srv,
+	}
+}
+
+// CreateOffer 买家对商品出价
+func (c *OfferController) CreateOffer(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.CreateOfferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	offer, err := c.service.CreateOffer(userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "出价已发送", offer)
+}
+
+// CounterOffer 还价
+func (c *OfferController) CounterOffer(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效出价ID", err))
+		return
+	}
+
+	var req api.CounterOfferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	offer, err := c.service.CounterOffer(uint(id), userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "还价已发送", offer)
+}
+
+// AcceptOffer 接受出价并生成订单
+func (c *OfferController) AcceptOffer(ctx *gin.Context) {
+	c.handleOffer(ctx, c.service.AcceptOffer, "已接受出价，订单已生成")
+}
+
+// RejectOffer 拒绝出价
+func (c *OfferController) RejectOffer(ctx *gin.Context) {
+	c.handleOffer(ctx, c.service.RejectOffer, "已拒绝出价")
+}
+
+// WithdrawOffer 撤回出价
+func (c *OfferController) WithdrawOffer(ctx *gin.Context) {
+	c.handleOffer(ctx, c.service.WithdrawOffer, "出价已撤回")
+}
+
+// GetOffer 查看出价详情
+func (c *OfferController) GetOffer(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效出价ID", err))
+		return
+	}
+
+	offer, err := c.service.GetOffer(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, offer)
+}
+
+// ListOffers 获取我发出或收到的出价
+func (c *OfferController) ListOffers(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.OfferListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	offers, err := c.service.ListOffers(userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, offers)
+}
+
+// handleOffer 处理无请求体的出价操作：接受、拒绝、撤回
+func (c *OfferController) handleOffer(ctx *gin.Context, action func(offerID, userID uint) (*api.OfferResponse, error), message string) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效出价ID", err))
+		return
+	}
+
+	offer, err := action(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, message, offer)
+}
@@ -4,10 +4,15 @@ import (
 	"campus/internal/modules/order/api"
 	"campus/internal/modules/order/services"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/export"
+	"campus/internal/utils/logger"
 	"campus/internal/utils/response"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type OrderController struct {
@@ -21,13 +26,19 @@ func NewOrderController(srv services.OrderService) *OrderController {
 }
 
 func (c *OrderController) CreateOrder(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	var req api.CreateOrderRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
 
-	order, err := c.service.CreateOrder(&req)
+	order, err := c.service.CreateOrder(userID.(uint), &req)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -37,6 +48,12 @@ func (c *OrderController) CreateOrder(ctx *gin.Context) {
 }
 
 func (c *OrderController) DeleteOrder(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -44,7 +61,7 @@ func (c *OrderController) DeleteOrder(ctx *gin.Context) {
 		return
 	}
 
-	if err := c.service.DeleteOrder(uint(id)); err != nil {
+	if err := c.service.DeleteOrder(uint(id), userID.(uint)); err != nil {
 		response.HandleError(ctx, err)
 		return
 	}
@@ -53,6 +70,12 @@ func (c *OrderController) DeleteOrder(ctx *gin.Context) {
 }
 
 func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -66,7 +89,7 @@ func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 		return
 	}
 
-	order, err := c.service.UpdateOrderStatus(uint(id), &req)
+	order, err := c.service.UpdateOrderStatus(uint(id), userID.(uint), &req)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -76,6 +99,12 @@ func (c *OrderController) UpdateOrderStatus(ctx *gin.Context) {
 }
 
 func (c *OrderController) GetOrderByID(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -83,7 +112,7 @@ func (c *OrderController) GetOrderByID(ctx *gin.Context) {
 		return
 	}
 
-	order, err := c.service.GetOrderByID(uint(id))
+	order, err := c.service.GetOrderByID(uint(id), userID.(uint))
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -93,13 +122,57 @@ func (c *OrderController) GetOrderByID(ctx *gin.Context) {
 }
 
 func (c *OrderController) GetUserOrders(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	var req api.GetUserOrdersRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
 
-	orders, err := c.service.GetUserOrders(req.UserID, req.Page, req.Size)
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	orders, err := c.service.GetUserOrders(userID.(uint), params)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, orders)
+}
+
+// GetSoldOrders 获取当前用户作为卖家收到的订单
+func (c *OrderController) GetSoldOrders(ctx *gin.Context) {
+	c.listOrdersByRole(ctx, c.service.GetSoldOrders)
+}
+
+// GetBoughtOrders 获取当前用户作为买家下的订单
+func (c *OrderController) GetBoughtOrders(ctx *gin.Context) {
+	c.listOrdersByRole(ctx, c.service.GetBoughtOrders)
+}
+
+func (c *OrderController) listOrdersByRole(ctx *gin.Context, list func(uint, *api.UserOrderListRequest) (*api.OrderListResponse, error)) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.UserOrderListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	orders, err := list(userID.(uint), &req)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -147,6 +220,12 @@ func (c *OrderController) GetAdminOrderDetail(ctx *gin.Context) {
 
 // AdminUpdateOrderStatus 管理员更新订单状态
 func (c *OrderController) AdminUpdateOrderStatus(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	idStr := ctx.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
@@ -161,7 +240,7 @@ func (c *OrderController) AdminUpdateOrderStatus(ctx *gin.Context) {
 	}
 	
 	// 更新订单状态
-	if err := c.service.AdminUpdateOrderStatus(uint(id), &req); err != nil {
+	if err := c.service.AdminUpdateOrderStatus(uint(id), adminID.(uint), &req); err != nil {
 		response.HandleError(ctx, err)
 		return
 	}
@@ -169,23 +248,37 @@ func (c *OrderController) AdminUpdateOrderStatus(ctx *gin.Context) {
 	response.SuccessWithMessage(ctx, "更新成功", nil)
 }
 
-// ExportOrders 导出订单数据
+// ExportOrders 导出订单数据，直接流式写入响应体
 func (c *OrderController) ExportOrders(ctx *gin.Context) {
-	var req api.AdminOrderListRequest
+	var req api.AdminOrderExportRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
-	
-	// 导出订单数据
-	data, err := c.service.ExportOrders(&req)
+	if req.Format == "" {
+		req.Format = export.FormatCSV
+	}
+
+	writer, err := export.NewWriter(req.Format, ctx.Writer, services.OrderExportColumns)
 	if err != nil {
-		response.HandleError(ctx, err)
+		response.HandleError(ctx, errors.NewBadRequestError(err.Error(), err))
 		return
 	}
-	
+
 	// 设置响应头
-	ctx.Header("Content-Disposition", "attachment; filename=orders.csv")
-	ctx.Header("Content-Type", "text/csv")
-	ctx.Data(http.StatusOK, "text/csv", data)
+	filename := fmt.Sprintf("orders_%s.%s", time.Now().Format("20060102150405"), req.Format)
+	ctx.Header("Content-Disposition", "attachment; filename="+filename)
+	ctx.Header("Content-Type", export.ContentType(req.Format))
+	ctx.Status(http.StatusOK)
+
+	if err := c.service.ExportOrders(&req, writer); err != nil {
+		// 尚未写出数据时还可以返回错误响应，否则只能中断下载
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Disposition")
+			ctx.Writer.Header().Del("Content-Type")
+			response.HandleError(ctx, err)
+			return
+		}
+		logger.Error("导出订单中断", zap.Error(err))
+	}
 }
@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"campus/internal/modules/order/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type PaymentController struct {
+	service services.PaymentService
+}
+
+func NewPaymentController(srv services.PaymentService) *PaymentController {
+	return &PaymentController{
+		service: srv,
+	}
+}
+
+// CreatePaymentIntent 买家为订单发起支付
+func (c *PaymentController) CreatePaymentIntent(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	intent, err := c.service.CreatePaymentIntent(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "支付单创建成功", intent)
+}
+
+// GetOrderPayments 获取订单的支付记录
+func (c *PaymentController) GetOrderPayments(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	payments, err := c.service.GetOrderPayments(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, payments)
+}
+
+// SimulatePay 本地模拟支付，fail=true 时模拟支付失败
+func (c *PaymentController) SimulatePay(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	success := ctx.Query("fail") != "true"
+	if err := c.service.SimulatePay(uint(id), userID.(uint), ctx.Param("tradeNo"), success); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "模拟支付完成", nil)
+}
+
+// PaymentCallback 支付渠道异步回调，参数以表单形式提交，通过签名校验来源
+func (c *PaymentController) PaymentCallback(ctx *gin.Context) {
+	if err := ctx.Request.ParseForm(); err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("回调参数错误", err))
+		return
+	}
+
+	params := make(map[string]string, len(ctx.Request.PostForm))
+	for k := range ctx.Request.PostForm {
+		params[k] = ctx.Request.PostForm.Get(k)
+	}
+
+	if err := c.service.HandleCallback(ctx.Param("provider"), params); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, nil)
+}
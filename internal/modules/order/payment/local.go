@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalProviderName 本地模拟支付渠道名称
+const LocalProviderName = "local"
+
+// LocalProvider 本地模拟支付渠道，用于校园内线下交易和测试
+// 回调参数使用HMAC-SHA256签名，与真实渠道的验签流程一致
+type LocalProvider struct {
+	secret []byte
+}
+
+// NewLocalProvider 创建本地模拟支付渠道
+func NewLocalProvider(secret string) *LocalProvider {
+	return &LocalProvider{secret: []byte(secret)}
+}
+
+func (p *LocalProvider) Name() string {
+	return LocalProviderName
+}
+
+// CreateIntent 本地渠道不需要远程下单，直接生成渠道交易号
+func (p *LocalProvider) CreateIntent(intent *Intent) (*IntentResult, error) {
+	providerTradeNo := "L" + intent.TradeNo
+	return &IntentResult{
+		ProviderTradeNo: providerTradeNo,
+		PayParams: map[string]string{
+			"provider":          LocalProviderName,
+			"trade_no":          intent.TradeNo,
+			"provider_trade_no": providerTradeNo,
+			"amount":            formatAmount(intent.Amount),
+		},
+	}, nil
+}
+
+// SignCallback 生成模拟支付结果的签名回调参数
+func (p *LocalProvider) SignCallback(tradeNo string, amount float64, success bool) map[string]string {
+	status := "SUCCESS"
+	if !success {
+		status = "FAIL"
+	}
+	params := map[string]string{
+		"trade_no":          tradeNo,
+		"provider_trade_no": "L" + tradeNo,
+		"amount":            formatAmount(amount),
+		"status":            status,
+		"timestamp":         strconv.FormatInt(time.Now().Unix(), 10),
+	}
+	params["sign"] = p.sign(params)
+	return params
+}
+
+func (p *LocalProvider) VerifyCallback(params map[string]string) (*CallbackResult, error) {
+	sign := params["sign"]
+	if sign == "" || !hmac.Equal([]byte(sign), []byte(p.sign(params))) {
+		return nil, errors.New("回调签名无效")
+	}
+
+	amount, err := strconv.ParseFloat(params["amount"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("回调金额无效: %w", err)
+	}
+
+	result := &CallbackResult{
+		TradeNo:         params["trade_no"],
+		ProviderTradeNo: params["provider_trade_no"],
+		Amount:          amount,
+		Success:         params["status"] == "SUCCESS",
+	}
+	if !result.Success {
+		result.FailReason = "模拟支付失败"
+	}
+	return result, nil
+}
+
+// Refund 本地渠道直接退款成功
+func (p *LocalProvider) Refund(req *RefundRequest) (*RefundResult, error) {
+	return &RefundResult{RefundNo: "R" + req.TradeNo}, nil
+}
+
+// sign 对除sign外的参数按键名排序后拼接，计算HMAC-SHA256
+func (p *LocalProvider) sign(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(params[k])
+	}
+
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(sb.String()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,34 @@
+package payment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLocalProviderCallback 测试本地渠道回调签名校验
+func TestLocalProviderCallback(t *testing.T) {
+	provider := NewLocalProvider("secret")
+
+	params := provider.SignCallback("P1001", 25.5, true)
+	result, err := provider.VerifyCallback(params)
+	assert.NoError(t, err)
+	assert.Equal(t, "P1001", result.TradeNo)
+	assert.Equal(t, 25.5, result.Amount)
+	assert.True(t, result.Success)
+
+	// 篡改金额后签名失效
+	params["amount"] = "0.01"
+	_, err = provider.VerifyCallback(params)
+	assert.Error(t, err)
+
+	// 其他密钥签名的回调无法通过校验
+	other := NewLocalProvider("other").SignCallback("P1001", 25.5, true)
+	_, err = provider.VerifyCallback(other)
+	assert.Error(t, err)
+
+	// 支付失败的回调
+	result, err = provider.VerifyCallback(provider.SignCallback("P1002", 10, false))
+	assert.NoError(t, err)
+	assert.False(t, result.Success)
+}
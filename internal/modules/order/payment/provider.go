@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"campus/internal/config"
+	"fmt"
+)
+
+// Intent 发起支付的请求参数
+type Intent struct {
+	TradeNo string  // 平台交易号
+	Amount  float64 // 支付金额
+	Subject string  // 支付标题，一般为商品名称
+}
+
+// IntentResult 支付渠道创建支付单的结果
+type IntentResult struct {
+	ProviderTradeNo string            // 渠道交易号，部分渠道在回调时才返回
+	PayParams       map[string]string // 客户端拉起支付所需的参数
+}
+
+// CallbackResult 校验通过的支付回调结果
+type CallbackResult struct {
+	TradeNo         string
+	ProviderTradeNo string
+	Amount          float64
+	Success         bool
+	FailReason      string
+}
+
+// RefundRequest 退款请求参数
+type RefundRequest struct {
+	TradeNo         string
+	ProviderTradeNo string
+	Amount          float64
+	Reason          string
+}
+
+// RefundResult 退款结果
+type RefundResult struct {
+	RefundNo string
+}
+
+// Provider 支付渠道接口，接入新的支付方式只需实现该接口
+type Provider interface {
+	// Name 渠道名称，同时用于回调地址 /api/v1/payment/callback/:provider
+	Name() string
+	// CreateIntent 在渠道侧创建支付单
+	CreateIntent(intent *Intent) (*IntentResult, error)
+	// VerifyCallback 校验回调签名并解析结果，签名错误时返回error
+	VerifyCallback(params map[string]string) (*CallbackResult, error)
+	// Refund 原路退款
+	Refund(req *RefundRequest) (*RefundResult, error)
+}
+
+// NewProvider 根据配置创建支付渠道
+func NewProvider(cfg config.PaymentConfig) (Provider, error) {
+	switch cfg.Provider {
+	case "", LocalProviderName:
+		return NewLocalProvider(cfg.LocalSecret), nil
+	default:
+		return nil, fmt.Errorf("不支持的支付渠道: %s", cfg.Provider)
+	}
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"campus/internal/models"
+)
+
+// CreateDispute 创建售后申请，同时保存关联的凭证图片
+func (r *OrderRepositoryImpl) CreateDispute(dispute *models.Dispute) error {
+	return r.db.Create(dispute).Error
+}
+
+// CreateDisputeEvidences 追加售后凭证图片
+func (r *OrderRepositoryImpl) CreateDisputeEvidences(evidences []models.DisputeEvidence) error {
+	if len(evidences) == 0 {
+		return nil
+	}
+	return r.db.Create(&evidences).Error
+}
+
+// GetActiveDispute 获取订单未处理完成的售后申请
+func (r *OrderRepositoryImpl) GetActiveDispute(orderID uint) (*models.Dispute, error) {
+	var dispute models.Dispute
+	err := r.db.Where("order_id = ? AND status <> ?", orderID, models.DisputeStatusResolved).
+		Order("id DESC").
+		First(&dispute).Error
+	return &dispute, err
+}
+
+// GetDisputesByOrderID 获取订单的全部售后申请及凭证，按创建时间倒序
+func (r *OrderRepositoryImpl) GetDisputesByOrderID(orderID uint) ([]*models.Dispute, error) {
+	var disputes []*models.Dispute
+	err := r.db.Preload("Evidences").
+		Where("order_id = ?", orderID).
+		Order("created_at DESC, id DESC").
+		Find(&disputes).Error
+	return disputes, err
+}
+
+// UpdateDispute 在售后申请处于fromStatuses之一时更新，返回是否更新成功
+func (r *OrderRepositoryImpl) UpdateDispute(id uint, fromStatuses []string, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.Dispute{}).
+		Where("id = ? AND status IN ?", id, fromStatuses).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListDisputes 管理员分页查询售后申请
+func (r *OrderRepositoryImpl) ListDisputes(status string, page, pageSize uint) ([]*models.Dispute, int64, error) {
+	var disputes []*models.Dispute
+	var total int64
+
+	query := r.db.Model(&models.Dispute{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Evidences").
+		Order("created_at DESC, id DESC").
+		Offset(int(offset)).
+		Limit(int(pageSize)).
+		Find(&disputes).Error
+	return disputes, total, err
+}
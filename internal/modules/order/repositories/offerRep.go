@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"time"
+)
+
+// CreateOffer 创建出价记录
+func (r *OrderRepositoryImpl) CreateOffer(offer *models.Offer) error {
+	return r.db.Create(offer).Error
+}
+
+// GetOfferByID 获取出价及关联商品
+func (r *OrderRepositoryImpl) GetOfferByID(id uint) (*models.Offer, error) {
+	var offer models.Offer
+	err := r.db.Preload("Product").First(&offer, id).Error
+	return &offer, err
+}
+
+// GetPendingOffer 获取买家对商品待回应的出价，同一买家对同一商品同时只有一条
+func (r *OrderRepositoryImpl) GetPendingOffer(productID, buyerID uint) (*models.Offer, error) {
+	var offer models.Offer
+	err := r.db.Where("product_id = ? AND buyer_id = ? AND status = ?", productID, buyerID, models.OfferStatusPending).
+		Order("id DESC").
+		First(&offer).Error
+	return &offer, err
+}
+
+// UpdateOffer 在出价处于from状态时更新，返回是否更新成功
+func (r *OrderRepositoryImpl) UpdateOffer(id uint, from string, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&models.Offer{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListOffers 按买家或卖家身份分页查询出价记录
+func (r *OrderRepositoryImpl) ListOffers(userID uint, role, status string, page, size uint) ([]*models.Offer, int64, error) {
+	var offers []*models.Offer
+	var total int64
+
+	column := "buyer_id"
+	if role == OrderRoleSeller {
+		column = "seller_id"
+	}
+	query := r.db.Model(&models.Offer{}).Where(column+" = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query.Preload("Product").
+		Order("created_at DESC, id DESC").
+		Offset(int(offset)).
+		Limit(int(size)).
+		Find(&offers).Error
+	return offers, total, err
+}
+
+// FindExpiredOffers 查询已过有效期仍待回应的出价，用于过期处理
+func (r *OrderRepositoryImpl) FindExpiredOffers(now time.Time, afterID uint, limit int) ([]*models.Offer, error) {
+	var offers []*models.Offer
+	err := r.db.Preload("Product").
+		Where("id > ? AND status = ? AND expires_at < ?", afterID, models.OfferStatusPending, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&offers).Error
+	return offers, err
+}
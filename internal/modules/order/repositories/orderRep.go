@@ -2,24 +2,81 @@ package repositories
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
+// 按身份查询订单时的用户角色
+const (
+	OrderRoleBuyer  = "buyer"
+	OrderRoleSeller = "seller"
+)
+
+// OrderQuery 按买家或卖家身份查询订单的条件
+type OrderQuery struct {
+	UserID    uint
+	Role      string // OrderRoleBuyer 或 OrderRoleSeller
+	Status    string
+	StartDate string
+	EndDate   string
+	Sort      string
+	Params    pagination.Params
+}
+
+// orderSorts 订单列表允许的排序方式
+var orderSorts = map[string]string{
+	"created_desc": "orders.created_at DESC",
+	"created_asc":  "orders.created_at ASC",
+	"updated_desc": "orders.updated_at DESC",
+}
+
 type OrderRepository interface {
 	Create(order *models.Order) error
 	Delete(id uint) error
-	UpdateStatus(id uint, status string, remark string) error
-	UpdateStatusWithTimes(id uint, status, remark string, payTime, deliveryTime, completeTime *time.Time) error
+	TransitionStatus(id uint, from, to, remark string, awaitPayTime, payTime, deliveryTime, completeTime *time.Time) (bool, error)
 	GetByID(id uint) (*models.Order, error)
-	GetByBuyerID(buyerID uint, page, size uint) ([]*models.Order, int64, error)
+	GetByBuyerID(buyerID uint, p pagination.Params) ([]*models.Order, pagination.Info, error)
+	ListByRole(query *OrderQuery) ([]*models.Order, pagination.Info, error)
+	CountByStatus(userID uint, role string) (map[string]int64, error)
+	// FindTimedOut 按ID顺序查询afterID之后处于status状态且timeColumn早于before的订单，用于超时处理
+	FindTimedOut(status, timeColumn string, before time.Time, afterID uint, limit int) ([]*models.Order, error)
 	// 管理员接口
-	GetOrdersForAdmin(search, status, startDate, endDate string, page, pageSize uint) ([]*models.Order, int64, error)
+	GetOrdersForAdmin(search, status, startDate, endDate string, p pagination.Params) ([]*models.Order, pagination.Info, error)
 	GetOrderDetailForAdmin(id uint) (*models.Order, error)
-	CreateOrderLog(orderID uint, action, operator, remark string) error
+	// IterateOrdersForAdmin 按ID倒序分批读取符合条件的全部订单，用于导出
+	IterateOrdersForAdmin(search, status, startDate, endDate string, batchSize int, fn func(orders []*models.Order) error) error
+	CreateOrderLog(log *models.OrderLog) error
 	GetOrderLogs(orderID uint) ([]models.OrderLog, error)
 	// 获取商品信息
 	GetProductByID(productID uint) (*models.Product, error)
+	// LockProduct 加行锁读取商品，需在事务中调用
+	LockProduct(productID uint) (*models.Product, error)
+	TransitionProductStatus(productID uint, from, to string) (bool, error)
+	// 支付单
+	CreatePayment(payment *models.Payment) error
+	GetPaymentByTradeNo(tradeNo string) (*models.Payment, error)
+	GetPaymentsByOrderID(orderID uint) ([]*models.Payment, error)
+	TransitionPaymentStatus(id uint, from, to string, fields map[string]interface{}) (bool, error)
+	// 售后
+	CreateDispute(dispute *models.Dispute) error
+	CreateDisputeEvidences(evidences []models.DisputeEvidence) error
+	GetActiveDispute(orderID uint) (*models.Dispute, error)
+	GetDisputesByOrderID(orderID uint) ([]*models.Dispute, error)
+	UpdateDispute(id uint, fromStatuses []string, updates map[string]interface{}) (bool, error)
+	ListDisputes(status string, page, pageSize uint) ([]*models.Dispute, int64, error)
+	// 议价
+	CreateOffer(offer *models.Offer) error
+	GetOfferByID(id uint) (*models.Offer, error)
+	// GetPendingOffer 获取买家对商品待回应的出价（含卖家还价）
+	GetPendingOffer(productID, buyerID uint) (*models.Offer, error)
+	UpdateOffer(id uint, from string, updates map[string]interface{}) (bool, error)
+	ListOffers(userID uint, role, status string, page, size uint) ([]*models.Offer, int64, error)
+	// FindExpiredOffers 按ID顺序查询afterID之后已过有效期仍待回应的出价
+	FindExpiredOffers(now time.Time, afterID uint, limit int) ([]*models.Offer, error)
+	// Transaction 在同一事务中执行fn，fn内通过txRepo进行的操作共享该事务
+	Transaction(fn func(txRepo OrderRepository) error) error
 }
 
 type OrderRepositoryImpl struct {
@@ -39,51 +96,57 @@ func (r *OrderRepositoryImpl) GetProductByID(productID uint) (*models.Product, e
 	return &product, err
 }
 
-func (r *OrderRepositoryImpl) Create(order *models.Order) error {
-	err := r.db.Create(order).Error
-	if err != nil {
-		return err
+// LockProduct 以 SELECT ... FOR UPDATE 读取商品，事务结束前其他下单请求会在此等待
+func (r *OrderRepositoryImpl) LockProduct(productID uint) (*models.Product, error) {
+	var product models.Product
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error
+	return &product, err
+}
+
+// TransitionProductStatus 在商品仍处于from状态时将其变更为to状态，返回是否更新成功
+func (r *OrderRepositoryImpl) TransitionProductStatus(productID uint, from, to string) (bool, error) {
+	updates := map[string]interface{}{
+		"status": to,
+	}
+	if to == models.ProductStatusSold {
+		updates["sold_at"] = time.Now()
 	}
 
-	// 创建订单日志
-	return r.CreateOrderLog(order.ID, "创建订单", "用户", "")
+	result := r.db.Model(&models.Product{}).Where("id = ? AND status = ?", productID, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
-func (r *OrderRepositoryImpl) Delete(id uint) error {
-	return r.db.Delete(&models.Order{}, id).Error
+// Transaction 在同一事务中执行fn
+func (r *OrderRepositoryImpl) Transaction(fn func(txRepo OrderRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&OrderRepositoryImpl{db: tx})
+	})
 }
 
-func (r *OrderRepositoryImpl) UpdateStatus(id uint, status string, remark string) error {
-	err := r.db.Model(&models.Order{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"status": status,
-		"remark": remark,
-	}).Error
-
-	if err != nil {
-		return err
-	}
-
-	// 创建订单状态变更日志
-	var operator string
-	if status == "卖家已同意" {
-		operator = "卖家"
-	} else if status == "卖家已拒绝" {
-		operator = "卖家"
-	} else {
-		operator = "管理员"
-	}
+func (r *OrderRepositoryImpl) Create(order *models.Order) error {
+	return r.db.Create(order).Error
+}
 
-	return r.CreateOrderLog(id, "更新订单状态为"+status, operator, remark)
+func (r *OrderRepositoryImpl) Delete(id uint) error {
+	return r.db.Delete(&models.Order{}, id).Error
 }
 
-func (r *OrderRepositoryImpl) UpdateStatusWithTimes(id uint, status, remark string, payTime, deliveryTime, completeTime *time.Time) error {
+// TransitionStatus 在订单仍处于from状态时将其变更为to状态，返回是否更新成功
+func (r *OrderRepositoryImpl) TransitionStatus(id uint, from, to, remark string, awaitPayTime, payTime, deliveryTime, completeTime *time.Time) (bool, error) {
 	// 构建更新字段
 	updates := map[string]interface{}{
-		"status": status,
+		"status": to,
 		"remark": remark,
 	}
 
 	// 只有当提供了时间才更新相应字段
+	if awaitPayTime != nil {
+		updates["await_pay_time"] = awaitPayTime
+	}
+
 	if payTime != nil {
 		updates["pay_time"] = payTime
 	}
@@ -96,23 +159,12 @@ func (r *OrderRepositoryImpl) UpdateStatusWithTimes(id uint, status, remark stri
 		updates["complete_time"] = completeTime
 	}
 
-	// 更新订单
-	err := r.db.Model(&models.Order{}).Where("id = ?", id).Updates(updates).Error
-	if err != nil {
-		return err
-	}
-
-	// 创建订单状态变更日志
-	var operator string
-	if status == "卖家已同意" {
-		operator = "卖家"
-	} else if status == "卖家已拒绝" {
-		operator = "卖家"
-	} else {
-		operator = "管理员"
+	// 以当前状态作为更新条件，避免并发请求覆盖彼此的状态变更
+	result := r.db.Model(&models.Order{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
 	}
-
-	return r.CreateOrderLog(id, "更新订单状态为"+status, operator, remark)
+	return result.RowsAffected > 0, nil
 }
 
 func (r *OrderRepositoryImpl) GetByID(id uint) (*models.Order, error) {
@@ -121,29 +173,131 @@ func (r *OrderRepositoryImpl) GetByID(id uint) (*models.Order, error) {
 	return &order, err
 }
 
-func (r *OrderRepositoryImpl) GetByBuyerID(buyerID uint, page, size uint) ([]*models.Order, int64, error) {
+func (r *OrderRepositoryImpl) GetByBuyerID(buyerID uint, p pagination.Params) ([]*models.Order, pagination.Info, error) {
+	return paginateOrders(r.db.Model(&models.Order{}).Where("orders.buyer_id = ?", buyerID), p)
+}
+
+// paginateOrders 按创建时间倒序分页查询订单，page/size模式下同时统计总数；preloads为需要预加载的关联
+func paginateOrders(query *gorm.DB, p pagination.Params, preloads ...string) ([]*models.Order, pagination.Info, error) {
 	var orders []*models.Order
 	var total int64
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
+	}
 
-	// 计算总记录数
-	err := r.db.Model(&models.Order{}).Where("buyer_id = ?", buyerID).Count(&total).Error
-	if err != nil {
-		return nil, 0, err
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if err := pagination.Apply(query, p, "orders").Find(&orders).Error; err != nil {
+		return nil, pagination.Info{}, err
 	}
 
-	// 分页查询
-	offset := (page - 1) * size
-	err = r.db.Where("buyer_id = ?", buyerID).Offset(int(offset)).Limit(int(size)).Find(&orders).Error
-	return orders, total, err
+	orders, info := pagination.Trim(orders, p, total, func(order *models.Order) pagination.Cursor {
+		return pagination.ModelCursor(order.Model)
+	})
+	return orders, info, nil
 }
 
-// GetOrdersForAdmin 管理员获取订单列表
-func (r *OrderRepositoryImpl) GetOrdersForAdmin(search, status, startDate, endDate string, page, pageSize uint) ([]*models.Order, int64, error) {
+// roleColumn 返回角色对应的订单用户字段
+func roleColumn(role string) string {
+	if role == OrderRoleSeller {
+		return "orders.seller_id"
+	}
+	return "orders.buyer_id"
+}
+
+// ListByRole 按买家或卖家身份分页查询订单
+func (r *OrderRepositoryImpl) ListByRole(q *OrderQuery) ([]*models.Order, pagination.Info, error) {
+	query := r.db.Model(&models.Order{}).Where(roleColumn(q.Role)+" = ?", q.UserID)
+
+	if q.Status != "" {
+		query = query.Where("orders.status = ?", q.Status)
+	}
+
+	// 添加日期筛选，格式错误的日期忽略
+	if q.StartDate != "" {
+		if startTime, err := time.Parse("2006-01-02", q.StartDate); err == nil {
+			query = query.Where("orders.created_at >= ?", startTime)
+		}
+	}
+	if q.EndDate != "" {
+		if endTime, err := time.Parse("2006-01-02", q.EndDate); err == nil {
+			// 增加一天，使得结束日期是包含当天的
+			query = query.Where("orders.created_at < ?", endTime.AddDate(0, 0, 1))
+		}
+	}
+
+	// 默认按创建时间倒序，支持游标分页
+	order, ok := orderSorts[q.Sort]
+	if !ok || q.Sort == "created_desc" {
+		return paginateOrders(query, q.Params)
+	}
+
+	// 其他排序方式只支持page/size分页
 	var orders []*models.Order
 	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, pagination.Info{}, err
+	}
+	err := query.Order(order).
+		Order("orders.id DESC").
+		Offset(q.Params.Offset()).
+		Limit(q.Params.Size).
+		Find(&orders).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	return orders, pagination.Info{
+		Total:   total,
+		Page:    q.Params.Page,
+		Size:    q.Params.Size,
+		HasMore: int64(q.Params.Offset()+len(orders)) < total,
+	}, nil
+}
+
+// CountByStatus 统计用户作为买家或卖家时各状态的订单数量
+func (r *OrderRepositoryImpl) CountByStatus(userID uint, role string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+
+	err := r.db.Model(&models.Order{}).
+		Select("orders.status AS status, COUNT(*) AS count").
+		Where(roleColumn(role)+" = ?", userID).
+		Group("orders.status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// FindTimedOut 查询超时订单，timeColumn只能由调用方传入固定的字段名或表达式
+func (r *OrderRepositoryImpl) FindTimedOut(status, timeColumn string, before time.Time, afterID uint, limit int) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := r.db.Where("id > ? AND status = ? AND "+timeColumn+" < ?", afterID, status, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
 
-	query := r.db.Model(&models.Order{})
+// GetOrdersForAdmin 管理员获取订单列表
+func (r *OrderRepositoryImpl) GetOrdersForAdmin(search, status, startDate, endDate string, p pagination.Params) ([]*models.Order, pagination.Info, error) {
+	query := applyAdminOrderFilters(r.db.Model(&models.Order{}), search, status, startDate, endDate)
+	return paginateOrders(query, p, "Product", "Product.ProductImages", "Buyer", "Seller")
+}
 
+// applyAdminOrderFilters 添加管理员订单列表的搜索、状态和日期筛选条件
+func applyAdminOrderFilters(query *gorm.DB, search, status, startDate, endDate string) *gorm.DB {
 	// 添加搜索条件
 	if search != "" {
 		// 通过关联查询，搜索订单号、商品标题、买家名称、卖家名称
@@ -176,24 +330,42 @@ func (r *OrderRepositoryImpl) GetOrdersForAdmin(search, status, startDate, endDa
 		}
 	}
 
-	// 计算总记录数
-	err := query.Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
+	return query
+}
 
-	// 分页查询 - 添加预加载
-	offset := (page - 1) * pageSize
-	err = query.Preload("Product").
-		Preload("Product.ProductImages").
-		Preload("Buyer").
-		Preload("Seller").
-		Offset(int(offset)).
-		Limit(int(pageSize)).
-		Order("orders.created_at DESC").
-		Find(&orders).Error
+// IterateOrdersForAdmin 按ID倒序分批读取订单，使用键集分页避免大偏移量的深分页
+func (r *OrderRepositoryImpl) IterateOrdersForAdmin(search, status, startDate, endDate string, batchSize int, fn func(orders []*models.Order) error) error {
+	var lastID uint
+	for {
+		var orders []*models.Order
 
-	return orders, total, err
+		query := applyAdminOrderFilters(r.db.Model(&models.Order{}), search, status, startDate, endDate)
+		if lastID > 0 {
+			query = query.Where("orders.id < ?", lastID)
+		}
+
+		err := query.Preload("Product").
+			Preload("Buyer").
+			Preload("Seller").
+			Order("orders.id DESC").
+			Limit(batchSize).
+			Find(&orders).Error
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		if err := fn(orders); err != nil {
+			return err
+		}
+
+		if len(orders) < batchSize {
+			return nil
+		}
+		lastID = orders[len(orders)-1].ID
+	}
 }
 
 // GetOrderDetailForAdmin 管理员获取订单详情
@@ -211,15 +383,8 @@ func (r *OrderRepositoryImpl) GetOrderDetailForAdmin(id uint) (*models.Order, er
 }
 
 // CreateOrderLog 创建订单日志
-func (r *OrderRepositoryImpl) CreateOrderLog(orderID uint, action, operator, remark string) error {
-	log := models.OrderLog{
-		OrderID:  orderID,
-		Action:   action,
-		Operator: operator,
-		Remark:   remark,
-	}
-
-	return r.db.Create(&log).Error
+func (r *OrderRepositoryImpl) CreateOrderLog(log *models.OrderLog) error {
+	return r.db.Create(log).Error
 }
 
 // GetOrderLogs 获取订单日志
@@ -227,7 +392,7 @@ func (r *OrderRepositoryImpl) GetOrderLogs(orderID uint) ([]models.OrderLog, err
 	var logs []models.OrderLog
 
 	err := r.db.Where("order_id = ?", orderID).
-		Order("created_at ASC").
+		Order("created_at ASC, id ASC").
 		Find(&logs).Error
 
 	return logs, err
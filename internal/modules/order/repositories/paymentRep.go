@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"campus/internal/models"
+)
+
+// CreatePayment 创建支付单
+func (r *OrderRepositoryImpl) CreatePayment(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+// GetPaymentByTradeNo 根据平台交易号获取支付单
+func (r *OrderRepositoryImpl) GetPaymentByTradeNo(tradeNo string) (*models.Payment, error) {
+	var payment models.Payment
+	err := r.db.Where("trade_no = ?", tradeNo).First(&payment).Error
+	return &payment, err
+}
+
+// GetPaymentsByOrderID 获取订单的全部支付单，按创建时间倒序
+func (r *OrderRepositoryImpl) GetPaymentsByOrderID(orderID uint) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	err := r.db.Where("order_id = ?", orderID).
+		Order("created_at DESC, id DESC").
+		Find(&payments).Error
+	return payments, err
+}
+
+// TransitionPaymentStatus 在支付单仍处于from状态时更新状态及附加字段，返回是否更新成功
+func (r *OrderRepositoryImpl) TransitionPaymentStatus(id uint, from, to string, fields map[string]interface{}) (bool, error) {
+	updates := map[string]interface{}{
+		"status": to,
+	}
+	for k, v := range fields {
+		updates[k] = v
+	}
+
+	// 以当前状态作为更新条件，重复回调或并发退款只会生效一次
+	result := r.db.Model(&models.Payment{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -3,26 +3,62 @@ package order
 import (
 	"campus/internal/bootstrap"
 	"campus/internal/middleware"
+	messageRep "campus/internal/modules/message/repositories"
+	messageSrv "campus/internal/modules/message/services"
 	"campus/internal/modules/order/controllers"
+	"campus/internal/modules/order/payment"
 	"campus/internal/modules/order/repositories"
 	"campus/internal/modules/order/services"
+	"campus/internal/utils/logger"
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes 注册order模块的所有路由
 func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
-	orderController := controllers.NewOrderController(services.NewOrderService(repositories.NewOrderRepository(bootstrap.GetDB())))
+	orderRep := repositories.NewOrderRepository(bootstrap.GetDB())
+
+	provider, err := payment.NewProvider(bootstrap.GetConfig().Payment)
+	if err != nil {
+		logger.Fatalf("支付渠道初始化失败: %v", err)
+	}
+
+	// 订单状态变更以系统消息通知买卖双方，议价事件以商品消息推送给对方
+	messageService := messageSrv.NewMessageService(messageRep.NewMessageRepository(bootstrap.GetDB()), bootstrap.GetMessagePublisher(), bootstrap.GetWebSocketManager(), bootstrap.GetConfig().Message.WithdrawWindow)
+	paymentService := services.NewPaymentService(orderRep, provider, messageService)
+	paymentController := controllers.NewPaymentController(paymentService)
+	orderController := controllers.NewOrderController(services.NewOrderService(orderRep, paymentService, messageService))
+	disputeController := controllers.NewDisputeController(services.NewDisputeService(orderRep, paymentService, messageService))
+
+	orderConfig := bootstrap.GetConfig().Order
+	offerService := services.NewOfferService(orderRep, messageService, orderConfig.OfferExpireAfter)
+	offerController := controllers.NewOfferController(offerService)
+
+	// 注册订单超时和出价过期处理任务
+	timeoutJob := services.NewOrderTimeoutJob(orderRep, messageService, orderConfig)
+	bootstrap.GetScheduler().Register(services.OrderTimeoutJobName, orderConfig.JobInterval, timeoutJob.Run)
+	bootstrap.GetScheduler().Register(services.OfferExpiryJobName, orderConfig.JobInterval, offerService.ExpireOffers)
 
 	// 订单路由 - 需要认证
 	orderGroup := api.Group("/order")
 	orderGroup.Use(middleware.JWTAuth())
 	registerOrderRoutes(orderGroup, orderController)
+	registerPaymentRoutes(orderGroup, paymentController, bootstrap.GetConfig().Payment.EnableSimulate)
+	registerDisputeRoutes(orderGroup, disputeController)
+
+	// 议价路由 - 需要认证
+	offerGroup := api.Group("/offers")
+	offerGroup.Use(middleware.JWTAuth())
+	registerOfferRoutes(offerGroup, offerController)
+
+	// 支付回调 - 由支付渠道调用，通过签名校验
+	api.POST("/payment/callback/:provider", paymentController.PaymentCallback)
 	
 	// 管理员订单路由 - 需要管理员权限
 	adminOrderGroup := api.Group("/admin/orders")
 	adminOrderGroup.Use(middleware.JWTAuth())
 	adminOrderGroup.Use(middleware.AuthorizeByRole("admin"))
 	registerAdminOrderRoutes(adminOrderGroup, orderController)
+	registerAdminDisputeRoutes(adminOrderGroup, disputeController)
 }
 
 // registerOrderRoutes 注册订单相关路由
@@ -32,6 +68,36 @@ func registerOrderRoutes(router *gin.RouterGroup, controller *controllers.OrderC
 	router.PUT("/:id/status", controller.UpdateOrderStatus)
 	router.GET("/:id", controller.GetOrderByID)
 	router.GET("/user", controller.GetUserOrders)
+	router.GET("/sold", controller.GetSoldOrders)
+	router.GET("/bought", controller.GetBoughtOrders)
+}
+
+// registerPaymentRoutes 注册订单支付相关路由
+// 模拟支付允许买家自行完成付款，只在显式开启 payment.enable_simulate 时注册
+func registerPaymentRoutes(router *gin.RouterGroup, controller *controllers.PaymentController, enableSimulate bool) {
+	router.POST("/:id/payments", controller.CreatePaymentIntent)
+	router.GET("/:id/payments", controller.GetOrderPayments)
+	if enableSimulate {
+		router.POST("/:id/payments/:tradeNo/simulate", controller.SimulatePay)
+	}
+}
+
+// registerDisputeRoutes 注册订单售后相关路由
+func registerDisputeRoutes(router *gin.RouterGroup, controller *controllers.DisputeController) {
+	router.POST("/:id/disputes", controller.OpenDispute)
+	router.PUT("/:id/disputes", controller.RespondDispute)
+	router.GET("/:id/disputes", controller.GetOrderDisputes)
+}
+
+// registerOfferRoutes 注册议价相关路由
+func registerOfferRoutes(router *gin.RouterGroup, controller *controllers.OfferController) {
+	router.POST("", controller.CreateOffer)
+	router.GET("", controller.ListOffers)
+	router.GET("/:id", controller.GetOffer)
+	router.POST("/:id/counter", controller.CounterOffer)
+	router.POST("/:id/accept", controller.AcceptOffer)
+	router.POST("/:id/reject", controller.RejectOffer)
+	router.POST("/:id/withdraw", controller.WithdrawOffer)
 }
 
 // registerAdminOrderRoutes 注册管理员订单相关路由
@@ -48,3 +114,15 @@ func registerAdminOrderRoutes(router *gin.RouterGroup, controller *controllers.O
 	// 导出订单数据
 	router.GET("/export", middleware.AuthorizePermission("/api/v1/admin/orders/export", "GET"), controller.ExportOrders)
 }
+
+// registerAdminDisputeRoutes 注册管理员售后相关路由
+func registerAdminDisputeRoutes(router *gin.RouterGroup, controller *controllers.DisputeController) {
+	// 获取售后列表
+	router.GET("/disputes", middleware.AuthorizePermission("/api/v1/admin/orders/disputes", "GET"), controller.AdminListDisputes)
+
+	// 获取订单售后记录
+	router.GET("/:id/disputes", middleware.AuthorizePermission("/api/v1/admin/orders/:id/disputes", "GET"), controller.AdminGetOrderDisputes)
+
+	// 处理售后
+	router.POST("/:id/disputes/resolve", middleware.AuthorizePermission("/api/v1/admin/orders/:id/disputes/resolve", "POST"), controller.ResolveDispute)
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type DisputeService interface {
+	OpenDispute(orderID, buyerID uint, req *api.OpenDisputeRequest) (*api.DisputeResponse, error)
+	RespondDispute(orderID, sellerID uint, req *api.RespondDisputeRequest) (*api.DisputeResponse, error)
+	GetOrderDisputes(orderID, userID uint) ([]*api.DisputeResponse, error)
+
+	// 管理员接口
+	AdminListDisputes(req *api.AdminDisputeListRequest) (*api.DisputeListResponse, error)
+	AdminGetOrderDisputes(orderID uint) ([]*api.DisputeResponse, error)
+	ResolveDispute(orderID, adminID uint, req *api.ResolveDisputeRequest) (*api.DisputeResponse, error)
+}
+
+type DisputeServiceImpl struct {
+	repository repositories.OrderRepository
+	payments   PaymentService
+	notifier   OrderNotifier
+}
+
+func NewDisputeService(orderRep repositories.OrderRepository, payments PaymentService, notifier OrderNotifier) DisputeService {
+	return &DisputeServiceImpl{
+		repository: orderRep,
+		payments:   payments,
+		notifier:   notifier,
+	}
+}
+
+// disputeResolutionLabels 售后处理结果的中文描述，用于订单日志
+var disputeResolutionLabels = map[string]string{
+	models.DisputeResolutionRefund:  "全额退款",
+	models.DisputeResolutionPartial: "部分退款",
+	models.DisputeResolutionReject:  "驳回",
+}
+
+// OpenDispute 买家对待发货、待收货或已完成的订单申请售后，订单变更为售后中
+func (s *DisputeServiceImpl) OpenDispute(orderID, buyerID uint, req *api.OpenDisputeRequest) (*api.DisputeResponse, error) {
+	order, err := s.repository.GetByID(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+	if order.BuyerID != buyerID {
+		return nil, errors.NewForbiddenError("只有买家可以申请售后", nil)
+	}
+
+	dispute := &models.Dispute{
+		OrderID:     order.ID,
+		BuyerID:     order.BuyerID,
+		SellerID:    order.SellerID,
+		Reason:      req.Reason,
+		OrderStatus: order.Status,
+		Status:      models.DisputeStatusOpen,
+		Evidences:   newDisputeEvidences(0, buyerID, req.Evidences), // 售后ID在创建时由关联写入
+	}
+
+	operator := OrderOperator{UserID: buyerID, Actor: ActorBuyer}
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		// 状态机校验订单当前状态能否发起售后，同一订单同时只能有一个售后申请
+		if err := transitionOrderTx(repo, order, models.OrderStatusDisputed, operator, req.Reason); err != nil {
+			return err
+		}
+		if err := repo.CreateDispute(dispute); err != nil {
+			return errors.NewInternalServerError("创建售后申请失败", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyOrderStatus(s.notifier, order)
+
+	return api.ConvertToDisputeResponse(dispute), nil
+}
+
+// RespondDispute 卖家回应售后申请，补充说明和凭证后交由平台处理
+func (s *DisputeServiceImpl) RespondDispute(orderID, sellerID uint, req *api.RespondDisputeRequest) (*api.DisputeResponse, error) {
+	dispute, err := s.getActiveDispute(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.SellerID != sellerID {
+		return nil, errors.NewForbiddenError("只有卖家可以回应售后", nil)
+	}
+	if dispute.Status != models.DisputeStatusOpen {
+		return nil, errors.NewConflictError("已回应过该售后申请", nil)
+	}
+
+	now := time.Now()
+	operator := OrderOperator{UserID: sellerID, Actor: ActorSeller}
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		updated, err := repo.UpdateDispute(dispute.ID, []string{models.DisputeStatusOpen}, map[string]interface{}{
+			"status":          models.DisputeStatusResponded,
+			"seller_response": req.Response,
+			"responded_at":    now,
+		})
+		if err != nil {
+			return errors.NewInternalServerError("更新售后申请失败", err)
+		}
+		if !updated {
+			return errors.NewConflictError("售后申请状态已变更，请刷新后重试", nil)
+		}
+		if err := repo.CreateDisputeEvidences(newDisputeEvidences(dispute.ID, sellerID, req.Evidences)); err != nil {
+			return errors.NewInternalServerError("保存售后凭证失败", err)
+		}
+		return appendOrderLog(repo, newOrderLog(orderID, OrderActionRespond, operator,
+			models.OrderStatusDisputed, models.OrderStatusDisputed, req.Response))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.latestDispute(orderID)
+}
+
+// GetOrderDisputes 获取订单的售后记录，买卖双方可查看
+func (s *DisputeServiceImpl) GetOrderDisputes(orderID, userID uint) ([]*api.DisputeResponse, error) {
+	order, err := s.repository.GetByID(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+	if err := authorizeOrderView(order, userID); err != nil {
+		return nil, err
+	}
+	return s.AdminGetOrderDisputes(orderID)
+}
+
+// AdminListDisputes 管理员分页获取售后申请
+func (s *DisputeServiceImpl) AdminListDisputes(req *api.AdminDisputeListRequest) (*api.DisputeListResponse, error) {
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 10
+	}
+
+	disputes, total, err := s.repository.ListDisputes(req.Status, req.Page, req.PageSize)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取售后列表失败", err)
+	}
+
+	resp := &api.DisputeListResponse{
+		Total: total,
+		List:  make([]*api.DisputeResponse, 0, len(disputes)),
+	}
+	for _, d := range disputes {
+		resp.List = append(resp.List, api.ConvertToDisputeResponse(d))
+	}
+	return resp, nil
+}
+
+// AdminGetOrderDisputes 管理员获取订单的售后记录
+func (s *DisputeServiceImpl) AdminGetOrderDisputes(orderID uint) ([]*api.DisputeResponse, error) {
+	disputes, err := s.repository.GetDisputesByOrderID(orderID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取售后记录失败", err)
+	}
+
+	list := make([]*api.DisputeResponse, 0, len(disputes))
+	for _, d := range disputes {
+		list = append(list, api.ConvertToDisputeResponse(d))
+	}
+	return list, nil
+}
+
+// ResolveDispute 管理员处理售后：全额退款后订单变更为已退款；部分退款后订单完成；
+// 驳回后订单恢复到申请售后前的状态。退款期间售后处于退款中，退款失败时售后恢复待处理
+func (s *DisputeServiceImpl) ResolveDispute(orderID, adminID uint, req *api.ResolveDisputeRequest) (*api.DisputeResponse, error) {
+	dispute, err := s.getActiveDispute(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status == models.DisputeStatusRefunding {
+		return nil, errors.NewConflictError("售后正在退款中，请稍后刷新", nil)
+	}
+
+	order, err := s.repository.GetByID(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+
+	var to string
+	var refundAmount float64
+	switch req.Resolution {
+	case models.DisputeResolutionRefund:
+		to = models.OrderStatusRefunded
+		if refundAmount, err = s.payments.RefundableAmount(orderID); err != nil {
+			return nil, err
+		}
+	case models.DisputeResolutionPartial:
+		to = models.OrderStatusCompleted
+		refundable, err := s.payments.RefundableAmount(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if req.RefundAmount <= 0 || req.RefundAmount > refundable {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("退款金额须大于0且不超过可退金额%.2f", refundable), nil)
+		}
+		refundAmount = req.RefundAmount
+	default:
+		to = dispute.OrderStatus
+	}
+
+	remark := disputeResolutionLabels[req.Resolution]
+	if req.Remark != "" {
+		remark += "：" + req.Remark
+	}
+	operator := OrderOperator{UserID: adminID, Actor: ActorAdmin}
+	resolution := map[string]interface{}{
+		"resolution":        req.Resolution,
+		"refund_amount":     refundAmount,
+		"resolution_remark": req.Remark,
+		"resolved_by":       adminID,
+	}
+
+	if req.Resolution == models.DisputeResolutionReject {
+		err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+			if err := resolveDisputeTx(repo, dispute.ID, []string{models.DisputeStatusOpen, models.DisputeStatusResponded}, resolution); err != nil {
+				return err
+			}
+			return restoreOrderStatusTx(repo, order, to, operator, remark)
+		})
+		if err != nil {
+			return nil, err
+		}
+		notifyOrderStatus(s.notifier, order)
+		return s.latestDispute(orderID)
+	}
+
+	// 先将售后标记为退款中，防止重复处理导致重复退款
+	refunding := map[string]interface{}{"status": models.DisputeStatusRefunding}
+	for k, v := range resolution {
+		refunding[k] = v
+	}
+	updated, err := s.repository.UpdateDispute(dispute.ID,
+		[]string{models.DisputeStatusOpen, models.DisputeStatusResponded}, refunding)
+	if err != nil {
+		return nil, errors.NewInternalServerError("更新售后申请失败", err)
+	}
+	if !updated {
+		return nil, errors.NewConflictError("售后申请已被处理", nil)
+	}
+
+	if req.Resolution == models.DisputeResolutionRefund {
+		err = s.payments.RefundOrder(order, "售后全额退款")
+	} else {
+		err = s.payments.PartialRefundOrder(order, refundAmount, "售后部分退款")
+	}
+	if err != nil {
+		// 退款失败时售后恢复为处理前的状态，订单保持售后中，管理员可重新处理
+		if _, rollbackErr := s.repository.UpdateDispute(dispute.ID, []string{models.DisputeStatusRefunding}, map[string]interface{}{
+			"status":            dispute.Status,
+			"resolution":        "",
+			"refund_amount":     0,
+			"resolution_remark": "",
+			"resolved_by":       0,
+		}); rollbackErr != nil {
+			logger.Error("恢复售后申请状态失败", zap.Uint("disputeID", dispute.ID), zap.Error(rollbackErr))
+		}
+		return nil, err
+	}
+
+	// 退款完成后售后处理完毕，订单变更为最终状态
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		if err := resolveDisputeTx(repo, dispute.ID, []string{models.DisputeStatusRefunding}, nil); err != nil {
+			return err
+		}
+		return transitionOrderTx(repo, order, to, operator, remark)
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyOrderStatus(s.notifier, order)
+
+	return s.latestDispute(orderID)
+}
+
+// resolveDisputeTx 在事务中将处于fromStatuses之一的售后申请标记为已处理
+func resolveDisputeTx(repo repositories.OrderRepository, disputeID uint, fromStatuses []string, fields map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"status":      models.DisputeStatusResolved,
+		"resolved_at": time.Now(),
+	}
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updated, err := repo.UpdateDispute(disputeID, fromStatuses, updates)
+	if err != nil {
+		return errors.NewInternalServerError("更新售后申请失败", err)
+	}
+	if !updated {
+		return errors.NewConflictError("售后申请已被处理", nil)
+	}
+	return nil
+}
+
+// getActiveDispute 获取订单进行中的售后申请
+func (s *DisputeServiceImpl) getActiveDispute(orderID uint) (*models.Dispute, error) {
+	dispute, err := s.repository.GetActiveDispute(orderID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("售后申请", err)
+		}
+		return nil, errors.NewInternalServerError("查询售后申请失败", err)
+	}
+	return dispute, nil
+}
+
+// latestDispute 获取订单最新的售后申请（含凭证）
+func (s *DisputeServiceImpl) latestDispute(orderID uint) (*api.DisputeResponse, error) {
+	disputes, err := s.repository.GetDisputesByOrderID(orderID)
+	if err != nil || len(disputes) == 0 {
+		return nil, errors.NewInternalServerError("获取售后申请失败", err)
+	}
+	return api.ConvertToDisputeResponse(disputes[0]), nil
+}
+
+func newDisputeEvidences(disputeID, userID uint, urls []string) []models.DisputeEvidence {
+	evidences := make([]models.DisputeEvidence, 0, len(urls))
+	for _, url := range urls {
+		evidences = append(evidences, models.DisputeEvidence{DisputeID: disputeID, UserID: userID, ImageURL: url})
+	}
+	return evidences
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/payment"
+	"campus/internal/utils/errors"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testAdminID uint = 99
+
+// failingRefundProvider 退款总是失败的支付渠道
+type failingRefundProvider struct {
+	*payment.LocalProvider
+}
+
+func (p *failingRefundProvider) Refund(req *payment.RefundRequest) (*payment.RefundResult, error) {
+	return nil, stderrors.New("渠道退款失败")
+}
+
+// newTestDispute 创建已付款、已发货并由买家发起售后的订单
+func newTestDispute(t *testing.T, provider payment.Provider) (DisputeService, *gorm.DB, *models.Order) {
+	repo, db := newTestOrderRepository(t)
+	service := NewDisputeService(repo, NewPaymentService(repo, provider, nil), nil)
+
+	paidAt := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	deliveredAt := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+	order := createTestOrder(t, db, models.Order{
+		Status:       models.OrderStatusAwaitReceipt,
+		PayTime:      &paidAt,
+		DeliveryTime: &deliveredAt,
+	})
+	require.NoError(t, db.Create(&models.Payment{
+		OrderID:  order.ID,
+		UserID:   testBuyerID,
+		Provider: payment.LocalProviderName,
+		TradeNo:  "T0001",
+		Amount:   100,
+		Status:   models.PaymentStatusPaid,
+		PaidAt:   &paidAt,
+	}).Error)
+
+	_, err := service.OpenDispute(order.ID, testBuyerID, &api.OpenDisputeRequest{Reason: "商品与描述不符"})
+	require.NoError(t, err)
+	require.Equal(t, models.OrderStatusDisputed, reloadOrder(t, db, order.ID).Status)
+	return service, db, order
+}
+
+func loadOrderPayment(t *testing.T, db *gorm.DB, orderID uint) *models.Payment {
+	var record models.Payment
+	require.NoError(t, db.Where("order_id = ?", orderID).First(&record).Error)
+	return &record
+}
+
+func loadProduct(t *testing.T, db *gorm.DB, id uint) *models.Product {
+	var product models.Product
+	require.NoError(t, db.First(&product, id).Error)
+	return &product
+}
+
+func TestResolveDisputeRefund(t *testing.T) {
+	service, db, order := newTestDispute(t, payment.NewLocalProvider("test_payment_secret"))
+
+	resp, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{Resolution: models.DisputeResolutionRefund})
+	require.NoError(t, err)
+	assert.Equal(t, models.DisputeStatusResolved, resp.Status)
+	assert.Equal(t, 100.0, resp.RefundAmount)
+
+	assert.Equal(t, models.OrderStatusRefunded, reloadOrder(t, db, order.ID).Status)
+	assert.Equal(t, models.PaymentStatusRefunded, loadOrderPayment(t, db, order.ID).Status)
+	assert.Equal(t, models.ProductStatusOnSale, loadProduct(t, db, order.ProductID).Status)
+}
+
+func TestResolveDisputePartialRefund(t *testing.T) {
+	service, db, order := newTestDispute(t, payment.NewLocalProvider("test_payment_secret"))
+
+	_, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{
+		Resolution: models.DisputeResolutionPartial, RefundAmount: 150,
+	})
+	assert.True(t, errors.IsBadRequest(err))
+
+	resp, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{
+		Resolution: models.DisputeResolutionPartial, RefundAmount: 30,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DisputeStatusResolved, resp.Status)
+
+	assert.Equal(t, models.OrderStatusCompleted, reloadOrder(t, db, order.ID).Status)
+	record := loadOrderPayment(t, db, order.ID)
+	assert.Equal(t, models.PaymentStatusPartRefunded, record.Status)
+	assert.Equal(t, 30.0, record.RefundAmount)
+}
+
+func TestResolveDisputeRefundFailureKeepsDisputeOpen(t *testing.T) {
+	provider := &failingRefundProvider{payment.NewLocalProvider("test_payment_secret")}
+	service, db, order := newTestDispute(t, provider)
+
+	_, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{Resolution: models.DisputeResolutionRefund})
+	require.Error(t, err)
+
+	var dispute models.Dispute
+	require.NoError(t, db.Where("order_id = ?", order.ID).First(&dispute).Error)
+	assert.Equal(t, models.DisputeStatusOpen, dispute.Status)
+	assert.Empty(t, dispute.Resolution)
+	assert.Nil(t, dispute.ResolvedAt)
+	assert.Equal(t, models.OrderStatusDisputed, reloadOrder(t, db, order.ID).Status)
+	assert.Equal(t, models.PaymentStatusPaid, loadOrderPayment(t, db, order.ID).Status)
+
+	// 驳回仍然可以处理
+	_, err = service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{Resolution: models.DisputeResolutionReject})
+	require.NoError(t, err)
+}
+
+func TestResolveDisputeRejectRestoresOrder(t *testing.T) {
+	service, db, order := newTestDispute(t, payment.NewLocalProvider("test_payment_secret"))
+
+	resp, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{
+		Resolution: models.DisputeResolutionReject, Remark: "凭证不足",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DisputeStatusResolved, resp.Status)
+
+	// 恢复到售后前的状态，付款和发货时间不变，自动确认收货不会重新计时
+	restored := reloadOrder(t, db, order.ID)
+	assert.Equal(t, models.OrderStatusAwaitReceipt, restored.Status)
+	require.NotNil(t, restored.PayTime)
+	require.NotNil(t, restored.DeliveryTime)
+	assert.True(t, order.PayTime.Equal(*restored.PayTime))
+	assert.True(t, order.DeliveryTime.Equal(*restored.DeliveryTime))
+	assert.Equal(t, models.PaymentStatusPaid, loadOrderPayment(t, db, order.ID).Status)
+	assert.Equal(t, models.ProductStatusReserved, loadProduct(t, db, order.ProductID).Status)
+}
+
+func TestResolveDisputeConflicts(t *testing.T) {
+	service, db, order := newTestDispute(t, payment.NewLocalProvider("test_payment_secret"))
+
+	// 退款处理中的售后不能再次处理
+	require.NoError(t, db.Model(&models.Dispute{}).Where("order_id = ?", order.ID).
+		Update("status", models.DisputeStatusRefunding).Error)
+	_, err := service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{Resolution: models.DisputeResolutionRefund})
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, models.PaymentStatusPaid, loadOrderPayment(t, db, order.ID).Status)
+
+	// 已处理的售后不存在进行中的申请
+	require.NoError(t, db.Model(&models.Dispute{}).Where("order_id = ?", order.ID).
+		Update("status", models.DisputeStatusResolved).Error)
+	_, err = service.ResolveDispute(order.ID, testAdminID, &api.ResolveDisputeRequest{Resolution: models.DisputeResolutionReject})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestRespondDisputeKeepsSellerEvidence(t *testing.T) {
+	service, _, order := newTestDispute(t, payment.NewLocalProvider("test_payment_secret"))
+
+	resp, err := service.RespondDispute(order.ID, testSellerID, &api.RespondDisputeRequest{
+		Response:  "发货时完好",
+		Evidences: []string{"/static/uploads/ship1.jpg", "/static/uploads/ship2.jpg"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DisputeStatusResponded, resp.Status)
+	assert.Equal(t, []string{"/static/uploads/ship1.jpg", "/static/uploads/ship2.jpg"}, resp.SellerEvidences)
+	assert.Empty(t, resp.BuyerEvidences)
+
+	disputes, err := service.GetOrderDisputes(order.ID, testBuyerID)
+	require.NoError(t, err)
+	require.Len(t, disputes, 1)
+	assert.Len(t, disputes[0].SellerEvidences, 2)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"campus/internal/config"
+	"campus/internal/models"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/testdb"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// 未配置输出时日志被丢弃
+	logger.Init(&config.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// 测试数据中的买家、卖家和卖家发布的商品
+const (
+	testBuyerID   uint = 1
+	testSellerID  uint = 2
+	testProductID uint = 1
+)
+
+// newTestOrderRepository 使用内存SQLite创建订单仓库，并写入买家、卖家和一件在售商品
+func newTestOrderRepository(t *testing.T) (repositories.OrderRepository, *gorm.DB) {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.Order{}, &models.OrderLog{},
+		&models.Payment{}, &models.Dispute{}, &models.DisputeEvidence{}, &models.Offer{})
+
+	for _, user := range []models.User{
+		{Model: gorm.Model{ID: testBuyerID}, Username: "buyer", Password: "x", Email: "buyer@example.com"},
+		{Model: gorm.Model{ID: testSellerID}, Username: "seller", Password: "x", Email: "seller@example.com"},
+	} {
+		require.NoError(t, db.Create(&user).Error)
+	}
+	product := models.Product{Model: gorm.Model{ID: testProductID}, Title: "台灯", Price: 100,
+		UserID: testSellerID, Status: models.ProductStatusOnSale}
+	require.NoError(t, db.Create(&product).Error)
+
+	return repositories.NewOrderRepository(db), db
+}
+
+// createTestOrder 直接写入指定状态的订单，商品随订单状态标记为交易中
+func createTestOrder(t *testing.T, db *gorm.DB, order models.Order) *models.Order {
+	if order.BuyerID == 0 {
+		order.BuyerID = testBuyerID
+	}
+	if order.SellerID == 0 {
+		order.SellerID = testSellerID
+	}
+	if order.ProductID == 0 {
+		order.ProductID = testProductID
+	}
+	require.NoError(t, db.Create(&order).Error)
+	if !IsTerminalOrderStatus(order.Status) {
+		require.NoError(t, db.Model(&models.Product{}).Where("id = ?", order.ProductID).
+			Update("status", models.ProductStatusReserved).Error)
+	}
+	return &order
+}
+
+// reloadOrder 从数据库重新读取订单
+func reloadOrder(t *testing.T, db *gorm.DB, id uint) *models.Order {
+	var order models.Order
+	require.NoError(t, db.First(&order, id).Error)
+	return &order
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"campus/internal/models"
+	msgapi "campus/internal/modules/message/api"
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// OfferExpiryJobName 出价过期任务名称，同时作为数据库租约的键
+const OfferExpiryJobName = "offer_expiry"
+
+// 每批处理的过期出价数量
+const offerExpiryBatchSize = 100
+
+type OfferService interface {
+	CreateOffer(buyerID uint, req *api.CreateOfferRequest) (*api.OfferResponse, error)
+	CounterOffer(offerID, userID uint, req *api.CounterOfferRequest) (*api.OfferResponse, error)
+	AcceptOffer(offerID, userID uint) (*api.OfferResponse, error)
+	RejectOffer(offerID, userID uint) (*api.OfferResponse, error)
+	WithdrawOffer(offerID, userID uint) (*api.OfferResponse, error)
+	GetOffer(offerID, userID uint) (*api.OfferResponse, error)
+	ListOffers(userID uint, req *api.OfferListRequest) (*api.OfferListResponse, error)
+	// ExpireOffers 将超时未回应的出价标记为已过期，由定时任务调用
+	ExpireOffers(ctx context.Context) error
+}
+
+// OfferNotifier 将议价事件以商品消息推送给对方，由消息服务实现
+type OfferNotifier interface {
+	SendMessage(senderID uint, req msgapi.SendMessageRequest) (*msgapi.MessageResponse, error)
+}
+
+type OfferServiceImpl struct {
+	repository  repositories.OrderRepository
+	notifier    OfferNotifier
+	expireAfter time.Duration
+}
+
+func NewOfferService(orderRep repositories.OrderRepository, notifier OfferNotifier, expireAfter time.Duration) OfferService {
+	return &OfferServiceImpl{
+		repository:  orderRep,
+		notifier:    notifier,
+		expireAfter: expireAfter,
+	}
+}
+
+// CreateOffer 买家对在售商品出价，同一买家对同一商品同时只能有一个待回应的出价
+func (s *OfferServiceImpl) CreateOffer(buyerID uint, req *api.CreateOfferRequest) (*api.OfferResponse, error) {
+	product, err := s.repository.GetProductByID(req.ProductID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("商品", err)
+	}
+	if product.UserID == buyerID {
+		return nil, errors.NewBadRequestError("不能对自己发布的商品出价", nil)
+	}
+	if product.Status != models.ProductStatusOnSale {
+		return nil, errors.NewConflictError(fmt.Sprintf("商品当前不可议价（%s）", product.Status), nil)
+	}
+
+	if _, err := s.repository.GetPendingOffer(product.ID, buyerID); err == nil {
+		return nil, errors.NewConflictError("您对该商品已有待回应的出价", nil)
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, errors.NewInternalServerError("查询出价失败", err)
+	}
+
+	offer := &models.Offer{
+		ProductID:  product.ID,
+		BuyerID:    buyerID,
+		SellerID:   product.UserID,
+		ProposerID: buyerID,
+		Price:      req.Price,
+		Message:    req.Message,
+		Status:     models.OfferStatusPending,
+		ExpiresAt:  time.Now().Add(s.expireAfter),
+	}
+	if err := s.repository.CreateOffer(offer); err != nil {
+		return nil, errors.NewInternalServerError("创建出价失败", err)
+	}
+	offer.Product = *product
+
+	s.notify(buyerID, offer.SellerID, offer, withMessage(fmt.Sprintf("对商品《%s》出价 ¥%.2f", product.Title, offer.Price), offer.Message))
+	return api.ConvertToOfferResponse(offer), nil
+}
+
+// CounterOffer 被出价方还价，原出价变更为已还价，由对方继续回应新的价格
+func (s *OfferServiceImpl) CounterOffer(offerID, userID uint, req *api.CounterOfferRequest) (*api.OfferResponse, error) {
+	offer, err := s.getRespondableOffer(offerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.Product.Status != models.ProductStatusOnSale {
+		return nil, errors.NewConflictError(fmt.Sprintf("商品当前不可议价（%s）", offer.Product.Status), nil)
+	}
+
+	now := time.Now()
+	counter := &models.Offer{
+		ProductID:  offer.ProductID,
+		BuyerID:    offer.BuyerID,
+		SellerID:   offer.SellerID,
+		ProposerID: userID,
+		ParentID:   &offer.ID,
+		Price:      req.Price,
+		Message:    req.Message,
+		Status:     models.OfferStatusPending,
+		ExpiresAt:  now.Add(s.expireAfter),
+	}
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		if err := s.closeOffer(repo, offer, models.OfferStatusCountered, now, nil); err != nil {
+			return err
+		}
+		if err := repo.CreateOffer(counter); err != nil {
+			return errors.NewInternalServerError("创建还价失败", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	counter.Product = offer.Product
+
+	s.notify(userID, offer.ProposerID, counter, withMessage(fmt.Sprintf("对商品《%s》还价 ¥%.2f", offer.Product.Title, counter.Price), counter.Message))
+	return api.ConvertToOfferResponse(counter), nil
+}
+
+// AcceptOffer 被出价方接受出价，按出价生成卖家已同意的订单并预订商品
+func (s *OfferServiceImpl) AcceptOffer(offerID, userID uint) (*api.OfferResponse, error) {
+	offer, err := s.getRespondableOffer(offerID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	operator := OrderOperator{UserID: userID, Actor: ActorSeller}
+	if userID == offer.BuyerID {
+		operator.Actor = ActorBuyer
+	}
+	remark := fmt.Sprintf("议价成交，成交价%.2f", offer.Price)
+
+	now := time.Now()
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		// 商品不可购买时整个事务回滚，出价保持待回应
+		order, err := createOrderTx(repo, offer.BuyerID, offer.ProductID, &offer.Price, models.OrderStatusAccepted, operator, remark)
+		if err != nil {
+			return err
+		}
+		offer.OrderID = &order.ID
+		return s.closeOffer(repo, offer, models.OfferStatusAccepted, now, map[string]interface{}{"order_id": order.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(userID, offer.ProposerID, offer, fmt.Sprintf("已接受商品《%s》的出价 ¥%.2f，订单号%d", offer.Product.Title, offer.Price, *offer.OrderID))
+	return api.ConvertToOfferResponse(offer), nil
+}
+
+// RejectOffer 被出价方拒绝出价
+func (s *OfferServiceImpl) RejectOffer(offerID, userID uint) (*api.OfferResponse, error) {
+	offer, err := s.getRespondableOffer(offerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.closeOffer(s.repository, offer, models.OfferStatusRejected, time.Now(), nil); err != nil {
+		return nil, err
+	}
+
+	s.notify(userID, offer.ProposerID, offer, fmt.Sprintf("已拒绝商品《%s》的出价 ¥%.2f", offer.Product.Title, offer.Price))
+	return api.ConvertToOfferResponse(offer), nil
+}
+
+// WithdrawOffer 出价方撤回待回应的出价
+func (s *OfferServiceImpl) WithdrawOffer(offerID, userID uint) (*api.OfferResponse, error) {
+	offer, err := s.getOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.ProposerID != userID {
+		return nil, errors.NewForbiddenError("只有出价方可以撤回出价", nil)
+	}
+	if offer.Status != models.OfferStatusPending {
+		return nil, errors.NewConflictError(fmt.Sprintf("出价当前不能撤回（%s）", offer.Status), nil)
+	}
+	if err := s.closeOffer(s.repository, offer, models.OfferStatusWithdrawn, time.Now(), nil); err != nil {
+		return nil, err
+	}
+
+	s.notify(userID, counterpartOf(offer, userID), offer, fmt.Sprintf("已撤回商品《%s》的出价 ¥%.2f", offer.Product.Title, offer.Price))
+	return api.ConvertToOfferResponse(offer), nil
+}
+
+// GetOffer 获取出价详情，买卖双方可查看
+func (s *OfferServiceImpl) GetOffer(offerID, userID uint) (*api.OfferResponse, error) {
+	offer, err := s.getOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerID != userID && offer.SellerID != userID {
+		return nil, errors.NewForbiddenError("无权查看该出价", nil)
+	}
+	return api.ConvertToOfferResponse(offer), nil
+}
+
+// ListOffers 按买家或卖家身份分页获取出价记录
+func (s *OfferServiceImpl) ListOffers(userID uint, req *api.OfferListRequest) (*api.OfferListResponse, error) {
+	role := req.Role
+	if role == "" {
+		role = repositories.OrderRoleBuyer
+	}
+
+	offers, total, err := s.repository.ListOffers(userID, role, req.Status, req.Page, req.Size)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取出价记录失败", err)
+	}
+
+	resp := &api.OfferListResponse{
+		Total: total,
+		List:  make([]*api.OfferResponse, 0, len(offers)),
+	}
+	for _, o := range offers {
+		resp.List = append(resp.List, api.ConvertToOfferResponse(o))
+	}
+	return resp, nil
+}
+
+// ExpireOffers 按ID分批将过期出价标记为已过期并通知出价方，已被回应的出价直接跳过
+func (s *OfferServiceImpl) ExpireOffers(ctx context.Context) error {
+	now := time.Now()
+
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		offers, err := s.repository.FindExpiredOffers(now, lastID, offerExpiryBatchSize)
+		if err != nil {
+			return errors.NewInternalServerError("查询过期出价失败", err)
+		}
+
+		for _, offer := range offers {
+			lastID = offer.ID
+			s.expireOffer(offer)
+		}
+
+		if len(offers) < offerExpiryBatchSize {
+			return nil
+		}
+	}
+}
+
+// getOffer 获取出价及关联商品
+func (s *OfferServiceImpl) getOffer(offerID uint) (*models.Offer, error) {
+	offer, err := s.repository.GetOfferByID(offerID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("出价", err)
+		}
+		return nil, errors.NewInternalServerError("查询出价失败", err)
+	}
+	return offer, nil
+}
+
+// getRespondableOffer 获取当前用户可以回应的出价：用户是被出价方，且出价待回应、未过期
+func (s *OfferServiceImpl) getRespondableOffer(offerID, userID uint) (*models.Offer, error) {
+	offer, err := s.getOffer(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerID != userID && offer.SellerID != userID {
+		return nil, errors.NewForbiddenError("无权回应该出价", nil)
+	}
+	if offer.ProposerID == userID {
+		return nil, errors.NewForbiddenError("不能回应自己的出价", nil)
+	}
+	if offer.Status != models.OfferStatusPending {
+		return nil, errors.NewConflictError(fmt.Sprintf("出价当前不能回应（%s）", offer.Status), nil)
+	}
+	if time.Now().After(offer.ExpiresAt) {
+		s.expireOffer(offer)
+		return nil, errors.NewConflictError("出价已过期", nil)
+	}
+	return offer, nil
+}
+
+// closeOffer 将待回应的出价变更为to状态，出价已被并发处理时返回冲突
+func (s *OfferServiceImpl) closeOffer(repo repositories.OrderRepository, offer *models.Offer, to string, now time.Time, fields map[string]interface{}) error {
+	updates := map[string]interface{}{
+		"status":       to,
+		"responded_at": now,
+	}
+	for k, v := range fields {
+		updates[k] = v
+	}
+
+	updated, err := repo.UpdateOffer(offer.ID, models.OfferStatusPending, updates)
+	if err != nil {
+		return errors.NewInternalServerError("更新出价失败", err)
+	}
+	if !updated {
+		return errors.NewConflictError("出价状态已变更，请刷新后重试", nil)
+	}
+	offer.Status = to
+	offer.RespondedAt = &now
+	return nil
+}
+
+// expireOffer 将出价标记为已过期，并以被出价方的名义通知出价方
+func (s *OfferServiceImpl) expireOffer(offer *models.Offer) {
+	err := s.closeOffer(s.repository, offer, models.OfferStatusExpired, time.Now(), nil)
+	if err != nil {
+		if !errors.IsConflict(err) {
+			logger.Error("出价过期处理失败", zap.Uint("offerID", offer.ID), zap.Error(err))
+		}
+		return
+	}
+
+	s.notify(counterpartOf(offer, offer.ProposerID), offer.ProposerID, offer,
+		fmt.Sprintf("商品《%s》的出价 ¥%.2f 超时未回应，已过期", offer.Product.Title, offer.Price))
+}
+
+// notify 通过消息服务向对方推送议价事件，推送失败不影响议价结果
+func (s *OfferServiceImpl) notify(senderID, receiverID uint, offer *models.Offer, content string) {
+	if s.notifier == nil {
+		return
+	}
+	_, err := s.notifier.SendMessage(senderID, msgapi.SendMessageRequest{
+		ReceiverID: receiverID,
+		Content:    content,
+		ProductID:  offer.ProductID,
+		Type:       models.MessageTypeProduct,
+	})
+	if err != nil {
+		logger.Warn("议价消息推送失败", zap.Uint("offerID", offer.ID), zap.Error(err))
+	}
+}
+
+// counterpartOf 返回出价中userID的交易对方
+func counterpartOf(offer *models.Offer, userID uint) uint {
+	if userID == offer.BuyerID {
+		return offer.SellerID
+	}
+	return offer.BuyerID
+}
+
+// withMessage 在通知内容后附加出价留言
+func withMessage(content, message string) string {
+	if message == "" {
+		return content
+	}
+	return content + "：" + message
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"campus/internal/models"
+	msgapi "campus/internal/modules/message/api"
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/payment"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingNotifier 记录议价推送的消息接收方
+type recordingNotifier struct {
+	receivers []uint
+}
+
+func (n *recordingNotifier) SendMessage(senderID uint, req msgapi.SendMessageRequest) (*msgapi.MessageResponse, error) {
+	n.receivers = append(n.receivers, req.ReceiverID)
+	return &msgapi.MessageResponse{}, nil
+}
+
+func newTestOfferService(t *testing.T) (OfferService, *recordingNotifier, *gorm.DB) {
+	repo, db := newTestOrderRepository(t)
+	notifier := &recordingNotifier{}
+	return NewOfferService(repo, notifier, time.Hour), notifier, db
+}
+
+func loadOffer(t *testing.T, db *gorm.DB, id uint) *models.Offer {
+	var offer models.Offer
+	require.NoError(t, db.First(&offer, id).Error)
+	return &offer
+}
+
+func TestCounterOffer(t *testing.T) {
+	service, notifier, db := newTestOfferService(t)
+
+	offer, err := service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 80})
+	require.NoError(t, err)
+
+	// 出价方不能回应自己的出价
+	_, err = service.CounterOffer(offer.ID, testBuyerID, &api.CounterOfferRequest{Price: 85})
+	assert.True(t, errors.IsForbidden(err))
+
+	counter, err := service.CounterOffer(offer.ID, testSellerID, &api.CounterOfferRequest{Price: 90})
+	require.NoError(t, err)
+	assert.Equal(t, models.OfferStatusPending, counter.Status)
+	assert.Equal(t, 90.0, counter.Price)
+
+	original := loadOffer(t, db, offer.ID)
+	assert.Equal(t, models.OfferStatusCountered, original.Status)
+	saved := loadOffer(t, db, counter.ID)
+	assert.Equal(t, testSellerID, saved.ProposerID)
+	require.NotNil(t, saved.ParentID)
+	assert.Equal(t, offer.ID, *saved.ParentID)
+
+	// 已还价的出价不能再回应
+	_, err = service.AcceptOffer(offer.ID, testSellerID)
+	assert.True(t, errors.IsConflict(err))
+
+	assert.Equal(t, []uint{testSellerID, testBuyerID}, notifier.receivers)
+}
+
+func TestAcceptOfferCreatesOrderAtAgreedPrice(t *testing.T) {
+	service, _, db := newTestOfferService(t)
+
+	offer, err := service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 80})
+	require.NoError(t, err)
+	counter, err := service.CounterOffer(offer.ID, testSellerID, &api.CounterOfferRequest{Price: 90})
+	require.NoError(t, err)
+
+	accepted, err := service.AcceptOffer(counter.ID, testBuyerID)
+	require.NoError(t, err)
+	assert.Equal(t, models.OfferStatusAccepted, accepted.Status)
+
+	saved := loadOffer(t, db, counter.ID)
+	require.NotNil(t, saved.OrderID)
+	order := reloadOrder(t, db, *saved.OrderID)
+	assert.Equal(t, models.OrderStatusAccepted, order.Status)
+	assert.Equal(t, 90.0, order.AgreedPrice)
+	assert.Equal(t, testBuyerID, order.BuyerID)
+	assert.Equal(t, testSellerID, order.SellerID)
+	assert.Equal(t, models.ProductStatusReserved, loadProduct(t, db, testProductID).Status)
+
+	// 支付金额按议价成交价计算，而不是商品标价
+	require.NoError(t, db.Model(&models.Order{}).Where("id = ?", order.ID).
+		Update("status", models.OrderStatusAwaitPayment).Error)
+	payments := NewPaymentService(repositories.NewOrderRepository(db), payment.NewLocalProvider("test_payment_secret"), nil)
+	intent, err := payments.CreatePaymentIntent(order.ID, testBuyerID)
+	require.NoError(t, err)
+	assert.Equal(t, 90.0, intent.Amount)
+}
+
+func TestAcceptOfferProductUnavailable(t *testing.T) {
+	service, _, db := newTestOfferService(t)
+
+	offer, err := service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 80})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Product{}).Where("id = ?", testProductID).
+		Update("status", models.ProductStatusReserved).Error)
+
+	// 商品已被预订时不生成订单，出价保持待回应
+	_, err = service.AcceptOffer(offer.ID, testSellerID)
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, models.OfferStatusPending, loadOffer(t, db, offer.ID).Status)
+
+	var orders int64
+	require.NoError(t, db.Model(&models.Order{}).Count(&orders).Error)
+	assert.Zero(t, orders)
+}
+
+func TestExpireOffers(t *testing.T) {
+	service, notifier, db := newTestOfferService(t)
+
+	expired, err := service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 80})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Offer{}).Where("id = ?", expired.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+	notifier.receivers = nil
+
+	require.NoError(t, service.ExpireOffers(context.Background()))
+	assert.Equal(t, models.OfferStatusExpired, loadOffer(t, db, expired.ID).Status)
+	// 以卖家名义通知出价的买家
+	assert.Equal(t, []uint{testBuyerID}, notifier.receivers)
+
+	// 过期后不能再回应，买家可以重新出价
+	_, err = service.AcceptOffer(expired.ID, testSellerID)
+	assert.True(t, errors.IsConflict(err))
+	_, err = service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 85})
+	require.NoError(t, err)
+}
+
+func TestRespondToExpiredOffer(t *testing.T) {
+	service, _, db := newTestOfferService(t)
+
+	offer, err := service.CreateOffer(testBuyerID, &api.CreateOfferRequest{ProductID: testProductID, Price: 80})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(&models.Offer{}).Where("id = ?", offer.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error)
+
+	// 定时任务执行前回应已过期的出价，出价随即标记为已过期
+	_, err = service.AcceptOffer(offer.ID, testSellerID)
+	assert.True(t, errors.IsConflict(err))
+	assert.Equal(t, models.OfferStatusExpired, loadOffer(t, db, offer.ID).Status)
+}
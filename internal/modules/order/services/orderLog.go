@@ -0,0 +1,62 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+)
+
+// 订单日志动作
+const (
+	OrderActionCreate       = "创建订单"
+	OrderActionUpdateStatus = "更新订单状态"
+	OrderActionCancel       = "取消订单"
+	OrderActionDelete       = "删除订单"
+	OrderActionPay          = "发起支付"
+	OrderActionRefund       = "退款"
+	OrderActionDispute      = "申请售后"
+	OrderActionRespond      = "卖家回应售后"
+)
+
+// OrderOperator 订单操作人，记录在订单日志中
+type OrderOperator struct {
+	UserID uint       // 操作用户ID，系统操作为0
+	Actor  OrderActor // 操作方角色
+}
+
+// SystemOperator 系统操作人（定时任务、支付回调等）
+var SystemOperator = OrderOperator{Actor: ActorSystem}
+
+// newOrderLog 构建订单日志记录
+func newOrderLog(orderID uint, action string, op OrderOperator, from, to, remark string) *models.OrderLog {
+	return &models.OrderLog{
+		OrderID:      orderID,
+		Action:       action,
+		Operator:     op.Actor.Label(),
+		OperatorID:   op.UserID,
+		OperatorRole: string(op.Actor),
+		FromStatus:   from,
+		ToStatus:     to,
+		Remark:       remark,
+	}
+}
+
+// statusChangeAction 根据目标状态返回日志动作描述
+func statusChangeAction(to string) string {
+	switch to {
+	case models.OrderStatusCancelled:
+		return OrderActionCancel
+	case models.OrderStatusDisputed:
+		return OrderActionDispute
+	default:
+		return OrderActionUpdateStatus + "为" + to
+	}
+}
+
+// appendOrderLog 在事务中写入订单日志
+func appendOrderLog(repo repositories.OrderRepository, log *models.OrderLog) error {
+	if err := repo.CreateOrderLog(log); err != nil {
+		return errors.NewInternalServerError("记录订单日志失败", err)
+	}
+	return nil
+}
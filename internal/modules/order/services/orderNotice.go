@@ -0,0 +1,34 @@
+package services
+
+import (
+	"campus/internal/models"
+	msgapi "campus/internal/modules/message/api"
+	"campus/internal/utils/logger"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// OrderNotifier 以系统消息通知买卖双方订单状态变更，由消息服务实现。
+// 系统消息保存在消息表中，离线的设备重连时随其他消息一起补发
+type OrderNotifier interface {
+	SendSystemMessage(req *msgapi.AdminSendSystemMessageRequest) error
+}
+
+// notifyOrderStatus 在状态变更提交后通知买卖双方，通知失败只记录日志
+func notifyOrderStatus(notifier OrderNotifier, order *models.Order) {
+	if notifier == nil {
+		return
+	}
+	content := fmt.Sprintf("订单#%d状态已变更为%s", order.ID, order.Status)
+	for _, receiverID := range []uint{order.BuyerID, order.SellerID} {
+		err := notifier.SendSystemMessage(&msgapi.AdminSendSystemMessageRequest{
+			ReceiverID: receiverID,
+			Title:      "订单通知",
+			Content:    content,
+		})
+		if err != nil {
+			logger.Error("发送订单通知失败", zap.Uint("orderID", order.ID), zap.Uint("receiverID", receiverID), zap.Error(err))
+		}
+	}
+}
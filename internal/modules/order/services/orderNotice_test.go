@@ -0,0 +1,47 @@
+package services
+
+import (
+	"campus/internal/models"
+	msgapi "campus/internal/modules/message/api"
+	messageRep "campus/internal/modules/message/repositories"
+	messageSrv "campus/internal/modules/message/services"
+	"campus/internal/modules/order/payment"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storingOrderNotifier 与消息服务一样将订单通知保存为系统消息，不做实时推送
+type storingOrderNotifier struct {
+	repo messageRep.MessageRepository
+}
+
+func (n *storingOrderNotifier) SendSystemMessage(req *msgapi.AdminSendSystemMessageRequest) error {
+	_, err := n.repo.CreateSystemMessage(req.ReceiverID, req.Content, req.Title)
+	return err
+}
+
+func TestOrderNoticeReplayedAfterReconnect(t *testing.T) {
+	repo, db := newTestOrderRepository(t)
+	require.NoError(t, db.AutoMigrate(&models.Message{}))
+	messages := messageRep.NewMessageRepository(db)
+	provider := payment.NewLocalProvider("test_payment_secret")
+	service := NewPaymentService(repo, provider, &storingOrderNotifier{repo: messages})
+
+	order := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitPayment})
+	intent, err := service.CreatePaymentIntent(order.ID, testBuyerID)
+	require.NoError(t, err)
+	params := provider.SignCallback(intent.TradeNo, 100, true)
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, params))
+	// 重复回调不会重复通知
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, params))
+
+	// 买卖双方离线期间的订单通知在重连时补发
+	for _, userID := range []uint{testBuyerID, testSellerID} {
+		deliveries, err := messageSrv.NewMessageBacklog(messages).Since(userID, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Contains(t, string(deliveries[0].Payload), models.OrderStatusAwaitDelivery)
+	}
+}
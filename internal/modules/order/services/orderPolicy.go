@@ -0,0 +1,54 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/errors"
+)
+
+// 订单资源的访问策略：根据JWT中的user_id判断当前用户在订单中的身份，
+// 身份确定后具体的状态流转权限再交由订单状态机校验
+
+// resolveOrderActor 根据当前用户在订单中的身份确定操作方
+func resolveOrderActor(order *models.Order, userID uint) (OrderActor, error) {
+	switch userID {
+	case order.SellerID:
+		return ActorSeller, nil
+	case order.BuyerID:
+		return ActorBuyer, nil
+	default:
+		return "", errors.NewForbiddenError("您不是该订单的买家或卖家", nil)
+	}
+}
+
+// authorizeOrderView 只有买家和卖家可以查看订单
+func authorizeOrderView(order *models.Order, userID uint) error {
+	_, err := resolveOrderActor(order, userID)
+	return err
+}
+
+// authorizeOrderStatusChange 校验用户能否发起状态变更，返回其操作方身份
+func authorizeOrderStatusChange(order *models.Order, userID uint, to string) (OrderActor, error) {
+	actor, err := resolveOrderActor(order, userID)
+	if err != nil {
+		return "", err
+	}
+
+	// 同意、拒绝订单是卖家的专属操作
+	if (to == models.OrderStatusAccepted || to == models.OrderStatusRejected) && actor != ActorSeller {
+		return "", errors.NewForbiddenError("只有卖家可以同意或拒绝订单", nil)
+	}
+	return actor, nil
+}
+
+// authorizeOrderDelete 只有买家和卖家可以删除订单，且订单必须已结束
+func authorizeOrderDelete(order *models.Order, userID uint) (OrderActor, error) {
+	actor, err := resolveOrderActor(order, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if !IsTerminalOrderStatus(order.Status) {
+		return "", errors.NewForbiddenError("只能删除已完成、已取消或已拒绝的订单", nil)
+	}
+	return actor, nil
+}
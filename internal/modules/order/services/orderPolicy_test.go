@@ -0,0 +1,37 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOrderPolicy 测试订单访问策略
+func TestOrderPolicy(t *testing.T) {
+	order := &models.Order{BuyerID: 1, SellerID: 2, Status: models.OrderStatusPending}
+
+	// 买卖双方可以查看，其他用户不行
+	assert.NoError(t, authorizeOrderView(order, 1))
+	assert.NoError(t, authorizeOrderView(order, 2))
+	assert.True(t, errors.IsForbidden(authorizeOrderView(order, 3)))
+
+	// 只有卖家可以同意订单
+	actor, err := authorizeOrderStatusChange(order, 2, models.OrderStatusAccepted)
+	assert.NoError(t, err)
+	assert.Equal(t, ActorSeller, actor)
+	_, err = authorizeOrderStatusChange(order, 1, models.OrderStatusRejected)
+	assert.True(t, errors.IsForbidden(err))
+
+	// 未结束的订单不能删除
+	_, err = authorizeOrderDelete(order, 1)
+	assert.True(t, errors.IsForbidden(err))
+
+	order.Status = models.OrderStatusCancelled
+	actor, err = authorizeOrderDelete(order, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, ActorBuyer, actor)
+	_, err = authorizeOrderDelete(order, 3)
+	assert.True(t, errors.IsForbidden(err))
+}
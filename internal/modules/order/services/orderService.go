@@ -5,76 +5,156 @@ import (
 	"campus/internal/modules/order/api"
 	"campus/internal/modules/order/repositories"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/export"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/pagination"
 	"fmt"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 	"strconv"
 	"time"
 )
 
 type OrderService interface {
-	CreateOrder(data *api.CreateOrderRequest) (*api.OrderResponse, error)
-	DeleteOrder(id uint) error
-	UpdateOrderStatus(id uint, data *api.UpdateOrderStatusRequest) (*api.OrderResponse, error)
-	GetOrderByID(id uint) (*api.OrderResponse, error)
-	GetUserOrders(buyerID uint, page, size uint) (*api.OrderListResponse, error)
+	CreateOrder(buyerID uint, data *api.CreateOrderRequest) (*api.OrderResponse, error)
+	DeleteOrder(id, userID uint) error
+	UpdateOrderStatus(id, userID uint, data *api.UpdateOrderStatusRequest) (*api.OrderResponse, error)
+	GetOrderByID(id, userID uint) (*api.OrderResponse, error)
+	GetUserOrders(buyerID uint, p pagination.Params) (*api.OrderListResponse, error)
+	GetSoldOrders(sellerID uint, req *api.UserOrderListRequest) (*api.OrderListResponse, error)
+	GetBoughtOrders(buyerID uint, req *api.UserOrderListRequest) (*api.OrderListResponse, error)
 
 	// 管理员接口
 	GetAdminOrderList(req *api.AdminOrderListRequest) (*api.AdminOrderListResponse, error)
 	GetAdminOrderDetail(id uint) (*api.AdminOrderDetailResponse, error)
-	AdminUpdateOrderStatus(id uint, req *api.AdminUpdateOrderStatusRequest) error
-	ExportOrders(req *api.AdminOrderListRequest) ([]byte, error)
+	AdminUpdateOrderStatus(id, adminID uint, req *api.AdminUpdateOrderStatusRequest) error
+	ExportOrders(req *api.AdminOrderExportRequest, w export.Writer) error
+}
+
+// OrderRefunder 订单取消时退还已支付款项
+type OrderRefunder interface {
+	RefundOrder(order *models.Order, reason string) error
 }
 
 type OrderServiceImpl struct {
 	repository repositories.OrderRepository
+	refunder   OrderRefunder
+	notifier   OrderNotifier
 }
 
-func NewOrderService(orderRep repositories.OrderRepository) OrderService {
+func NewOrderService(orderRep repositories.OrderRepository, refunder OrderRefunder, notifier OrderNotifier) OrderService {
 	return &OrderServiceImpl{
 		repository: orderRep,
+		refunder:   refunder,
+		notifier:   notifier,
 	}
 }
 
-func (s *OrderServiceImpl) CreateOrder(data *api.CreateOrderRequest) (*api.OrderResponse, error) {
-	// 使用指针类型的time.Time，初始值为nil表示数据库中的NULL
-	order := &models.Order{
-		BuyerID:      data.BuyerID,
-		SellerID:     data.SellerID,
-		ProductID:    data.ProductID,
-		Status:       "卖家未处理",
-		PayTime:      nil, // 设置为nil表示未支付
-		DeliveryTime: nil, // 设置为nil表示未发货
-		CompleteTime: nil, // 设置为nil表示未完成
-		Remark:       "",
+// CreateOrder 创建订单并预订商品，锁定商品行以防止同一商品被重复下单
+func (s *OrderServiceImpl) CreateOrder(buyerID uint, data *api.CreateOrderRequest) (*api.OrderResponse, error) {
+	var order *models.Order
+	operator := OrderOperator{UserID: buyerID, Actor: ActorBuyer}
+
+	err := s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		var err error
+		order, err = createOrderTx(repo, buyerID, data.ProductID, nil, models.OrderStatusPending, operator, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	notifyOrderStatus(s.notifier, order)
+
+	return api.ConvertToOrderResponse(order), nil
+}
+
+// createOrderTx 在事务中锁定并预订商品后创建订单，agreedPrice为nil时以商品当前价格成交
+func createOrderTx(repo repositories.OrderRepository, buyerID, productID uint, agreedPrice *float64, status string, op OrderOperator, remark string) (*models.Order, error) {
+	product, err := repo.LockProduct(productID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("商品", err)
+		}
+		return nil, errors.NewInternalServerError("查询商品失败", err)
+	}
+
+	if product.UserID == buyerID {
+		return nil, errors.NewBadRequestError("不能购买自己发布的商品", nil)
+	}
+	if product.Status != models.ProductStatusOnSale {
+		return nil, errors.NewConflictError(fmt.Sprintf("商品当前不可购买（%s）", product.Status), nil)
+	}
+	price := product.Price
+	if agreedPrice != nil {
+		price = *agreedPrice
 	}
 
-	if err := s.repository.Create(order); err != nil {
+	// 卖家以商品发布者为准，使用指针类型的时间字段，nil表示数据库中的NULL
+	order := &models.Order{
+		BuyerID:     buyerID,
+		SellerID:    product.UserID,
+		ProductID:   product.ID,
+		Status:      status,
+		AgreedPrice: price,
+	}
+	if err := repo.Create(order); err != nil {
 		return nil, errors.NewInternalServerError("创建订单失败", err)
 	}
 
-	return api.ConvertToOrderResponse(order), nil
+	reserved, err := repo.TransitionProductStatus(product.ID, models.ProductStatusOnSale, models.ProductStatusReserved)
+	if err != nil {
+		return nil, errors.NewInternalServerError("预订商品失败", err)
+	}
+	if !reserved {
+		return nil, errors.NewConflictError("商品已被其他买家预订", nil)
+	}
+
+	if err := appendOrderLog(repo, newOrderLog(order.ID, OrderActionCreate, op, "", order.Status, remark)); err != nil {
+		return nil, err
+	}
+	return order, nil
 }
 
-func (s *OrderServiceImpl) DeleteOrder(id uint) error {
-	if err := s.repository.Delete(id); err != nil {
-		return errors.NewInternalServerError("删除订单失败", err)
+func (s *OrderServiceImpl) DeleteOrder(id, userID uint) error {
+	order, err := s.repository.GetByID(id)
+	if err != nil {
+		return errors.NewNotFoundError("订单", err)
 	}
-	return nil
+
+	actor, err := authorizeOrderDelete(order, userID)
+	if err != nil {
+		return err
+	}
+
+	operator := OrderOperator{UserID: userID, Actor: actor}
+	return s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		// 先写日志再软删除，保证删除的订单仍可追溯
+		if err := appendOrderLog(repo, newOrderLog(order.ID, OrderActionDelete, operator, order.Status, order.Status, "")); err != nil {
+			return err
+		}
+		if err := repo.Delete(id); err != nil {
+			return errors.NewInternalServerError("删除订单失败", err)
+		}
+		return nil
+	})
 }
 
-func (s *OrderServiceImpl) UpdateOrderStatus(id uint, data *api.UpdateOrderStatusRequest) (*api.OrderResponse, error) {
+func (s *OrderServiceImpl) UpdateOrderStatus(id, userID uint, data *api.UpdateOrderStatusRequest) (*api.OrderResponse, error) {
 	// 先获取订单当前状态
 	order, err := s.repository.GetByID(id)
 	if err != nil {
 		return nil, errors.NewNotFoundError("订单", err)
 	}
 
-	// 校验订单当前状态是否为未处理，只有未处理状态才能修改为已同意或已拒绝
-	if order.Status != "卖家未处理" {
-		return nil, errors.NewBadRequestError("订单状态不可修改", nil)
+	// 根据当前用户在订单中的身份确定操作方
+	actor, err := authorizeOrderStatusChange(order, userID, data.Status)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repository.UpdateStatus(id, data.Status, data.Remark); err != nil {
-		return nil, errors.NewInternalServerError("更新订单状态失败", err)
+	operator := OrderOperator{UserID: userID, Actor: actor}
+	if err := s.transitionOrder(order, data.Status, operator, data.Remark); err != nil {
+		return nil, err
 	}
 
 	// 重新获取更新后的订单信息
@@ -86,45 +166,194 @@ func (s *OrderServiceImpl) UpdateOrderStatus(id uint, data *api.UpdateOrderStatu
 	return api.ConvertToOrderResponse(updatedOrder), nil
 }
 
-func (s *OrderServiceImpl) GetOrderByID(id uint) (*api.OrderResponse, error) {
+// transitionOrder 通过订单状态机执行状态变更，并在同一事务中记录订单日志
+// 订单取消后退还已支付的款项
+func (s *OrderServiceImpl) transitionOrder(order *models.Order, to string, op OrderOperator, remark string) error {
+	err := s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		return transitionOrderTx(repo, order, to, op, remark)
+	})
+	if err != nil {
+		return err
+	}
+	notifyOrderStatus(s.notifier, order)
+
+	if to == models.OrderStatusCancelled && s.refunder != nil {
+		// 订单已取消，退款失败不回滚订单状态，记录日志后由管理员处理
+		if err := s.refunder.RefundOrder(order, "订单取消，自动退款"); err != nil {
+			logger.Error("订单取消退款失败", zap.Uint("orderID", order.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// transitionOrderTx 在调用方的事务中执行状态变更、商品预订同步和订单日志记录
+func transitionOrderTx(repo repositories.OrderRepository, order *models.Order, to string, op OrderOperator, remark string) error {
+	from := order.Status
+	if err := ApplyOrderTransition(order, to, op.Actor, time.Now()); err != nil {
+		return err
+	}
+
+	// 只写入本次流转新设置的时间字段
+	var awaitPayTime, payTime, deliveryTime, completeTime *time.Time
+	switch to {
+	case models.OrderStatusAwaitPayment:
+		awaitPayTime = order.AwaitPayTime
+	case models.OrderStatusAwaitDelivery:
+		payTime = order.PayTime
+	case models.OrderStatusAwaitReceipt:
+		deliveryTime = order.DeliveryTime
+	case models.OrderStatusCompleted:
+		completeTime = order.CompleteTime
+	}
+
+	updated, err := repo.TransitionStatus(order.ID, from, to, remark, awaitPayTime, payTime, deliveryTime, completeTime)
+	if err != nil {
+		return errors.NewInternalServerError("更新订单状态失败", err)
+	}
+	if !updated {
+		return errors.NewConflictError("订单状态已变更，请刷新后重试", nil)
+	}
+	if err := syncProductReservation(repo, order.ProductID, to); err != nil {
+		return err
+	}
+	return appendOrderLog(repo, newOrderLog(order.ID, statusChangeAction(to), op, from, to, remark))
+}
+
+// restoreOrderStatusTx 在事务中将订单恢复到之前的状态（如售后驳回），
+// 保留原有的时间字段，付款、发货时间和自动确认收货的计时不会重新开始
+func restoreOrderStatusTx(repo repositories.OrderRepository, order *models.Order, to string, op OrderOperator, remark string) error {
+	from := order.Status
+	if err := CheckOrderTransition(from, to, op.Actor); err != nil {
+		return err
+	}
+
+	updated, err := repo.TransitionStatus(order.ID, from, to, remark, nil, nil, nil, nil)
+	if err != nil {
+		return errors.NewInternalServerError("更新订单状态失败", err)
+	}
+	if !updated {
+		return errors.NewConflictError("订单状态已变更，请刷新后重试", nil)
+	}
+	order.Status = to
+	if err := syncProductReservation(repo, order.ProductID, to); err != nil {
+		return err
+	}
+	return appendOrderLog(repo, newOrderLog(order.ID, statusChangeAction(to), op, from, to, remark))
+}
+
+// syncProductReservation 订单被拒绝、取消或退款时释放商品预订，订单完成时将商品标记为已售出
+func syncProductReservation(repo repositories.OrderRepository, productID uint, to string) error {
+	var target string
+	switch to {
+	case models.OrderStatusRejected, models.OrderStatusCancelled, models.OrderStatusRefunded:
+		target = models.ProductStatusOnSale
+	case models.OrderStatusCompleted:
+		target = models.ProductStatusSold
+	default:
+		return nil
+	}
+
+	// 商品不处于交易中（如历史订单）时不做处理
+	if _, err := repo.TransitionProductStatus(productID, models.ProductStatusReserved, target); err != nil {
+		return errors.NewInternalServerError("更新商品状态失败", err)
+	}
+	return nil
+}
+
+func (s *OrderServiceImpl) GetOrderByID(id, userID uint) (*api.OrderResponse, error) {
 	order, err := s.repository.GetByID(id)
 	if err != nil {
 		return nil, errors.NewNotFoundError("订单", err)
 	}
+	if err := authorizeOrderView(order, userID); err != nil {
+		return nil, err
+	}
 
 	return api.ConvertToOrderResponse(order), nil
 }
 
-func (s *OrderServiceImpl) GetUserOrders(buyerID uint, page, size uint) (*api.OrderListResponse, error) {
-	orders, total, err := s.repository.GetByBuyerID(buyerID, page, size)
+func (s *OrderServiceImpl) GetUserOrders(buyerID uint, p pagination.Params) (*api.OrderListResponse, error) {
+	orders, info, err := s.repository.GetByBuyerID(buyerID, p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("查询用户订单失败", err)
 	}
 
-	return api.ConvertToOrderListResponse(orders, uint(total), page, size), nil
+	return api.ConvertToOrderListResponse(orders, info), nil
+}
+
+// GetSoldOrders 查询卖家收到的订单
+func (s *OrderServiceImpl) GetSoldOrders(sellerID uint, req *api.UserOrderListRequest) (*api.OrderListResponse, error) {
+	return s.listOrdersByRole(sellerID, repositories.OrderRoleSeller, req)
+}
+
+// GetBoughtOrders 查询买家下的订单
+func (s *OrderServiceImpl) GetBoughtOrders(buyerID uint, req *api.UserOrderListRequest) (*api.OrderListResponse, error) {
+	return s.listOrdersByRole(buyerID, repositories.OrderRoleBuyer, req)
+}
+
+func (s *OrderServiceImpl) listOrdersByRole(userID uint, role string, req *api.UserOrderListRequest) (*api.OrderListResponse, error) {
+	if req.Status != "" && !IsValidOrderStatus(req.Status) {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("未知的订单状态: %s", req.Status), nil)
+	}
+
+	params, err := req.Params()
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+	if params.CursorMode() && req.Sort != "" && req.Sort != "created_desc" {
+		return nil, errors.NewBadRequestError("该排序方式不支持游标分页，请传入page参数", nil)
+	}
+
+	orders, info, err := s.repository.ListByRole(&repositories.OrderQuery{
+		UserID:    userID,
+		Role:      role,
+		Status:    req.Status,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Sort:      req.Sort,
+		Params:    params,
+	})
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询订单列表失败", err)
+	}
+
+	// 角标数量不受状态筛选影响
+	counts, err := s.repository.CountByStatus(userID, role)
+	if err != nil {
+		return nil, errors.NewInternalServerError("统计订单数量失败", err)
+	}
+
+	resp := api.ConvertToOrderListResponse(orders, info)
+	resp.StatusCounts = counts
+	return resp, nil
 }
 
 // GetAdminOrderList 管理员获取订单列表
 func (s *OrderServiceImpl) GetAdminOrderList(req *api.AdminOrderListRequest) (*api.AdminOrderListResponse, error) {
-	// 设置默认值
-	if req.Page == 0 {
+	// 设置默认值，未传入游标时保持原有的page/size分页
+	if req.Cursor == "" && req.Page == 0 {
 		req.Page = 1
 	}
 	if req.PageSize == 0 {
 		req.PageSize = 10
 	}
 
+	params, err := pagination.NewParams(req.Cursor, req.Page, req.PageSize)
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+
 	// 获取订单列表
-	orders, total, err := s.repository.GetOrdersForAdmin(
-		req.Search, req.Status, req.StartDate, req.EndDate, req.Page, req.PageSize)
+	orders, info, err := s.repository.GetOrdersForAdmin(
+		req.Search, req.Status, req.StartDate, req.EndDate, params)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取订单列表失败", err)
 	}
 
 	// 构建响应
 	response := &api.AdminOrderListResponse{
-		Total: total,
-		List:  make([]api.AdminOrderItem, 0, len(orders)),
+		List: make([]api.AdminOrderItem, 0, len(orders)),
+		Info: info,
 	}
 
 	// 填充订单数据
@@ -157,6 +386,7 @@ func (s *OrderServiceImpl) GetAdminOrderList(req *api.AdminOrderListRequest) (*a
 			ID:           strconv.Itoa(int(order.ID)),
 			ProductTitle: productTitle,
 			ProductImage: productImage,
+			Price:        orderPrice(order),
 			Buyer:        buyerName,
 			Seller:       sellerName,
 			Status:       order.Status,
@@ -202,6 +432,7 @@ func (s *OrderServiceImpl) GetAdminOrderDetail(id uint) (*api.AdminOrderDetailRe
 		Status:     order.Status,
 		CreateTime: order.CreatedAt,
 		Remark:     order.Remark,
+		Price:      orderPrice(order),
 		Logs:       make([]api.OrderLogItem, 0, len(logs)),
 	}
 
@@ -243,10 +474,14 @@ func (s *OrderServiceImpl) GetAdminOrderDetail(id uint) (*api.AdminOrderDetailRe
 	// 填充日志信息
 	for _, log := range logs {
 		logItem := api.OrderLogItem{
-			Action:   log.Action,
-			Time:     log.CreatedAt,
-			Operator: log.Operator,
-			Remark:   log.Remark,
+			Action:       log.Action,
+			Time:         log.CreatedAt,
+			Operator:     log.Operator,
+			OperatorID:   log.OperatorID,
+			OperatorRole: log.OperatorRole,
+			FromStatus:   log.FromStatus,
+			ToStatus:     log.ToStatus,
+			Remark:       log.Remark,
 		}
 		response.Logs = append(response.Logs, logItem)
 	}
@@ -255,81 +490,86 @@ func (s *OrderServiceImpl) GetAdminOrderDetail(id uint) (*api.AdminOrderDetailRe
 }
 
 // AdminUpdateOrderStatus 管理员更新订单状态
-func (s *OrderServiceImpl) AdminUpdateOrderStatus(id uint, req *api.AdminUpdateOrderStatusRequest) error {
+func (s *OrderServiceImpl) AdminUpdateOrderStatus(id, adminID uint, req *api.AdminUpdateOrderStatusRequest) error {
 	// 获取订单
-	_, err := s.repository.GetByID(id)
+	order, err := s.repository.GetByID(id)
 	if err != nil {
 		return errors.NewNotFoundError("订单", err)
 	}
 
-	// 声明时间指针变量
-	var payTime, deliveryTime, completeTime *time.Time
-
-	// 根据状态更新相关时间字段
-	now := time.Now()
-	switch req.Status {
-	case "待付款":
-		// 无需特殊处理
-	case "待发货":
-		payTime = &now
-	case "待收货":
-		deliveryTime = &now
-	case "已完成":
-		completeTime = &now
-	case "已取消":
-		// 无需特殊处理
-	}
-
-	// 更新订单状态和时间
-	if err := s.repository.UpdateStatusWithTimes(id, req.Status, req.Remark, payTime, deliveryTime, completeTime); err != nil {
-		return errors.NewInternalServerError("更新订单状态失败", err)
+	// 售后中的订单需通过售后处理，保证退款与状态一致
+	if order.Status == models.OrderStatusDisputed {
+		return errors.NewConflictError("订单售后处理中，请通过售后流程处理", nil)
 	}
 
-	return nil
+	// 管理员同样需要遵循订单状态机的流转规则
+	operator := OrderOperator{UserID: adminID, Actor: ActorAdmin}
+	return s.transitionOrder(order, req.Status, operator, req.Remark)
 }
 
-// ExportOrders 导出订单数据
-func (s *OrderServiceImpl) ExportOrders(req *api.AdminOrderListRequest) ([]byte, error) {
-	// 设置导出时的页码和每页数量
-	req.Page = 1
-	req.PageSize = 1000 // 导出更多数据
+// 导出时每批读取的订单数量
+const exportBatchSize = 500
+
+// OrderExportColumns 订单导出的列定义
+var OrderExportColumns = []export.Column{
+	{Key: "id", Title: "订单ID"},
+	{Key: "productTitle", Title: "商品标题"},
+	{Key: "price", Title: "价格"},
+	{Key: "buyer", Title: "买家"},
+	{Key: "seller", Title: "卖家"},
+	{Key: "status", Title: "状态"},
+	{Key: "createTime", Title: "创建时间"},
+	{Key: "payTime", Title: "支付时间"},
+	{Key: "completeTime", Title: "完成时间"},
+}
 
-	// 获取订单列表
-	result, err := s.GetAdminOrderList(req)
+// ExportOrders 导出全部符合条件的订单，分批读取并逐行写入w，每批结束后刷新输出
+func (s *OrderServiceImpl) ExportOrders(req *api.AdminOrderExportRequest, w export.Writer) error {
+	err := s.repository.IterateOrdersForAdmin(req.Search, req.Status, req.StartDate, req.EndDate, exportBatchSize,
+		func(orders []*models.Order) error {
+			for _, order := range orders {
+				if err := w.WriteRow(orderExportRow(order)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		})
 	if err != nil {
-		return nil, err
+		return errors.NewInternalServerError("导出订单失败", err)
 	}
 
-	// 构建CSV数据
-	csvData := "订单ID,商品标题,价格,买家,卖家,状态,创建时间,支付时间,完成时间\n"
-
-	for _, order := range result.List {
-		// 格式化时间
-		createTime := order.CreateTime.Format("2006-01-02 15:04:05")
-		payTime := ""
-		if order.PayTime != nil {
-			payTime = order.PayTime.Format("2006-01-02 15:04:05")
-		}
-		completeTime := ""
-		if order.CompleteTime != nil {
-			completeTime = order.CompleteTime.Format("2006-01-02 15:04:05")
-		}
+	if err := w.Close(); err != nil {
+		return errors.NewInternalServerError("导出订单失败", err)
+	}
+	return nil
+}
 
-		// 添加一行数据
-		line := fmt.Sprintf("%s,%s,%.2f,%s,%s,%s,%s,%s,%s\n",
-			order.ID,
-			order.ProductTitle,
-			order.Price,
-			order.Buyer,
-			order.Seller,
-			order.Status,
-			createTime,
-			payTime,
-			completeTime,
-		)
+// orderPrice 返回订单成交价，早期未记录成交价的订单按商品当前价格计算
+func orderPrice(order *models.Order) float64 {
+	if order.AgreedPrice > 0 {
+		return order.AgreedPrice
+	}
+	return order.Product.Price
+}
 
-		csvData += line
+// orderExportRow 将订单转换为导出行，顺序与 OrderExportColumns 一致
+func orderExportRow(order *models.Order) []string {
+	return []string{
+		strconv.Itoa(int(order.ID)),
+		order.Product.Title,
+		strconv.FormatFloat(orderPrice(order), 'f', 2, 64),
+		order.Buyer.Username,
+		order.Seller.Username,
+		order.Status,
+		order.CreatedAt.Format("2006-01-02 15:04:05"),
+		formatExportTime(order.PayTime),
+		formatExportTime(order.CompleteTime),
 	}
+}
 
-	return []byte(csvData), nil
+func formatExportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
 }
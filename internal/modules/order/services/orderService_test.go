@@ -0,0 +1,32 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestGetOrderByIDOnlyForParticipants(t *testing.T) {
+	repo, db := newTestOrderRepository(t)
+	const otherID uint = 3
+	require.NoError(t, db.Create(&models.User{Model: gorm.Model{ID: otherID}, Username: "other", Password: "x", Email: "other@example.com"}).Error)
+	order := createTestOrder(t, db, models.Order{Status: models.OrderStatusPending})
+	service := NewOrderService(repo, nil, nil)
+
+	for _, userID := range []uint{testBuyerID, testSellerID} {
+		resp, err := service.GetOrderByID(order.ID, userID)
+		require.NoError(t, err)
+		assert.Equal(t, order.ID, resp.ID)
+	}
+
+	// 非买卖双方不能查看订单
+	_, err := service.GetOrderByID(order.ID, otherID)
+	assert.True(t, errors.IsForbidden(err))
+
+	_, err = service.GetOrderByID(order.ID+100, testBuyerID)
+	assert.True(t, errors.IsNotFound(err))
+}
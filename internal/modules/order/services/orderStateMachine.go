@@ -0,0 +1,164 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/errors"
+	"fmt"
+	"time"
+)
+
+// OrderActor 订单状态变更的操作方
+type OrderActor string
+
+const (
+	ActorBuyer  OrderActor = "buyer"  // 买家
+	ActorSeller OrderActor = "seller" // 卖家
+	ActorAdmin  OrderActor = "admin"  // 管理员
+	ActorSystem OrderActor = "system" // 系统（定时任务、支付回调等）
+)
+
+// Label 返回操作方的中文名称，用于订单日志展示
+func (a OrderActor) Label() string {
+	switch a {
+	case ActorBuyer:
+		return "买家"
+	case ActorSeller:
+		return "卖家"
+	case ActorAdmin:
+		return "管理员"
+	case ActorSystem:
+		return "系统"
+	default:
+		return string(a)
+	}
+}
+
+// orderTransition 一条合法的订单状态流转及允许触发的操作方
+type orderTransition struct {
+	To     string
+	Actors []OrderActor
+}
+
+// orderTransitions 订单状态流转表，未列出的流转均为非法
+var orderTransitions = map[string][]orderTransition{
+	models.OrderStatusPending: {
+		{To: models.OrderStatusAccepted, Actors: []OrderActor{ActorSeller, ActorAdmin}},
+		{To: models.OrderStatusRejected, Actors: []OrderActor{ActorSeller, ActorAdmin}},
+		{To: models.OrderStatusCancelled, Actors: []OrderActor{ActorBuyer, ActorAdmin, ActorSystem}},
+	},
+	models.OrderStatusAccepted: {
+		{To: models.OrderStatusAwaitPayment, Actors: []OrderActor{ActorBuyer, ActorAdmin, ActorSystem}},
+		// 校园交易多为当面交易，买家可直接确认完成
+		{To: models.OrderStatusCompleted, Actors: []OrderActor{ActorBuyer, ActorAdmin}},
+		{To: models.OrderStatusCancelled, Actors: []OrderActor{ActorBuyer, ActorSeller, ActorAdmin, ActorSystem}},
+	},
+	models.OrderStatusAwaitPayment: {
+		{To: models.OrderStatusAwaitDelivery, Actors: []OrderActor{ActorAdmin, ActorSystem}},
+		{To: models.OrderStatusCancelled, Actors: []OrderActor{ActorBuyer, ActorAdmin, ActorSystem}},
+	},
+	models.OrderStatusAwaitDelivery: {
+		{To: models.OrderStatusAwaitReceipt, Actors: []OrderActor{ActorSeller, ActorAdmin}},
+		{To: models.OrderStatusCancelled, Actors: []OrderActor{ActorSeller, ActorAdmin}},
+		{To: models.OrderStatusDisputed, Actors: []OrderActor{ActorBuyer}},
+	},
+	models.OrderStatusAwaitReceipt: {
+		{To: models.OrderStatusCompleted, Actors: []OrderActor{ActorBuyer, ActorAdmin, ActorSystem}},
+		{To: models.OrderStatusDisputed, Actors: []OrderActor{ActorBuyer}},
+	},
+	models.OrderStatusCompleted: {
+		{To: models.OrderStatusDisputed, Actors: []OrderActor{ActorBuyer}},
+	},
+	// 售后由管理员处理：退款、部分退款后完成，或驳回后恢复到发起售后前的状态
+	models.OrderStatusDisputed: {
+		{To: models.OrderStatusRefunded, Actors: []OrderActor{ActorAdmin}},
+		{To: models.OrderStatusCompleted, Actors: []OrderActor{ActorAdmin}},
+		{To: models.OrderStatusAwaitDelivery, Actors: []OrderActor{ActorAdmin}},
+		{To: models.OrderStatusAwaitReceipt, Actors: []OrderActor{ActorAdmin}},
+	},
+	models.OrderStatusRejected:  {},
+	models.OrderStatusCancelled: {},
+	models.OrderStatusRefunded:  {},
+}
+
+// terminalOrderStatuses 交易已结束的订单状态，已完成的订单仍可以发起售后
+var terminalOrderStatuses = map[string]bool{
+	models.OrderStatusRejected:  true,
+	models.OrderStatusCompleted: true,
+	models.OrderStatusCancelled: true,
+	models.OrderStatusRefunded:  true,
+}
+
+// IsValidOrderStatus 判断是否为已定义的订单状态
+func IsValidOrderStatus(status string) bool {
+	_, ok := orderTransitions[status]
+	return ok
+}
+
+// IsTerminalOrderStatus 判断订单交易是否已结束
+func IsTerminalOrderStatus(status string) bool {
+	return terminalOrderStatuses[status]
+}
+
+// AllowedOrderTransitions 返回操作方在当前状态下可以变更到的目标状态
+func AllowedOrderTransitions(from string, actor OrderActor) []string {
+	var targets []string
+	for _, t := range orderTransitions[from] {
+		if containsActor(t.Actors, actor) {
+			targets = append(targets, t.To)
+		}
+	}
+	return targets
+}
+
+// CheckOrderTransition 校验操作方能否将订单从from状态变更为to状态
+func CheckOrderTransition(from, to string, actor OrderActor) error {
+	if !IsValidOrderStatus(to) {
+		return errors.NewBadRequestError(fmt.Sprintf("未知的订单状态: %s", to), nil)
+	}
+
+	transitions, ok := orderTransitions[from]
+	if !ok {
+		return errors.NewConflictError(fmt.Sprintf("订单当前状态异常: %s", from), nil)
+	}
+
+	for _, t := range transitions {
+		if t.To != to {
+			continue
+		}
+		if !containsActor(t.Actors, actor) {
+			return errors.NewForbiddenError(fmt.Sprintf("%s无权将订单变更为%s", actor.Label(), to), nil)
+		}
+		return nil
+	}
+
+	return errors.NewConflictError(fmt.Sprintf("订单状态不能从%s变更为%s", from, to), nil)
+}
+
+// ApplyOrderTransition 校验并执行状态流转，同时设置对应的时间字段
+func ApplyOrderTransition(order *models.Order, to string, actor OrderActor, now time.Time) error {
+	if err := CheckOrderTransition(order.Status, to, actor); err != nil {
+		return err
+	}
+
+	switch to {
+	case models.OrderStatusAwaitPayment:
+		order.AwaitPayTime = &now
+	case models.OrderStatusAwaitDelivery:
+		order.PayTime = &now
+	case models.OrderStatusAwaitReceipt:
+		order.DeliveryTime = &now
+	case models.OrderStatusCompleted:
+		order.CompleteTime = &now
+	}
+	order.Status = to
+	return nil
+}
+
+func containsActor(actors []OrderActor, actor OrderActor) bool {
+	for _, a := range actors {
+		if a == actor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCheckOrderTransition 测试订单状态流转校验
+func TestCheckOrderTransition(t *testing.T) {
+	// 卖家可以同意未处理的订单
+	assert.NoError(t, CheckOrderTransition(models.OrderStatusPending, models.OrderStatusAccepted, ActorSeller))
+
+	// 买家不能替卖家同意订单
+	err := CheckOrderTransition(models.OrderStatusPending, models.OrderStatusAccepted, ActorBuyer)
+	assert.True(t, errors.IsForbidden(err))
+
+	// 已完成的订单不能再变更
+	err = CheckOrderTransition(models.OrderStatusCompleted, models.OrderStatusCancelled, ActorAdmin)
+	assert.True(t, errors.IsConflict(err))
+
+	// 管理员也不能跳过付款直接发货
+	err = CheckOrderTransition(models.OrderStatusPending, models.OrderStatusAwaitReceipt, ActorAdmin)
+	assert.True(t, errors.IsConflict(err))
+
+	// 未知状态
+	err = CheckOrderTransition(models.OrderStatusPending, "已发货", ActorAdmin)
+	assert.True(t, errors.IsBadRequest(err))
+}
+
+// TestApplyOrderTransition 测试状态流转时设置时间字段
+func TestApplyOrderTransition(t *testing.T) {
+	now := time.Now()
+	order := &models.Order{Status: models.OrderStatusAwaitPayment}
+
+	assert.NoError(t, ApplyOrderTransition(order, models.OrderStatusAwaitDelivery, ActorSystem, now))
+	assert.Equal(t, models.OrderStatusAwaitDelivery, order.Status)
+	assert.Equal(t, now, *order.PayTime)
+
+	assert.NoError(t, ApplyOrderTransition(order, models.OrderStatusAwaitReceipt, ActorSeller, now))
+	assert.Equal(t, now, *order.DeliveryTime)
+
+	assert.NoError(t, ApplyOrderTransition(order, models.OrderStatusCompleted, ActorBuyer, now))
+	assert.Equal(t, now, *order.CompleteTime)
+	assert.True(t, IsTerminalOrderStatus(order.Status))
+
+	// 校验失败时不修改订单
+	err := ApplyOrderTransition(order, models.OrderStatusCancelled, ActorBuyer, now)
+	assert.Error(t, err)
+	assert.Equal(t, models.OrderStatusCompleted, order.Status)
+}
+
+// TestAllowedOrderTransitions 测试获取可流转的目标状态
+func TestAllowedOrderTransitions(t *testing.T) {
+	assert.ElementsMatch(t,
+		[]string{models.OrderStatusAccepted, models.OrderStatusRejected},
+		AllowedOrderTransitions(models.OrderStatusPending, ActorSeller))
+	assert.Empty(t, AllowedOrderTransitions(models.OrderStatusCancelled, ActorAdmin))
+}
+
+// TestDisputeTransitions 测试售后相关的状态流转
+func TestDisputeTransitions(t *testing.T) {
+	// 买家可以对已完成的订单申请售后，已完成仍视为交易结束
+	assert.NoError(t, CheckOrderTransition(models.OrderStatusCompleted, models.OrderStatusDisputed, ActorBuyer))
+	assert.True(t, IsTerminalOrderStatus(models.OrderStatusCompleted))
+
+	// 卖家不能发起售后，售后只能由管理员处理
+	assert.True(t, errors.IsForbidden(CheckOrderTransition(models.OrderStatusAwaitReceipt, models.OrderStatusDisputed, ActorSeller)))
+	assert.True(t, errors.IsForbidden(CheckOrderTransition(models.OrderStatusDisputed, models.OrderStatusRefunded, ActorBuyer)))
+	assert.NoError(t, CheckOrderTransition(models.OrderStatusDisputed, models.OrderStatusRefunded, ActorAdmin))
+
+	// 未付款的订单不能申请售后
+	assert.True(t, errors.IsConflict(CheckOrderTransition(models.OrderStatusAwaitPayment, models.OrderStatusDisputed, ActorBuyer)))
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"campus/internal/config"
+	"campus/internal/models"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// OrderTimeoutJobName 订单超时任务名称，同时作为数据库租约的键
+const OrderTimeoutJobName = "order_timeout"
+
+// 每批处理的超时订单数量
+const orderTimeoutBatchSize = 100
+
+// orderTimeoutRule 一条超时规则：status状态的订单在timeColumn之后超过timeout时变更为to
+type orderTimeoutRule struct {
+	status     string
+	timeColumn string
+	timeout    time.Duration
+	to         string
+	remark     string
+}
+
+// OrderTimeoutJob 订单超时处理任务
+// 状态变更通过订单状态机以系统身份执行，同时记录订单日志并释放预订的商品；
+// 变更以当前状态为条件，重复执行或多实例并发执行都不会重复处理同一订单
+type OrderTimeoutJob struct {
+	service *OrderServiceImpl
+	rules   []orderTimeoutRule
+}
+
+// NewOrderTimeoutJob 创建订单超时处理任务
+func NewOrderTimeoutJob(orderRep repositories.OrderRepository, notifier OrderNotifier, cfg config.OrderConfig) *OrderTimeoutJob {
+	return &OrderTimeoutJob{
+		service: &OrderServiceImpl{repository: orderRep, notifier: notifier},
+		rules: []orderTimeoutRule{
+			{
+				status:     models.OrderStatusPending,
+				timeColumn: "created_at",
+				timeout:    cfg.SellerHandleTimeout,
+				to:         models.OrderStatusCancelled,
+				remark:     "卖家超时未处理，系统自动取消",
+			},
+			// 付款超时从进入待付款的时间开始计算，订单的其他更新不会推迟超时；
+			// 增加该字段之前进入待付款的订单没有记录，使用更新时间
+			{
+				status:     models.OrderStatusAwaitPayment,
+				timeColumn: "COALESCE(await_pay_time, updated_at)",
+				timeout:    cfg.PaymentTimeout,
+				to:         models.OrderStatusCancelled,
+				remark:     "买家超时未付款，系统自动取消",
+			},
+			{
+				status:     models.OrderStatusAwaitReceipt,
+				timeColumn: "delivery_time",
+				timeout:    cfg.AutoConfirmAfter,
+				to:         models.OrderStatusCompleted,
+				remark:     "超时未确认收货，系统自动确认",
+			},
+		},
+	}
+}
+
+// Run 执行一次超时处理
+func (j *OrderTimeoutJob) Run(ctx context.Context) error {
+	for _, rule := range j.rules {
+		if err := j.apply(ctx, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (j *OrderTimeoutJob) apply(ctx context.Context, rule orderTimeoutRule) error {
+	before := time.Now().Add(-rule.timeout)
+
+	// 按ID分批处理，处理失败的订单留到下一次执行
+	var lastID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		orders, err := j.service.repository.FindTimedOut(rule.status, rule.timeColumn, before, lastID, orderTimeoutBatchSize)
+		if err != nil {
+			return errors.NewInternalServerError("查询超时订单失败", err)
+		}
+
+		for _, order := range orders {
+			lastID = order.ID
+			// 状态已被其他请求变更的订单直接跳过
+			err := j.service.transitionOrder(order, rule.to, SystemOperator, rule.remark)
+			if err != nil && !errors.IsConflict(err) {
+				logger.Error("订单超时处理失败", zap.Uint("orderID", order.ID), zap.Error(err))
+			}
+		}
+
+		if len(orders) < orderTimeoutBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"campus/internal/config"
+	"campus/internal/models"
+	messageRep "campus/internal/modules/message/repositories"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderTimeoutJob(t *testing.T) {
+	repo, db := newTestOrderRepository(t)
+	require.NoError(t, db.AutoMigrate(&models.Message{}))
+	now := time.Now()
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	pending := createTestOrder(t, db, models.Order{Status: models.OrderStatusPending})
+	require.NoError(t, db.Model(pending).Update("created_at", now.Add(-49*time.Hour)).Error)
+	// 付款超时从进入待付款的时间计算，订单的其他更新不影响
+	unpaid := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitPayment, AwaitPayTime: ago(31 * time.Minute)})
+	waiting := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitPayment, AwaitPayTime: ago(5 * time.Minute)})
+	require.NoError(t, db.Model(waiting).UpdateColumn("updated_at", now.Add(-time.Hour)).Error)
+	delivered := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitReceipt, DeliveryTime: ago(8 * 24 * time.Hour)})
+	fresh := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitReceipt, DeliveryTime: ago(time.Hour)})
+
+	notifier := &storingOrderNotifier{repo: messageRep.NewMessageRepository(db)}
+	job := NewOrderTimeoutJob(repo, notifier, config.OrderConfig{
+		SellerHandleTimeout: 48 * time.Hour,
+		PaymentTimeout:      30 * time.Minute,
+		AutoConfirmAfter:    7 * 24 * time.Hour,
+	})
+	require.NoError(t, job.Run(context.Background()))
+
+	assert.Equal(t, models.OrderStatusCancelled, reloadOrder(t, db, pending.ID).Status)
+	assert.Equal(t, models.OrderStatusCancelled, reloadOrder(t, db, unpaid.ID).Status)
+	assert.Equal(t, models.OrderStatusAwaitPayment, reloadOrder(t, db, waiting.ID).Status)
+	completed := reloadOrder(t, db, delivered.ID)
+	assert.Equal(t, models.OrderStatusCompleted, completed.Status)
+	assert.NotNil(t, completed.CompleteTime)
+	assert.Equal(t, models.OrderStatusAwaitReceipt, reloadOrder(t, db, fresh.ID).Status)
+
+	var logs []models.OrderLog
+	require.NoError(t, db.Where("order_id = ?", unpaid.ID).Find(&logs).Error)
+	require.Len(t, logs, 1)
+	assert.Equal(t, string(ActorSystem), logs[0].OperatorRole)
+
+	// 重复执行不会重复处理
+	require.NoError(t, job.Run(context.Background()))
+	var count int64
+	db.Model(&models.OrderLog{}).Count(&count)
+	assert.Equal(t, int64(3), count)
+	// 每个超时订单通知买卖双方各一次
+	db.Model(&models.Message{}).Count(&count)
+	assert.Equal(t, int64(6), count)
+}
+
+func TestEnteringAwaitPaymentStampsTime(t *testing.T) {
+	order := &models.Order{Status: models.OrderStatusAccepted}
+	now := time.Now()
+	require.NoError(t, ApplyOrderTransition(order, models.OrderStatusAwaitPayment, ActorBuyer, now))
+	require.NotNil(t, order.AwaitPayTime)
+	assert.True(t, now.Equal(*order.AwaitPayTime))
+}
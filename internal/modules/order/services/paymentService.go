@@ -0,0 +1,338 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/order/api"
+	"campus/internal/modules/order/payment"
+	"campus/internal/modules/order/repositories"
+	"campus/internal/utils/errors"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PaymentService interface {
+	CreatePaymentIntent(orderID, userID uint) (*api.PaymentIntentResponse, error)
+	HandleCallback(provider string, params map[string]string) error
+	SimulatePay(orderID, userID uint, tradeNo string, success bool) error
+	GetOrderPayments(orderID, userID uint) ([]*api.PaymentResponse, error)
+	RefundOrder(order *models.Order, reason string) error
+	PartialRefundOrder(order *models.Order, amount float64, reason string) error
+	// RefundableAmount 返回订单已支付且未退款的金额，没有线上支付时返回0
+	RefundableAmount(orderID uint) (float64, error)
+}
+
+type PaymentServiceImpl struct {
+	repository repositories.OrderRepository
+	provider   payment.Provider
+	notifier   OrderNotifier
+}
+
+func NewPaymentService(orderRep repositories.OrderRepository, provider payment.Provider, notifier OrderNotifier) PaymentService {
+	return &PaymentServiceImpl{
+		repository: orderRep,
+		provider:   provider,
+		notifier:   notifier,
+	}
+}
+
+// CreatePaymentIntent 买家为待付款订单创建支付单，每次调用都是一次新的支付尝试
+func (s *PaymentServiceImpl) CreatePaymentIntent(orderID, userID uint) (*api.PaymentIntentResponse, error) {
+	order, err := s.repository.GetByID(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+	if order.BuyerID != userID {
+		return nil, errors.NewForbiddenError("只有买家可以支付订单", nil)
+	}
+	if order.Status != models.OrderStatusAwaitPayment {
+		return nil, errors.NewConflictError(fmt.Sprintf("订单当前状态不能支付（%s）", order.Status), nil)
+	}
+
+	product, err := s.repository.GetProductByID(order.ProductID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("商品", err)
+	}
+	order.Product = *product
+	amount := orderPrice(order)
+
+	tradeNo, err := newTradeNo()
+	if err != nil {
+		return nil, errors.NewInternalServerError("生成交易号失败", err)
+	}
+
+	result, err := s.provider.CreateIntent(&payment.Intent{
+		TradeNo: tradeNo,
+		Amount:  amount,
+		Subject: product.Title,
+	})
+	if err != nil {
+		return nil, errors.NewInternalServerError("创建支付单失败", err)
+	}
+
+	record := &models.Payment{
+		OrderID:         order.ID,
+		UserID:          userID,
+		Provider:        s.provider.Name(),
+		TradeNo:         tradeNo,
+		ProviderTradeNo: result.ProviderTradeNo,
+		Amount:          amount,
+		Status:          models.PaymentStatusPending,
+	}
+	operator := OrderOperator{UserID: userID, Actor: ActorBuyer}
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		if err := repo.CreatePayment(record); err != nil {
+			return errors.NewInternalServerError("创建支付单失败", err)
+		}
+		remark := fmt.Sprintf("交易号%s，金额%.2f", tradeNo, record.Amount)
+		return appendOrderLog(repo, newOrderLog(order.ID, OrderActionPay, operator, order.Status, order.Status, remark))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.PaymentIntentResponse{
+		TradeNo:   record.TradeNo,
+		Provider:  record.Provider,
+		Amount:    record.Amount,
+		Status:    record.Status,
+		PayParams: result.PayParams,
+	}, nil
+}
+
+// HandleCallback 处理支付渠道的异步回调，重复回调只会处理一次
+// 支付成功时订单变更为待发货；若订单已被取消，则立即原路退款
+func (s *PaymentServiceImpl) HandleCallback(provider string, params map[string]string) error {
+	if provider != s.provider.Name() {
+		return errors.NewBadRequestError(fmt.Sprintf("未知的支付渠道: %s", provider), nil)
+	}
+
+	result, err := s.provider.VerifyCallback(params)
+	if err != nil {
+		return errors.NewBadRequestError("支付回调校验失败", err)
+	}
+
+	record, err := s.repository.GetPaymentByTradeNo(result.TradeNo)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("支付单", err)
+		}
+		return errors.NewInternalServerError("查询支付单失败", err)
+	}
+	if record.Status != models.PaymentStatusPending {
+		return nil
+	}
+
+	if !result.Success {
+		return s.failPayment(record, result.FailReason)
+	}
+	if math.Abs(result.Amount-record.Amount) > 0.001 {
+		return s.failPayment(record, fmt.Sprintf("支付金额不符：应付%.2f，实付%.2f", record.Amount, result.Amount))
+	}
+
+	needRefund := false
+	var paidOrder *models.Order
+	err = s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		now := time.Now()
+		updated, err := repo.TransitionPaymentStatus(record.ID, models.PaymentStatusPending, models.PaymentStatusPaid, map[string]interface{}{
+			"paid_at":           now,
+			"provider_trade_no": result.ProviderTradeNo,
+		})
+		if err != nil {
+			return errors.NewInternalServerError("更新支付单失败", err)
+		}
+		if !updated {
+			// 并发的重复回调已处理
+			return nil
+		}
+		record.Status = models.PaymentStatusPaid
+		record.PaidAt = &now
+		record.ProviderTradeNo = result.ProviderTradeNo
+
+		order, err := repo.GetByID(record.OrderID)
+		if err != nil {
+			return errors.NewNotFoundError("订单", err)
+		}
+		if order.Status != models.OrderStatusAwaitPayment {
+			needRefund = true
+			return nil
+		}
+
+		remark := fmt.Sprintf("支付成功，交易号%s", record.TradeNo)
+		if err := transitionOrderTx(repo, order, models.OrderStatusAwaitDelivery, SystemOperator, remark); err != nil {
+			return err
+		}
+		paidOrder = order
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if paidOrder != nil {
+		notifyOrderStatus(s.notifier, paidOrder)
+	}
+
+	if needRefund {
+		return s.refundPayment(record, record.Amount, "订单已关闭，支付款项自动退回")
+	}
+	return nil
+}
+
+// SimulatePay 使用本地模拟渠道完成支付，生成签名回调并按正常回调流程处理
+func (s *PaymentServiceImpl) SimulatePay(orderID, userID uint, tradeNo string, success bool) error {
+	local, ok := s.provider.(*payment.LocalProvider)
+	if !ok {
+		return errors.NewBadRequestError("当前支付渠道不支持模拟支付", nil)
+	}
+
+	record, err := s.repository.GetPaymentByTradeNo(tradeNo)
+	if err != nil || record.OrderID != orderID {
+		return errors.NewNotFoundError("支付单", err)
+	}
+	if record.UserID != userID {
+		return errors.NewForbiddenError("只有付款人可以完成支付", nil)
+	}
+
+	return s.HandleCallback(local.Name(), local.SignCallback(record.TradeNo, record.Amount, success))
+}
+
+// GetOrderPayments 获取订单的支付记录，买卖双方可查看
+func (s *PaymentServiceImpl) GetOrderPayments(orderID, userID uint) ([]*api.PaymentResponse, error) {
+	order, err := s.repository.GetByID(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+	if err := authorizeOrderView(order, userID); err != nil {
+		return nil, err
+	}
+
+	payments, err := s.repository.GetPaymentsByOrderID(orderID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询支付记录失败", err)
+	}
+
+	list := make([]*api.PaymentResponse, 0, len(payments))
+	for _, p := range payments {
+		list = append(list, api.ConvertToPaymentResponse(p))
+	}
+	return list, nil
+}
+
+// RefundOrder 退还订单所有已支付的款项，没有已支付的支付单时不做处理
+func (s *PaymentServiceImpl) RefundOrder(order *models.Order, reason string) error {
+	payments, err := s.repository.GetPaymentsByOrderID(order.ID)
+	if err != nil {
+		return errors.NewInternalServerError("查询支付记录失败", err)
+	}
+
+	for _, p := range payments {
+		if p.Status != models.PaymentStatusPaid {
+			continue
+		}
+		if err := s.refundPayment(p, p.Amount, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PartialRefundOrder 从订单已支付的支付单中退还部分款项
+func (s *PaymentServiceImpl) PartialRefundOrder(order *models.Order, amount float64, reason string) error {
+	paid, err := s.paidPayment(order.ID)
+	if err != nil {
+		return err
+	}
+	if paid == nil {
+		return errors.NewBadRequestError("订单没有可退款的支付记录", nil)
+	}
+	if amount <= 0 || amount > paid.Amount {
+		return errors.NewBadRequestError(fmt.Sprintf("退款金额须大于0且不超过实付金额%.2f", paid.Amount), nil)
+	}
+	return s.refundPayment(paid, amount, reason)
+}
+
+// RefundableAmount 返回订单可退款的金额
+func (s *PaymentServiceImpl) RefundableAmount(orderID uint) (float64, error) {
+	paid, err := s.paidPayment(orderID)
+	if err != nil || paid == nil {
+		return 0, err
+	}
+	return paid.Amount, nil
+}
+
+// paidPayment 返回订单最近一笔已支付的支付单，没有时返回nil
+func (s *PaymentServiceImpl) paidPayment(orderID uint) (*models.Payment, error) {
+	payments, err := s.repository.GetPaymentsByOrderID(orderID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询支付记录失败", err)
+	}
+	for _, p := range payments {
+		if p.Status == models.PaymentStatusPaid {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// refundPayment 调用支付渠道原路退款，并记录订单日志
+func (s *PaymentServiceImpl) refundPayment(record *models.Payment, amount float64, reason string) error {
+	result, err := s.provider.Refund(&payment.RefundRequest{
+		TradeNo:         record.TradeNo,
+		ProviderTradeNo: record.ProviderTradeNo,
+		Amount:          amount,
+		Reason:          reason,
+	})
+	if err != nil {
+		return errors.NewInternalServerError("退款失败", err)
+	}
+
+	status := models.PaymentStatusRefunded
+	if amount < record.Amount {
+		status = models.PaymentStatusPartRefunded
+	}
+
+	return s.repository.Transaction(func(repo repositories.OrderRepository) error {
+		updated, err := repo.TransitionPaymentStatus(record.ID, models.PaymentStatusPaid, status, map[string]interface{}{
+			"refund_no":     result.RefundNo,
+			"refund_amount": amount,
+			"refunded_at":   time.Now(),
+		})
+		if err != nil {
+			return errors.NewInternalServerError("更新支付单失败", err)
+		}
+		if !updated {
+			return nil
+		}
+
+		order, err := repo.GetByID(record.OrderID)
+		if err != nil {
+			return errors.NewNotFoundError("订单", err)
+		}
+		remark := fmt.Sprintf("%s，交易号%s，退款%.2f", reason, record.TradeNo, amount)
+		return appendOrderLog(repo, newOrderLog(order.ID, OrderActionRefund, SystemOperator, order.Status, order.Status, remark))
+	})
+}
+
+// failPayment 将支付单标记为支付失败，订单保持待付款可重新发起支付
+func (s *PaymentServiceImpl) failPayment(record *models.Payment, reason string) error {
+	_, err := s.repository.TransitionPaymentStatus(record.ID, models.PaymentStatusPending, models.PaymentStatusFailed, map[string]interface{}{
+		"fail_reason": reason,
+	})
+	if err != nil {
+		return errors.NewInternalServerError("更新支付单失败", err)
+	}
+	return nil
+}
+
+// newTradeNo 生成平台交易号：P + 时间 + 随机串
+func newTradeNo() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "P" + time.Now().Format("20060102150405") + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/order/payment"
+	"campus/internal/utils/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestPaymentIntent 创建待付款订单并发起一次支付
+func newTestPaymentIntent(t *testing.T) (PaymentService, *payment.LocalProvider, *gorm.DB, *models.Order, string) {
+	repo, db := newTestOrderRepository(t)
+	provider := payment.NewLocalProvider("test_payment_secret")
+	service := NewPaymentService(repo, provider, nil)
+
+	order := createTestOrder(t, db, models.Order{Status: models.OrderStatusAwaitPayment})
+	intent, err := service.CreatePaymentIntent(order.ID, testBuyerID)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, intent.Amount)
+	return service, provider, db, order, intent.TradeNo
+}
+
+// loadPayment 按交易号读取支付单
+func loadPayment(t *testing.T, db *gorm.DB, tradeNo string) *models.Payment {
+	var record models.Payment
+	require.NoError(t, db.Where("trade_no = ?", tradeNo).First(&record).Error)
+	return &record
+}
+
+func TestHandleCallbackRejectsInvalidSignature(t *testing.T) {
+	service, provider, db, order, tradeNo := newTestPaymentIntent(t)
+
+	params := provider.SignCallback(tradeNo, 100, true)
+	params["sign"] = "forged"
+	err := service.HandleCallback(payment.LocalProviderName, params)
+	assert.True(t, errors.IsBadRequest(err))
+
+	// 用其他密钥签名的回调同样被拒绝
+	forged := payment.NewLocalProvider("other_secret").SignCallback(tradeNo, 100, true)
+	err = service.HandleCallback(payment.LocalProviderName, forged)
+	assert.True(t, errors.IsBadRequest(err))
+
+	// 签名后篡改金额
+	params = provider.SignCallback(tradeNo, 100, true)
+	params["amount"] = "1.00"
+	err = service.HandleCallback(payment.LocalProviderName, params)
+	assert.True(t, errors.IsBadRequest(err))
+
+	assert.Equal(t, models.PaymentStatusPending, loadPayment(t, db, tradeNo).Status)
+	assert.Equal(t, models.OrderStatusAwaitPayment, reloadOrder(t, db, order.ID).Status)
+}
+
+func TestHandleCallbackRejectsUnknownProvider(t *testing.T) {
+	service, provider, _, _, tradeNo := newTestPaymentIntent(t)
+
+	err := service.HandleCallback("alipay", provider.SignCallback(tradeNo, 100, true))
+	assert.True(t, errors.IsBadRequest(err))
+}
+
+func TestHandleCallbackAmountMismatch(t *testing.T) {
+	service, provider, db, order, tradeNo := newTestPaymentIntent(t)
+
+	err := service.HandleCallback(payment.LocalProviderName, provider.SignCallback(tradeNo, 1, true))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.PaymentStatusFailed, loadPayment(t, db, tradeNo).Status)
+	assert.Equal(t, models.OrderStatusAwaitPayment, reloadOrder(t, db, order.ID).Status)
+}
+
+func TestHandleCallbackSuccess(t *testing.T) {
+	service, provider, db, order, tradeNo := newTestPaymentIntent(t)
+
+	params := provider.SignCallback(tradeNo, 100, true)
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, params))
+
+	record := loadPayment(t, db, tradeNo)
+	assert.Equal(t, models.PaymentStatusPaid, record.Status)
+	assert.NotNil(t, record.PaidAt)
+	updated := reloadOrder(t, db, order.ID)
+	assert.Equal(t, models.OrderStatusAwaitDelivery, updated.Status)
+	assert.NotNil(t, updated.PayTime)
+
+	// 重复回调不会重复处理
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, params))
+	var logs int64
+	require.NoError(t, db.Model(&models.OrderLog{}).
+		Where("order_id = ? AND to_status = ?", order.ID, models.OrderStatusAwaitDelivery).Count(&logs).Error)
+	assert.Equal(t, int64(1), logs)
+}
+
+func TestHandleCallbackFailedPayment(t *testing.T) {
+	service, provider, db, order, tradeNo := newTestPaymentIntent(t)
+
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, provider.SignCallback(tradeNo, 100, false)))
+
+	assert.Equal(t, models.PaymentStatusFailed, loadPayment(t, db, tradeNo).Status)
+	assert.Equal(t, models.OrderStatusAwaitPayment, reloadOrder(t, db, order.ID).Status)
+}
+
+func TestHandleCallbackAfterOrderClosedRefunds(t *testing.T) {
+	service, provider, db, order, tradeNo := newTestPaymentIntent(t)
+	require.NoError(t, db.Model(&models.Order{}).Where("id = ?", order.ID).
+		Update("status", models.OrderStatusCancelled).Error)
+
+	require.NoError(t, service.HandleCallback(payment.LocalProviderName, provider.SignCallback(tradeNo, 100, true)))
+
+	assert.Equal(t, models.PaymentStatusRefunded, loadPayment(t, db, tradeNo).Status)
+	assert.Equal(t, models.OrderStatusCancelled, reloadOrder(t, db, order.ID).Status)
+}
@@ -1,14 +1,22 @@
 package api
 
+import (
+	"campus/internal/utils/pagination"
+	"errors"
+)
+
+// ErrInvalidPriceRange 最低价格高于最高价格
+var ErrInvalidPriceRange = errors.New("最低价格不能高于最高价格")
+
 type CreateProductRequest struct {
 	Title       string   `json:"title" binding:"required"`
 	Description string   `json:"description"`
 	Price       float64  `json:"price" binding:"required"`
 	Images      []string `json:"images"`
-	Category    string   `json:"category"`
+	CategoryID  uint     `json:"category_id"`
+	Category    string   `json:"category"` // 未传入category_id时按分类名称查找
 	Condition   string   `json:"condition"`
-	UserID      uint     `json:"user_id" binding:"required"`
-	Status      string   `json:"status" binding:"required,oneof=售卖中 已下架 审核中"`
+	UserID      uint     `json:"-"` // 发布者为当前登录用户，不从请求体读取
 }
 
 type UpdateProductRequest struct {
@@ -16,34 +24,104 @@ type UpdateProductRequest struct {
 	Description string   `json:"description"`
 	Price       float64  `json:"price"`
 	Images      []string `json:"images"`
-	Category    string   `json:"category"`
+	CategoryID  uint     `json:"category_id"`
+	Category    string   `json:"category"` // category_id和category都为空时不修改分类
 	Condition   string   `json:"condition"`
-	Status      string   `json:"status" binding:"required,oneof=售卖中 已下架 审核中"`
+	// 传入已下架时直接下架，否则编辑后的商品重新进入审核
+	Status string `json:"status" binding:"omitempty,oneof=售卖中 已下架"`
+}
+
+// UpdateProductStatusRequest 管理员直接修改商品状态请求。
+// 可选状态与批量修改一致，交易中和已售出由订单流程维护，不能直接设置
+type UpdateProductStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=售卖中 已下架 审核中 审核未通过"`
 }
 
 type GetProductsRequest struct {
-	Page uint `json:"page" form:"page" binding:"required,min=1"`
-	Size uint `json:"size" form:"size" binding:"required,min=1,max=100"`
+	pagination.Request
+}
+
+// GetRevisionsRequest 获取商品修改记录请求
+type GetRevisionsRequest struct {
+	pagination.Request
 }
 
 type GetUserProductsRequest struct {
 	UserID uint `json:"user_id" form:"user_id" binding:"required"`
-	Page   uint `json:"page" form:"page" binding:"required,min=1"`
-	Size   uint `json:"size" form:"size" binding:"required,min=1,max=100"`
+	pagination.Request
+}
+
+// BatchNotice 批量操作后通知卖家的选项
+type BatchNotice struct {
+	Notify bool   `json:"notify"`                   // 是否以系统消息通知卖家
+	Reason string `json:"reason" binding:"max=200"` // 操作原因，通知卖家时附带
 }
 
 // BatchUpdateStatusRequest 批量更新商品状态请求
 type BatchUpdateStatusRequest struct {
-	ProductIDs []uint `json:"productIds" binding:"required,min=1"`         // 商品ID列表
-	Status     string `json:"status" binding:"required,oneof=售卖中 已下架 待审核"` // 商品状态
+	ProductIDs []uint `json:"productIds" binding:"required,min=1,max=100"`       // 商品ID列表
+	Status     string `json:"status" binding:"required,oneof=售卖中 已下架 审核中 审核未通过"` // 商品状态
+	BatchNotice
+}
+
+// BatchUpdateCategoryRequest 批量调整商品分类请求
+type BatchUpdateCategoryRequest struct {
+	ProductIDs []uint `json:"productIds" binding:"required,min=1,max=100"`
+	CategoryID uint   `json:"category_id" binding:"required"`
+	BatchNotice
+}
+
+// BatchDeleteRequest 批量删除商品请求
+type BatchDeleteRequest struct {
+	ProductIDs []uint `json:"productIds" binding:"required,min=1,max=100"`
+	BatchNotice
 }
+
 type FilterProductsRequest struct {
-	Page      uint   `json:"page" form:"page" binding:"required,min=1"`
-	Size      uint   `json:"size" form:"size" binding:"required,min=1,max=100"`
-	Keyword   string `json:"keyword" form:"keyword"`       // 关键词搜索
-	Category  string `json:"category" form:"category"`     // 分类筛选
-	Status    string `json:"status" form:"status"`         // 状态筛选
-	Condition string `json:"condition" form:"condition"`   // 商品状况
-	StartDate string `json:"start_date" form:"start_date"` // 开始日期
-	EndDate   string `json:"end_date" form:"end_date"`     // 结束日期
+	pagination.Request
+	Keyword   string   `json:"keyword" form:"keyword"`                               // 关键词搜索
+	Category  []string `json:"category" form:"category"`                             // 分类筛选，可多选（重复参数或逗号分隔）
+	Status    string   `json:"status" form:"status"`                                 // 状态筛选
+	Condition []string `json:"condition" form:"condition"`                           // 商品状况，可多选（重复参数或逗号分隔）
+	MinPrice  *float64 `json:"min_price" form:"min_price" binding:"omitempty,min=0"` // 最低价格（含）
+	MaxPrice  *float64 `json:"max_price" form:"max_price" binding:"omitempty,min=0"` // 最高价格（含）
+	SellerID  uint     `json:"seller_id" form:"seller_id"`                           // 卖家筛选
+	HasImages *bool    `json:"has_images" form:"has_images"`                         // 是否有图片
+	StartDate string   `json:"start_date" form:"start_date"`                         // 开始日期
+	EndDate   string   `json:"end_date" form:"end_date"`                             // 结束日期
+
+	// 排序方式，默认最新发布；关键词搜索时默认按相关度
+	Sort string `json:"sort" form:"sort" binding:"omitempty,oneof=newest price_asc price_desc favorites"`
+
+	// 以下字段由服务端设置：非管理员只能查看在售商品，查看自己发布的商品时不限状态
+	ViewerID    uint `json:"-" form:"-"` // 当前用户
+	AllStatuses bool `json:"-" form:"-"` // 管理员查看，不限商品状态
+}
+
+// Validate 校验字段之间的约束，min_price不能高于max_price
+func (r *FilterProductsRequest) Validate() error {
+	if r.MinPrice != nil && r.MaxPrice != nil && *r.MinPrice > *r.MaxPrice {
+		return ErrInvalidPriceRange
+	}
+	return nil
+}
+
+// 商品列表排序方式
+const (
+	ProductSortNewest    = "newest"
+	ProductSortPriceAsc  = "price_asc"
+	ProductSortPriceDesc = "price_desc"
+	ProductSortFavorites = "favorites"
+)
+
+// ModerationListRequest 管理员获取审核队列请求，默认只返回待审核的记录
+type ModerationListRequest struct {
+	pagination.Request
+	Status  string `json:"status" form:"status" binding:"omitempty,oneof=pending approved rejected all"`
+	Flagged *bool  `json:"flagged" form:"flagged"` // 是否被自动预审标记
+}
+
+// RejectProductRequest 管理员驳回商品请求
+type RejectProductRequest struct {
+	Reason string `json:"reason" binding:"required,max=200"`
 }
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterProductsRequestValidate(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+
+	assert.NoError(t, (&FilterProductsRequest{}).Validate())
+	assert.NoError(t, (&FilterProductsRequest{MinPrice: price(10)}).Validate())
+	assert.NoError(t, (&FilterProductsRequest{MaxPrice: price(10)}).Validate())
+	assert.NoError(t, (&FilterProductsRequest{MinPrice: price(10), MaxPrice: price(10)}).Validate())
+	assert.ErrorIs(t, (&FilterProductsRequest{MinPrice: price(20), MaxPrice: price(10)}).Validate(), ErrInvalidPriceRange)
+}
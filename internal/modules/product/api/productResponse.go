@@ -2,6 +2,9 @@ package api
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
+	"encoding/json"
+	"strings"
 	"time"
 )
 
@@ -11,6 +14,7 @@ type ProductResponse struct {
 	Description string                `json:"description"`
 	Price       float64               `json:"price"`
 	Images      []models.ProductImage `json:"images"`
+	CategoryID  *uint                 `json:"category_id"`
 	Category    string                `json:"category"`
 	Condition   string                `json:"condition"`
 	UserID      uint                  `json:"user_id"`
@@ -18,13 +22,41 @@ type ProductResponse struct {
 	SoldAt      time.Time             `json:"sold_at"`
 	CreatedAt   time.Time             `json:"created_at"`
 	UpdatedAt   time.Time             `json:"updated_at"`
+	ViewCount   int64                 `json:"view_count"`
+	Highlight   *ProductHighlight     `json:"highlight,omitempty"` // 搜索时返回命中内容的高亮
+}
+
+// ProductHighlight 搜索命中高亮，命中词用<em>标签包裹，描述为命中位置附近的摘要
+type ProductHighlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
 }
 
 type ProductListResponse struct {
 	Products []*ProductResponse `json:"products"`
-	Total    uint               `json:"total"`
-	Page     uint               `json:"page"`
-	Size     uint               `json:"size"`
+	pagination.Info
+	Facets *ProductFacets `json:"facets,omitempty"` // 筛选列表返回的分面统计
+}
+
+// ProductFacets 筛选侧边栏的分面统计。
+// 每个维度的统计应用除该维度自身以外的全部筛选条件，便于前端展示切换选项后的结果数
+type ProductFacets struct {
+	Categories  []FacetCount      `json:"categories"`
+	Conditions  []FacetCount      `json:"conditions"`
+	PriceRanges []PriceRangeCount `json:"price_ranges"`
+}
+
+// FacetCount 某个取值下的商品数
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeCount 价格区间 [Min, Max) 内的商品数，Max为空表示不设上限
+type PriceRangeCount struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Count int64    `json:"count"`
 }
 
 // LatestProductItem 最新商品项
@@ -46,6 +78,7 @@ func ConvertToProductResponse(product *models.Product) *ProductResponse {
 		Description: product.Description,
 		Price:       product.Price,
 		Images:      product.ProductImages,
+		CategoryID:  product.CategoryID,
 		Category:    product.Category,
 		Condition:   product.Condition,
 		UserID:      product.UserID,
@@ -53,18 +86,98 @@ func ConvertToProductResponse(product *models.Product) *ProductResponse {
 		SoldAt:      product.SoldAt,
 		CreatedAt:   product.CreatedAt,
 		UpdatedAt:   product.UpdatedAt,
+		ViewCount:   product.ViewCount,
 	}
 }
 
-func ConvertToProductListResponse(products []*models.Product, total, page, size uint) *ProductListResponse {
+func ConvertToProductListResponse(products []*models.Product, info pagination.Info) *ProductListResponse {
 	var productResponses []*ProductResponse
 	for _, product := range products {
 		productResponses = append(productResponses, ConvertToProductResponse(product))
 	}
 	return &ProductListResponse{
 		Products: productResponses,
-		Total:    total,
-		Page:     page,
-		Size:     size,
+		Info:     info,
+	}
+}
+
+// ModerationResponse 商品审核记录
+type ModerationResponse struct {
+	ID         uint             `json:"id"`
+	ProductID  uint             `json:"product_id"`
+	Product    *ProductResponse `json:"product"`
+	Status     string           `json:"status"`
+	Flags      []string         `json:"flags"` // 自动预审发现的问题
+	Reason     string           `json:"reason,omitempty"`
+	ReviewerID uint             `json:"reviewer_id,omitempty"`
+	ReviewedAt *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// ModerationListResponse 审核队列
+type ModerationListResponse struct {
+	List []*ModerationResponse `json:"list"`
+	pagination.Info
+}
+
+func ConvertToModerationResponse(m *models.ProductModeration) *ModerationResponse {
+	flags := []string{}
+	if m.Flags != "" {
+		flags = strings.Split(m.Flags, "\n")
+	}
+	return &ModerationResponse{
+		ID:         m.ID,
+		ProductID:  m.ProductID,
+		Product:    ConvertToProductResponse(&m.Product),
+		Status:     m.Status,
+		Flags:      flags,
+		Reason:     m.Reason,
+		ReviewerID: m.ReviewerID,
+		ReviewedAt: m.ReviewedAt,
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+// BatchItemResult 批量操作中单个商品的处理结果
+type BatchItemResult struct {
+	ProductID uint   `json:"product_id"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BatchResultResponse 批量操作结果，每个商品单独处理，部分失败不影响其他商品
+type BatchResultResponse struct {
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// RevisionResponse 商品修改记录
+type RevisionResponse struct {
+	ID        uint                    `json:"id"`
+	ProductID uint                    `json:"product_id"`
+	EditorID  uint                    `json:"editor_id"`
+	Changes   []models.RevisionChange `json:"changes"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
+// RevisionListResponse 商品修改记录列表，最新的在前
+type RevisionListResponse struct {
+	List []*RevisionResponse `json:"list"`
+	pagination.Info
+}
+
+func ConvertToRevisionResponse(r *models.ProductRevision) *RevisionResponse {
+	changes := []models.RevisionChange{}
+	if r.Changes != "" {
+		// 记录由服务端写入，解析失败时返回空的修改列表
+		_ = json.Unmarshal([]byte(r.Changes), &changes)
+	}
+	return &RevisionResponse{
+		ID:        r.ID,
+		ProductID: r.ProductID,
+		EditorID:  r.EditorID,
+		Changes:   changes,
+		CreatedAt: r.CreatedAt,
 	}
 }
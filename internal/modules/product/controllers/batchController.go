@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductBatchController struct {
+	service services.ProductBatchService
+}
+
+func NewProductBatchController(srv services.ProductBatchService) *ProductBatchController {
+	return &ProductBatchController{
+		service: srv,
+	}
+}
+
+// BatchUpdateStatus 批量更新商品状态
+func (c *ProductBatchController) BatchUpdateStatus(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.BatchUpdateStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	result, err := c.service.UpdateStatus(adminID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "批量更新完成", result)
+}
+
+// BatchUpdateCategory 批量调整商品分类
+func (c *ProductBatchController) BatchUpdateCategory(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.BatchUpdateCategoryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	result, err := c.service.UpdateCategory(adminID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "批量更新完成", result)
+}
+
+// BatchDelete 批量删除商品
+func (c *ProductBatchController) BatchDelete(ctx *gin.Context) {
+	var req api.BatchDeleteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	result, err := c.service.Delete(&req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "批量删除完成", result)
+}
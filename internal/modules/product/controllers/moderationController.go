@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationController struct {
+	service services.ModerationService
+}
+
+func NewModerationController(srv services.ModerationService) *ModerationController {
+	return &ModerationController{
+		service: srv,
+	}
+}
+
+// ListModerationQueue 商品审核队列，默认返回待审核的商品
+func (c *ModerationController) ListModerationQueue(ctx *gin.Context) {
+	var req api.ModerationListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	result, err := c.service.ListQueue(&req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, result)
+}
+
+// ApproveProduct 审核通过商品
+func (c *ModerationController) ApproveProduct(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	productID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的商品ID", err))
+		return
+	}
+
+	if err := c.service.Approve(uint(productID), adminID.(uint)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "审核通过", nil)
+}
+
+// RejectProduct 驳回商品
+func (c *ModerationController) RejectProduct(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	productID, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的商品ID", err))
+		return
+	}
+
+	var req api.RejectProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	if err := c.service.Reject(uint(productID), adminID.(uint), req.Reason); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "已驳回", nil)
+}
@@ -13,20 +13,28 @@ type ProductController struct {
 	service services.ProductService
 }
 
-func NewProductController() *ProductController {
+func NewProductController(srv services.ProductService) *ProductController {
 	return &ProductController{
-		service: services.NewProductService(),
+		service: srv,
 	}
 }
 
+// ListProducts 商品列表，支持按价格、分类、成色等条件筛选和排序，并返回分面统计
 func (c *ProductController) ListProducts(ctx *gin.Context) {
-	var req api.GetProductsRequest
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.FilterProductsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
+	req.ViewerID = userID.(uint)
 
-	products, err := c.service.GetAllProducts(req.Page, req.Size)
+	products, err := c.service.FilterProducts(&req)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -42,7 +50,13 @@ func (c *ProductController) ListSolvingProducts(ctx *gin.Context) {
 		return
 	}
 
-	products, err := c.service.GetSolvingProducts(req.Page, req.Size)
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	products, err := c.service.GetSolvingProducts(params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -51,7 +65,26 @@ func (c *ProductController) ListSolvingProducts(ctx *gin.Context) {
 	response.Success(ctx, products)
 }
 
+// GetProductByID 查看商品详情，同一用户在去重窗口内多次查看只计一次浏览
 func (c *ProductController) GetProductByID(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id := ctx.Param("id")
+	product, err := c.service.ViewProduct(id, userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, product)
+}
+
+// AdminGetProductByID 管理员查看商品详情，不计入浏览次数
+func (c *ProductController) AdminGetProductByID(ctx *gin.Context) {
 	id := ctx.Param("id")
 	product, err := c.service.GetProductByID(id)
 	if err != nil {
@@ -63,11 +96,18 @@ func (c *ProductController) GetProductByID(ctx *gin.Context) {
 }
 
 func (c *ProductController) CreateProduct(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	var req api.CreateProductRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
+	req.UserID = userID.(uint)
 
 	product, err := c.service.CreateProduct(&req)
 	if err != nil {
@@ -78,7 +118,14 @@ func (c *ProductController) CreateProduct(ctx *gin.Context) {
 	response.SuccessWithMessage(ctx, "商品创建成功", product)
 }
 
+// UpdateProduct 卖家编辑自己发布的商品
 func (c *ProductController) UpdateProduct(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	id := ctx.Param("id")
 	var req api.UpdateProductRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -86,7 +133,7 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 		return
 	}
 
-	product, err := c.service.UpdateProduct(id, &req)
+	product, err := c.service.UpdateProduct(id, userID.(uint), &req)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -95,9 +142,51 @@ func (c *ProductController) UpdateProduct(ctx *gin.Context) {
 	response.SuccessWithMessage(ctx, "商品更新成功", product)
 }
 
+// DeleteProduct 卖家删除自己发布的商品
 func (c *ProductController) DeleteProduct(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	id := ctx.Param("id")
-	if err := c.service.DeleteProduct(id); err != nil {
+	if err := c.service.DeleteProduct(id, userID.(uint)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "商品删除成功", nil)
+}
+
+// AdminUpdateProduct 管理员编辑任意商品
+func (c *ProductController) AdminUpdateProduct(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id := ctx.Param("id")
+	var req api.UpdateProductRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	product, err := c.service.AdminUpdateProduct(id, adminID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "商品更新成功", product)
+}
+
+// AdminDeleteProduct 管理员删除任意商品
+func (c *ProductController) AdminDeleteProduct(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := c.service.AdminDeleteProduct(id); err != nil {
 		response.HandleError(ctx, err)
 		return
 	}
@@ -113,7 +202,13 @@ func (c *ProductController) SearchProductsByKeyword(ctx *gin.Context) {
 		return
 	}
 
-	products, err := c.service.SearchProductsByKeyword(keyword, req.Page, req.Size)
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	products, err := c.service.SearchProductsByKeyword(keyword, params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -122,32 +217,26 @@ func (c *ProductController) SearchProductsByKeyword(ctx *gin.Context) {
 	response.Success(ctx, products)
 }
 
-// BatchUpdateStatus 批量更新商品状态
-//func (c *ProductController) BatchUpdateStatus(ctx *gin.Context) {
-//	var req api.BatchUpdateStatusRequest
-//	if err := ctx.ShouldBindJSON(&req); err != nil {
-//		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
-//		return
-//	}
-//
-//	// 调用服务层方法
-//	err := c.service.BatchUpdateStatus(req.ProductIDs, req.Status)
-//	if err != nil {
-//		response.HandleError(ctx, err)
-//		return
-//	}
-//
-//	response.SuccessWithMessage(ctx, "批量更新成功", nil)
-//}
-
 func (c *ProductController) GetUserProducts(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	var req api.GetUserProductsRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
 
-	products, err := c.service.GetUserProducts(req.UserID, req.Page, req.Size)
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	products, err := c.service.GetUserProducts(req.UserID, userID.(uint), params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -162,6 +251,7 @@ func (c *ProductController) AdminListProducts(ctx *gin.Context) {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
+	req.AllStatuses = true
 	products, err := c.service.FilterProducts(&req)
 	if err != nil {
 		response.HandleError(ctx, err)
@@ -192,9 +282,34 @@ func (c *ProductController) GetLatestProducts(ctx *gin.Context) {
 	response.Success(ctx, productsResponse)
 }
 
+// GetHotProducts 获取热门商品
+func (c *ProductController) GetHotProducts(ctx *gin.Context) {
+	// 获取参数limit，默认为8，最多50
+	limit := uint(8)
+	if limitInt, err := strconv.Atoi(ctx.Query("limit")); err == nil && limitInt > 0 {
+		limit = uint(limitInt)
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	productsResponse, err := c.service.GetHotProducts(limit)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+	response.Success(ctx, productsResponse)
+}
+
 func (c *ProductController) UpdateProductStatus(ctx *gin.Context) {
+	adminID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
 	id := ctx.Param("id")
-	var req api.UpdateProductRequest
+	var req api.UpdateProductStatusRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		response.HandleError(ctx, errors.NewValidationError("<UNK>", err))
 		return
@@ -206,10 +321,58 @@ func (c *ProductController) UpdateProductStatus(ctx *gin.Context) {
 		return
 	}
 
-	product, err := c.service.UpdateProductStatus(uint(pid), req.Status)
+	product, err := c.service.UpdateProductStatus(uint(pid), adminID.(uint), req.Status)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
 	}
 	response.Success(ctx, product)
 }
+
+// GetProductRevisions 卖家查看自己商品的修改记录
+func (c *ProductController) GetProductRevisions(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.GetRevisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	revisions, err := c.service.GetProductRevisions(ctx.Param("id"), userID.(uint), params)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+	response.Success(ctx, revisions)
+}
+
+// AdminGetProductRevisions 管理员查看商品的修改记录
+func (c *ProductController) AdminGetProductRevisions(ctx *gin.Context) {
+	var req api.GetRevisionsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	revisions, err := c.service.AdminGetProductRevisions(ctx.Param("id"), params)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+	response.Success(ctx, revisions)
+}
@@ -0,0 +1,116 @@
+package moderation
+
+import (
+	"campus/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Screener 商品自动预审规则，返回商品命中的问题描述，没有问题时返回空
+type Screener interface {
+	Screen(product *models.Product) ([]string, error)
+}
+
+// Chain 依次执行多个预审规则并合并结果
+type Chain []Screener
+
+func (c Chain) Screen(product *models.Product) ([]string, error) {
+	var flags []string
+	for _, screener := range c {
+		found, err := screener.Screen(product)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, found...)
+	}
+	return flags, nil
+}
+
+// KeywordScreener 检查标题和描述中的违禁词，不区分大小写
+type KeywordScreener struct {
+	keywords []string
+}
+
+// NewKeywordScreener 创建违禁词预审规则，忽略空白的词
+func NewKeywordScreener(keywords []string) *KeywordScreener {
+	s := &KeywordScreener{}
+	for _, k := range keywords {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			s.keywords = append(s.keywords, k)
+		}
+	}
+	return s
+}
+
+func (s *KeywordScreener) Screen(product *models.Product) ([]string, error) {
+	text := strings.ToLower(product.Title + "\n" + product.Description)
+	var hits []string
+	for _, k := range s.keywords {
+		if strings.Contains(text, k) {
+			hits = append(hits, k)
+		}
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	return []string{"包含违禁词: " + strings.Join(hits, "、")}, nil
+}
+
+// PriceSource 提供同类在售商品的价格，用于判断价格异常
+type PriceSource interface {
+	// CategoryPrices 获取分类下在售商品的价格，excludeID为被检查的商品自身
+	CategoryPrices(category string, excludeID uint) ([]float64, error)
+}
+
+// PriceOutlierScreener 与同类在售商品的价格中位数比较，偏离超过ratio倍时视为异常
+type PriceOutlierScreener struct {
+	source     PriceSource
+	ratio      float64
+	minSamples int
+}
+
+// NewPriceOutlierScreener 创建价格异常预审规则，同类商品少于minSamples件时不做判断
+func NewPriceOutlierScreener(source PriceSource, ratio float64, minSamples int) *PriceOutlierScreener {
+	return &PriceOutlierScreener{
+		source:     source,
+		ratio:      ratio,
+		minSamples: minSamples,
+	}
+}
+
+func (s *PriceOutlierScreener) Screen(product *models.Product) ([]string, error) {
+	if product.Price <= 0 {
+		return []string{"价格必须大于0"}, nil
+	}
+	if product.Category == "" {
+		return nil, nil
+	}
+
+	prices, err := s.source.CategoryPrices(product.Category, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(prices) < s.minSamples {
+		return nil, nil
+	}
+
+	m := median(prices)
+	if m <= 0 {
+		return nil, nil
+	}
+	if product.Price > m*s.ratio || product.Price < m/s.ratio {
+		return []string{fmt.Sprintf("价格%.2f偏离同类商品中位数%.2f", product.Price, m)}, nil
+	}
+	return nil, nil
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
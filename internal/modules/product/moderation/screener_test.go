@@ -0,0 +1,60 @@
+package moderation
+
+import (
+	"campus/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePrices []float64
+
+func (f fakePrices) CategoryPrices(category string, excludeID uint) ([]float64, error) {
+	return f, nil
+}
+
+func TestKeywordScreener(t *testing.T) {
+	s := NewKeywordScreener([]string{"代考", " VPN ", ""})
+
+	flags, err := s.Screen(&models.Product{Title: "英语四级代考", Description: "附送vpn账号"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"包含违禁词: 代考、vpn"}, flags)
+
+	flags, err = s.Screen(&models.Product{Title: "高等数学教材"})
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+}
+
+func TestPriceOutlierScreener(t *testing.T) {
+	s := NewPriceOutlierScreener(fakePrices{20, 30, 40, 50, 60}, 10, 5)
+
+	flags, err := s.Screen(&models.Product{Category: "书籍", Price: 35})
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+
+	flags, err = s.Screen(&models.Product{Category: "书籍", Price: 2000})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"价格2000.00偏离同类商品中位数40.00"}, flags)
+
+	flags, err = s.Screen(&models.Product{Category: "书籍", Price: 1})
+	require.NoError(t, err)
+	assert.Len(t, flags, 1)
+
+	// 同类商品数量不足时不判断
+	s = NewPriceOutlierScreener(fakePrices{20, 30}, 10, 5)
+	flags, err = s.Screen(&models.Product{Category: "书籍", Price: 2000})
+	require.NoError(t, err)
+	assert.Empty(t, flags)
+}
+
+func TestChain(t *testing.T) {
+	chain := Chain{
+		NewKeywordScreener([]string{"代考"}),
+		NewPriceOutlierScreener(fakePrices{20, 30, 40, 50, 60}, 10, 5),
+	}
+
+	flags, err := chain.Screen(&models.Product{Title: "代考", Category: "书籍", Price: 2000})
+	require.NoError(t, err)
+	assert.Len(t, flags, 2)
+}
@@ -5,50 +5,268 @@ import (
 	"campus/internal/models"
 	"campus/internal/modules/product/api"
 	"campus/internal/utils/logger"
+	"campus/internal/utils/pagination"
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 	"time"
 )
 
 type ProductRepository interface {
-	GetAll(page, size uint) ([]*models.Product, int64, error)
+	GetAll(p pagination.Params) ([]*models.Product, pagination.Info, error)
 	GetByID(id string) (*models.Product, error)
 	Create(product *models.Product) (uint, error)
-	Update(id string, product *models.Product) error
+	// Update 修改商品信息，商品不存在或已进入交易时返回false
+	Update(productID uint, product *models.Product) (bool, error)
 	Delete(id string) error
-	SearchProductsByKeyword(keyword string, page, size uint) ([]*models.Product, int64, error)
 	GetByUserID(userID uint, page, size uint) ([]*models.Product, int64, error)
-	GetSolvingProducts(page, size uint) ([]*models.Product, int64, error)
-	BatchUpdateStatus(productIDs []uint, status string) error
+	// ListByUserID 分页获取用户发布的商品，status为空时不限状态
+	ListByUserID(userID uint, status string, p pagination.Params) ([]*models.Product, pagination.Info, error)
+	GetSolvingProducts(p pagination.Params) ([]*models.Product, pagination.Info, error)
+	// ChangeStatus 修改商品状态，同时处理该商品的待审核记录。商品不存在或已进入交易时返回false
+	ChangeStatus(productID, adminID uint, status, reason string) (bool, error)
+	// ChangeCategory 修改商品分类，商品不存在或已进入交易时返回false
+	ChangeCategory(productID uint, category *models.Category) (bool, error)
+	// DeleteWithImages 删除商品及其图片，商品不存在或已进入交易时返回false
+	DeleteWithImages(productID uint) (bool, error)
 	GetLatest(limit uint) ([]*models.Product, int64, error)
-	FilterProducts(filter *api.FilterProductsRequest) ([]*models.Product, int64, error)
+	FilterProducts(filter *api.FilterProductsRequest, p pagination.Params) ([]*models.Product, pagination.Info, error)
+	FilterProductIDs(filter *api.FilterProductsRequest, ids []uint) ([]uint, error)
+	// GetProductFacets 统计筛选结果的分类、成色和价格区间分布，ids不为nil时只统计ids中的商品
+	GetProductFacets(filter *api.FilterProductsRequest, ids []uint) (*api.ProductFacets, error)
+	// GetByIDs 按ID批量获取商品，不保证顺序
+	GetByIDs(ids []uint) ([]*models.Product, error)
+	// GetSearchDocuments 获取全部商品的标题和描述，用于构建全文索引
+	GetSearchDocuments() ([]*models.Product, error)
+	GetCategory(id uint) (*models.Category, error)
+	// FindCategoriesByName 按名称查找启用的分类，不区分大小写
+	FindCategoriesByName(name string) ([]*models.Category, error)
+	// CategoryPrices 获取分类下在售商品的价格，用于审核时判断价格异常
+	CategoryPrices(category string, excludeID uint) ([]float64, error)
+	// AddViews 累加商品浏览次数，同时记录到at所在日期的每日浏览统计
+	AddViews(counts map[uint]int64, at time.Time) error
+	// GetHotCandidates 获取since之后发布的在售商品的浏览数和收藏数，用于计算热门排行
+	GetHotCandidates(since time.Time) ([]HotCandidate, error)
+
+	// 商品修改记录
+	SaveRevision(revision *models.ProductRevision) error
+	ListRevisions(productID uint, p pagination.Params) ([]*models.ProductRevision, pagination.Info, error)
+	// SetListedPrice 记录商品在售时的价格
+	SetListedPrice(productID uint, price float64) error
+	// GetFavoriteUserIDs 获取收藏了商品的用户
+	GetFavoriteUserIDs(productID uint) ([]uint, error)
+
+	// 商品审核
+	GetPendingModeration(productID uint) (*models.ProductModeration, error)
+	SaveModeration(moderation *models.ProductModeration) error
+	ListModerations(status string, flagged *bool, p pagination.Params) ([]*models.ProductModeration, pagination.Info, error)
+	// ResolveModeration 保存审核结果并更新商品状态，商品已不在审核中时返回false
+	ResolveModeration(moderation *models.ProductModeration, productStatus string) (bool, error)
+}
+
+// HotCandidate 参与热门排行的商品
+type HotCandidate struct {
+	ID            uint
+	ViewCount     int64
+	FavoriteCount int64
+	CreatedAt     time.Time
 }
 
 type ProductRepositoryImpl struct {
 	db *gorm.DB
 }
 
-func (r *ProductRepositoryImpl) FilterProducts(filter *api.FilterProductsRequest) ([]*models.Product, int64, error) {
-	var products []*models.Product
+// 分面统计的维度，统计某一维度时不应用该维度自身的筛选条件
+type facetDimension int
+
+const (
+	facetNone facetDimension = iota
+	facetCategory
+	facetCondition
+	facetPrice
+)
+
+// priceBucketBounds 价格分面的区间边界，生成 [0,50) [50,100) ... [1000,+∞) 六个区间
+var priceBucketBounds = []float64{50, 100, 300, 500, 1000}
 
+// FilterProducts 按条件筛选商品。按最新发布排序时支持游标分页，其他排序方式只支持page/size分页
+func (r *ProductRepositoryImpl) FilterProducts(filter *api.FilterProductsRequest, p pagination.Params) ([]*models.Product, pagination.Info, error) {
+	query := applyProductFilters(r.scopeProducts(filter, nil), filter, facetNone)
+	if filter.Sort == "" || filter.Sort == api.ProductSortNewest {
+		return paginateProducts(query, p)
+	}
+
+	var products []*models.Product
 	var tot int64
+	if err := query.Count(&tot).Error; err != nil {
+		return nil, pagination.Info{}, err
+	}
 
-	// 构建查询
+	query = r.applyProductSort(query, filter.Sort)
+	query = query.Offset(p.Offset()).Limit(p.Size)
+	query = query.Preload("ProductImages").Preload("User")
+	if err := query.Find(&products).Error; err != nil {
+		return nil, pagination.Info{}, err
+	}
+	return products, pagination.Info{
+		Total:   tot,
+		Page:    p.Page,
+		Size:    p.Size,
+		HasMore: int64(p.Offset()+len(products)) < tot,
+	}, nil
+}
+
+// paginateProducts 按发布时间倒序分页查询商品，page/size模式下同时统计总数
+func paginateProducts(query *gorm.DB, p pagination.Params) ([]*models.Product, pagination.Info, error) {
+	var total int64
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
+	}
+
+	var products []*models.Product
+	err := pagination.Apply(query, p, "products").
+		Preload("ProductImages").
+		Preload("User").
+		Find(&products).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+
+	products, info := pagination.Trim(products, p, total, func(product *models.Product) pagination.Cursor {
+		return pagination.ModelCursor(product.Model)
+	})
+	return products, info, nil
+}
+
+// FilterProductIDs 从ids中筛选出满足关键词以外其他过滤条件的商品ID，用于全文检索结果的二次过滤。
+// 指定了排序方式时按该方式排序，否则不保证顺序
+func (r *ProductRepositoryImpl) FilterProductIDs(filter *api.FilterProductsRequest, ids []uint) ([]uint, error) {
+	var matched []uint
+	if len(ids) == 0 {
+		return matched, nil
+	}
+
+	query := applyProductFilters(r.scopeProducts(filter, ids), filter, facetNone)
+	if filter.Sort != "" {
+		query = r.applyProductSort(query, filter.Sort)
+	}
+	err := query.Pluck("products.id", &matched).Error
+	return matched, err
+}
+
+func (r *ProductRepositoryImpl) GetProductFacets(filter *api.FilterProductsRequest, ids []uint) (*api.ProductFacets, error) {
+	facets := &api.ProductFacets{}
+
+	err := applyProductFilters(r.scopeProducts(filter, ids), filter, facetCategory).
+		Select("products.category AS value, COUNT(*) AS count").
+		Group("products.category").
+		Order("count DESC").
+		Scan(&facets.Categories).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyProductFilters(r.scopeProducts(filter, ids), filter, facetCondition).
+		Select("products.`condition` AS value, COUNT(*) AS count").
+		Group("products.`condition`").
+		Order("count DESC").
+		Scan(&facets.Conditions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	err = applyProductFilters(r.scopeProducts(filter, ids), filter, facetPrice).
+		Select(priceBucketExpr() + " AS bucket, COUNT(*) AS count").
+		Group("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int64, len(buckets))
+	for _, b := range buckets {
+		counts[b.Bucket] = b.Count
+	}
+	for i := 0; i <= len(priceBucketBounds); i++ {
+		bucket := api.PriceRangeCount{Count: counts[i]}
+		if i > 0 {
+			bucket.Min = priceBucketBounds[i-1]
+		}
+		if i < len(priceBucketBounds) {
+			bound := priceBucketBounds[i]
+			bucket.Max = &bound
+		}
+		facets.PriceRanges = append(facets.PriceRanges, bucket)
+	}
+
+	return facets, nil
+}
+
+// priceBucketExpr 生成按 priceBucketBounds 计算价格区间序号的SQL表达式
+func priceBucketExpr() string {
+	var b strings.Builder
+	b.WriteString("CASE")
+	for i, bound := range priceBucketBounds {
+		fmt.Fprintf(&b, " WHEN products.price < %g THEN %d", bound, i)
+	}
+	fmt.Fprintf(&b, " ELSE %d END", len(priceBucketBounds))
+	return b.String()
+}
+
+// scopeProducts 确定查询范围：ids不为nil时限定为全文检索命中的商品，否则按关键词模糊匹配
+func (r *ProductRepositoryImpl) scopeProducts(filter *api.FilterProductsRequest, ids []uint) *gorm.DB {
 	query := r.db.Model(&models.Product{})
-	// 过滤条件
+	if ids != nil {
+		return query.Where("products.id IN ?", ids)
+	}
 	if filter.Keyword != "" {
-		query = query.Where("title like ? OR description like ?", "%"+filter.Keyword, "%"+filter.Keyword)
+		keyword := "%" + filter.Keyword + "%"
+		query = query.Where("products.title like ? OR products.description like ?", keyword, keyword)
 	}
+	return query
+}
 
-	if filter.Category != "" {
-		query = query.Where("category = ?", filter.Category)
+// applyProductFilters 应用关键词以外的过滤条件，skip指定的分面维度不参与过滤
+func applyProductFilters(query *gorm.DB, filter *api.FilterProductsRequest, skip facetDimension) *gorm.DB {
+	if categories := splitValues(filter.Category); len(categories) > 0 && skip != facetCategory {
+		query = query.Where("products.category IN ?", categories)
 	}
 
 	if filter.Status != "" {
-		query = query.Where("status = ?", filter.Status)
+		query = query.Where("products.status = ?", filter.Status)
+	}
+
+	// condition 是MySQL保留字，需要加引号
+	if conditions := splitValues(filter.Condition); len(conditions) > 0 && skip != facetCondition {
+		query = query.Where("products.`condition` IN ?", conditions)
+	}
+
+	if skip != facetPrice {
+		if filter.MinPrice != nil {
+			query = query.Where("products.price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			query = query.Where("products.price <= ?", *filter.MaxPrice)
+		}
 	}
 
-	if filter.Condition != "" {
-		query = query.Where("condition = ?", filter.Condition)
+	if filter.SellerID != 0 {
+		query = query.Where("products.user_id = ?", filter.SellerID)
+	}
+
+	if filter.HasImages != nil {
+		images := "SELECT 1 FROM product_images WHERE product_images.product_id = products.id AND product_images.deleted_at IS NULL"
+		if *filter.HasImages {
+			query = query.Where("EXISTS (" + images + ")")
+		} else {
+			query = query.Where("NOT EXISTS (" + images + ")")
+		}
 	}
 
 	// 日期筛选
@@ -57,7 +275,7 @@ func (r *ProductRepositoryImpl) FilterProducts(filter *api.FilterProductsRequest
 		if err != nil {
 			logger.Errorf("开始日期格式化失败 : err %v", err)
 		} else {
-			query = query.Where("created_at >= ?", date)
+			query = query.Where("products.created_at >= ?", date)
 		}
 	}
 
@@ -68,24 +286,40 @@ func (r *ProductRepositoryImpl) FilterProducts(filter *api.FilterProductsRequest
 		} else {
 			// 设置为当天最后一秒
 			date = date.Add(24*time.Hour - time.Second)
-			query = query.Where("created_at <= ?", date)
+			query = query.Where("products.created_at <= ?", date)
 		}
 	}
+	return query
+}
 
-	if err := query.Count(&tot).Error; err != nil {
-		return nil, 0, err
+// applyProductSort 按排序方式排序，默认最新发布在前；相同排序值时新发布的在前
+func (r *ProductRepositoryImpl) applyProductSort(query *gorm.DB, sort string) *gorm.DB {
+	switch sort {
+	case api.ProductSortPriceAsc:
+		query = query.Order("products.price ASC")
+	case api.ProductSortPriceDesc:
+		query = query.Order("products.price DESC")
+	case api.ProductSortFavorites:
+		favorites := r.db.Model(&models.Favorite{}).
+			Select("product_id, COUNT(*) AS favorite_count").
+			Group("product_id")
+		query = query.Joins("LEFT JOIN (?) AS fav ON fav.product_id = products.id", favorites).
+			Order("COALESCE(fav.favorite_count, 0) DESC")
 	}
+	return query.Order("products.created_at DESC").Order("products.id DESC")
+}
 
-	// 默认降序排序
-	query = query.Order("created_at DESC")
-	// 分页
-	offset := (filter.Page - 1) * filter.Size
-	query = query.Offset(int(offset)).Limit(int(filter.Size))
-	query = query.Preload("ProductImages").Preload("User")
-	if err := query.Find(&products).Error; err != nil {
-		return nil, 0, err
+// splitValues 合并多选参数，支持重复参数和逗号分隔两种写法
+func splitValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
 	}
-	return products, tot, nil
+	return result
 }
 
 func NewProductRepository() ProductRepository {
@@ -94,34 +328,13 @@ func NewProductRepository() ProductRepository {
 	}
 }
 
-func (r *ProductRepositoryImpl) GetAll(page, size uint) ([]*models.Product, int64, error) {
-	var products []*models.Product
-	var total int64
-
-	err := r.db.Model(&models.Product{}).Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	offset := (page - 1) * size
-	// 使用 Preload 预加载图片
-	err = r.db.Preload("ProductImages").Offset(int(offset)).Limit(int(size)).Find(&products).Error
-	return products, total, err
+// GetAll 分页获取在售商品，审核中、未通过和已下架的商品不公开展示
+func (r *ProductRepositoryImpl) GetAll(p pagination.Params) ([]*models.Product, pagination.Info, error) {
+	return paginateProducts(r.db.Model(&models.Product{}).Where("status = ?", models.ProductStatusOnSale), p)
 }
 
-func (r *ProductRepositoryImpl) GetSolvingProducts(page, size uint) ([]*models.Product, int64, error) {
-	var products []*models.Product
-	var total int64
-
-	err := r.db.Model(&models.Product{}).Where("status = ?", "售卖中").Count(&total).Error
-	if err != nil {
-		return nil, 0, err
-	}
-
-	offset := (page - 1) * size
-	// 使用 Preload 预加载图片
-	err = r.db.Preload("ProductImages").Where("status = ?", "售卖中").Offset(int(offset)).Limit(int(size)).Find(&products).Error
-	return products, total, err
+func (r *ProductRepositoryImpl) GetSolvingProducts(p pagination.Params) ([]*models.Product, pagination.Info, error) {
+	return paginateProducts(r.db.Model(&models.Product{}).Where("status = ?", models.ProductStatusOnSale), p)
 }
 
 func (r *ProductRepositoryImpl) GetByID(id string) (*models.Product, error) {
@@ -136,27 +349,99 @@ func (r *ProductRepositoryImpl) Create(product *models.Product) (uint, error) {
 	return product.ID, err
 }
 
-func (r *ProductRepositoryImpl) Update(id string, product *models.Product) error {
-	return r.db.Model(&models.Product{}).Where("id = ?", id).Updates(product).Error
+func (r *ProductRepositoryImpl) Update(productID uint, product *models.Product) (bool, error) {
+	result := notInTrade(r.db, productID).Updates(product)
+	return result.RowsAffected > 0, result.Error
 }
 
-func (r *ProductRepositoryImpl) Delete(id string) error {
-	return r.db.Delete(&models.Product{}, "id = ?", id).Error
+func (r *ProductRepositoryImpl) GetCategory(id uint) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, id).Error
+	return &category, err
 }
 
-func (r *ProductRepositoryImpl) SearchProductsByKeyword(keyword string, page, size uint) ([]*models.Product, int64, error) {
-	var products []*models.Product
+func (r *ProductRepositoryImpl) FindCategoriesByName(name string) ([]*models.Category, error) {
+	var categories []*models.Category
+	err := r.db.Where("LOWER(name) = LOWER(?) AND is_enabled = ?", strings.TrimSpace(name), true).
+		Order("id ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+func (r *ProductRepositoryImpl) CategoryPrices(category string, excludeID uint) ([]float64, error) {
+	var prices []float64
+	err := r.db.Model(&models.Product{}).
+		Where("category = ? AND status = ? AND id <> ?", category, models.ProductStatusOnSale, excludeID).
+		Pluck("price", &prices).Error
+	return prices, err
+}
+
+func (r *ProductRepositoryImpl) GetPendingModeration(productID uint) (*models.ProductModeration, error) {
+	var moderation models.ProductModeration
+	err := r.db.Where("product_id = ? AND status = ?", productID, models.ModerationPending).
+		Order("id DESC").
+		First(&moderation).Error
+	return &moderation, err
+}
+
+func (r *ProductRepositoryImpl) SaveModeration(moderation *models.ProductModeration) error {
+	return r.db.Omit(clause.Associations).Save(moderation).Error
+}
+
+// ListModerations 分页查询审核记录，status为空时不限，flagged不为nil时按是否被自动预审标记筛选
+func (r *ProductRepositoryImpl) ListModerations(status string, flagged *bool, p pagination.Params) ([]*models.ProductModeration, pagination.Info, error) {
+	query := r.db.Model(&models.ProductModeration{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if flagged != nil {
+		if *flagged {
+			query = query.Where("flags <> ''")
+		} else {
+			query = query.Where("flags = ''")
+		}
+	}
+
 	var total int64
-	query := "%" + keyword + "%"
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
+	}
 
-	err := r.db.Model(&models.Product{}).Where("title LIKE ?", query).Count(&total).Error
+	var moderations []*models.ProductModeration
+	err := pagination.Apply(query, p, "").
+		Preload("Product").
+		Preload("Product.ProductImages").
+		Preload("Product.User").
+		Find(&moderations).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, pagination.Info{}, err
 	}
 
-	offset := (page - 1) * size
-	err = r.db.Where("title LIKE ?", query).Offset(int(offset)).Limit(int(size)).Find(&products).Error
-	return products, total, err
+	moderations, info := pagination.Trim(moderations, p, total, func(m *models.ProductModeration) pagination.Cursor {
+		return pagination.ModelCursor(m.Model)
+	})
+	return moderations, info, nil
+}
+
+func (r *ProductRepositoryImpl) ResolveModeration(moderation *models.ProductModeration, productStatus string) (bool, error) {
+	resolved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND status = ?", moderation.ProductID, models.ProductStatusReviewing).
+			Update("status", productStatus)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		resolved = true
+		return tx.Omit(clause.Associations).Save(moderation).Error
+	})
+	return resolved, err
+}
+
+func (r *ProductRepositoryImpl) Delete(id string) error {
+	return r.db.Delete(&models.Product{}, "id = ?", id).Error
 }
 
 func (r *ProductRepositoryImpl) GetByUserID(userID uint, page, size uint) ([]*models.Product, int64, error) {
@@ -175,9 +460,68 @@ func (r *ProductRepositoryImpl) GetByUserID(userID uint, page, size uint) ([]*mo
 	return products, total, err
 }
 
-// BatchUpdateStatus 批量更新商品状态
-func (r *ProductRepositoryImpl) BatchUpdateStatus(productIDs []uint, status string) error {
-	return r.db.Model(&models.Product{}).Where("id IN ?", productIDs).Update("status", status).Error
+func (r *ProductRepositoryImpl) ListByUserID(userID uint, status string, p pagination.Params) ([]*models.Product, pagination.Info, error) {
+	query := r.db.Model(&models.Product{}).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return paginateProducts(query, p)
+}
+
+// notInTrade 限定商品不在交易中，交易中和已售出的商品由订单流程维护
+func notInTrade(tx *gorm.DB, productID uint) *gorm.DB {
+	return tx.Model(&models.Product{}).Where("id = ? AND status NOT IN ?", productID,
+		[]string{models.ProductStatusReserved, models.ProductStatusSold})
+}
+
+func (r *ProductRepositoryImpl) ChangeStatus(productID, adminID uint, status, reason string) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := notInTrade(tx, productID).Update("status", status)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		changed = true
+		if status == models.ProductStatusReviewing {
+			return nil
+		}
+
+		// 管理员直接修改状态视为处理了待审核记录：上架视为通过，其他状态视为驳回
+		moderationStatus := models.ModerationRejected
+		if status == models.ProductStatusOnSale {
+			moderationStatus = models.ModerationApproved
+		}
+		return tx.Model(&models.ProductModeration{}).
+			Where("product_id = ? AND status = ?", productID, models.ModerationPending).
+			Updates(map[string]interface{}{
+				"status":      moderationStatus,
+				"reason":      reason,
+				"reviewer_id": adminID,
+				"reviewed_at": time.Now(),
+			}).Error
+	})
+	return changed, err
+}
+
+func (r *ProductRepositoryImpl) ChangeCategory(productID uint, category *models.Category) (bool, error) {
+	result := notInTrade(r.db, productID).Updates(map[string]interface{}{
+		"category_id": category.ID,
+		"category":    category.Name,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *ProductRepositoryImpl) DeleteWithImages(productID uint) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := notInTrade(tx, productID).Delete(&models.Product{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = true
+		return tx.Where("product_id = ?", productID).Delete(&models.ProductImage{}).Error
+	})
+	return deleted, err
 }
 
 // GetLatest 获取最新商品
@@ -186,7 +530,7 @@ func (r *ProductRepositoryImpl) GetLatest(limit uint) ([]*models.Product, int64,
 	var total int64
 
 	// 统计有效商品总数（状态为"售卖中"）
-	err := r.db.Model(&models.Product{}).Where("status = ?", "售卖中").Count(&total).Error
+	err := r.db.Model(&models.Product{}).Where("status = ?", models.ProductStatusOnSale).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -194,7 +538,7 @@ func (r *ProductRepositoryImpl) GetLatest(limit uint) ([]*models.Product, int64,
 	// 获取最新有效商品
 	err = r.db.Preload("ProductImages").
 		Preload("User").
-		Where("status = ?", "售卖中").
+		Where("status = ?", models.ProductStatusOnSale).
 		Order("created_at DESC").
 		Limit(int(limit)).
 		Find(&products).Error
@@ -210,3 +554,89 @@ func (r *ProductRepositoryImpl) GetLatest(limit uint) ([]*models.Product, int64,
 
 	return products, total, err
 }
+
+func (r *ProductRepositoryImpl) AddViews(counts map[uint]int64, at time.Time) error {
+	day := at.Format("2006-01-02")
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for productID, n := range counts {
+			err := tx.Model(&models.Product{}).Where("id = ?", productID).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", n)).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "product_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("views + ?", n)}),
+			}).Create(&models.ProductDailyView{ProductID: productID, Day: day, Views: n}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *ProductRepositoryImpl) GetHotCandidates(since time.Time) ([]HotCandidate, error) {
+	favorites := r.db.Model(&models.Favorite{}).
+		Select("product_id, COUNT(*) AS favorite_count").
+		Group("product_id")
+
+	var candidates []HotCandidate
+	err := r.db.Model(&models.Product{}).
+		Select("products.id, products.view_count, COALESCE(fav.favorite_count, 0) AS favorite_count, products.created_at").
+		Joins("LEFT JOIN (?) AS fav ON fav.product_id = products.id", favorites).
+		Where("products.status = ? AND products.created_at >= ?", models.ProductStatusOnSale, since).
+		Scan(&candidates).Error
+	return candidates, err
+}
+
+func (r *ProductRepositoryImpl) SaveRevision(revision *models.ProductRevision) error {
+	return r.db.Create(revision).Error
+}
+
+func (r *ProductRepositoryImpl) ListRevisions(productID uint, p pagination.Params) ([]*models.ProductRevision, pagination.Info, error) {
+	query := r.db.Model(&models.ProductRevision{}).Where("product_id = ?", productID)
+
+	var total int64
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
+	}
+
+	var revisions []*models.ProductRevision
+	if err := pagination.Apply(query, p, "").Find(&revisions).Error; err != nil {
+		return nil, pagination.Info{}, err
+	}
+
+	revisions, info := pagination.Trim(revisions, p, total, func(r *models.ProductRevision) pagination.Cursor {
+		return pagination.ModelCursor(r.Model)
+	})
+	return revisions, info, nil
+}
+
+func (r *ProductRepositoryImpl) SetListedPrice(productID uint, price float64) error {
+	return r.db.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("listed_price", price).Error
+}
+
+func (r *ProductRepositoryImpl) GetFavoriteUserIDs(productID uint) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.Model(&models.Favorite{}).Where("product_id = ?", productID).
+		Distinct().Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *ProductRepositoryImpl) GetByIDs(ids []uint) ([]*models.Product, error) {
+	var products []*models.Product
+	if len(ids) == 0 {
+		return products, nil
+	}
+	err := r.db.Preload("ProductImages").Preload("User").Where("id IN ?", ids).Find(&products).Error
+	return products, err
+}
+
+func (r *ProductRepositoryImpl) GetSearchDocuments() ([]*models.Product, error) {
+	var products []*models.Product
+	err := r.db.Select("id", "title", "description").Find(&products).Error
+	return products, err
+}
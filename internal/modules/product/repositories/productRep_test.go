@@ -0,0 +1,275 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/api"
+	"campus/internal/utils/pagination"
+	"campus/internal/utils/testdb"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestRepository 使用内存SQLite创建商品仓库，并写入一组测试商品
+func newTestRepository(t *testing.T) *ProductRepositoryImpl {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.ProductImage{}, &models.Favorite{}, &models.ProductModeration{}, &models.ProductDailyView{})
+
+	seller := models.User{Username: "seller", Password: "x", Email: "seller@example.com"}
+	require.NoError(t, db.Create(&seller).Error)
+
+	base := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	products := []*models.Product{
+		{Title: "山地自行车", Description: "九成新", Price: 450, Category: "运动", Condition: "good"},
+		{Title: "台灯", Description: "护眼自行车灯", Price: 35, Category: "生活", Condition: "new"},
+		{Title: "高等数学", Description: "教材", Price: 20, Category: "书籍", Condition: "good"},
+		{Title: "iPad", Description: "平板电脑", Price: 1500, Category: "数码", Condition: "like_new"},
+		{Title: "篮球", Description: "比赛用球", Price: 80, Category: "运动", Condition: "fair"},
+	}
+	for i, p := range products {
+		p.UserID = seller.ID
+		p.Status = models.ProductStatusOnSale
+		p.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, db.Create(p).Error)
+	}
+	require.NoError(t, db.Create(&models.ProductImage{ProductID: products[3].ID, ImageURL: "ipad.jpg"}).Error)
+	for _, f := range []models.Favorite{
+		{UserID: seller.ID, ProductID: products[2].ID},
+		{UserID: seller.ID + 1, ProductID: products[2].ID},
+		{UserID: seller.ID, ProductID: products[0].ID},
+	} {
+		require.NoError(t, db.Create(&f).Error)
+	}
+
+	return &ProductRepositoryImpl{db: db}
+}
+
+func titles(products []*models.Product) []string {
+	var out []string
+	for _, p := range products {
+		out = append(out, p.Title)
+	}
+	return out
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+func firstPage(size int) pagination.Params {
+	return pagination.Params{Page: 1, Size: size}
+}
+
+func TestFilterProductsKeywordMatchesAnywhere(t *testing.T) {
+	repo := newTestRepository(t)
+
+	products, info, err := repo.FilterProducts(&api.FilterProductsRequest{Keyword: "自行车"}, firstPage(10))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Total)
+	assert.Equal(t, []string{"台灯", "山地自行车"}, titles(products))
+}
+
+func TestFilterProductsMultiValueAndPrice(t *testing.T) {
+	repo := newTestRepository(t)
+
+	products, info, err := repo.FilterProducts(&api.FilterProductsRequest{
+		Category: []string{"运动,书籍"},
+		MinPrice: float(30),
+		MaxPrice: float(450),
+		Sort:     api.ProductSortPriceAsc,
+	}, firstPage(10))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), info.Total)
+	assert.Equal(t, []string{"篮球", "山地自行车"}, titles(products))
+
+	hasImages := true
+	products, _, err = repo.FilterProducts(&api.FilterProductsRequest{HasImages: &hasImages}, firstPage(10))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"iPad"}, titles(products))
+}
+
+func TestFilterProductsSortByFavorites(t *testing.T) {
+	repo := newTestRepository(t)
+
+	products, info, err := repo.FilterProducts(&api.FilterProductsRequest{Sort: api.ProductSortFavorites}, firstPage(3))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Total)
+	assert.True(t, info.HasMore)
+	// 收藏数相同的按发布时间倒序
+	assert.Equal(t, []string{"高等数学", "山地自行车", "篮球"}, titles(products))
+}
+
+func TestGetProductFacets(t *testing.T) {
+	repo := newTestRepository(t)
+
+	facets, err := repo.GetProductFacets(&api.FilterProductsRequest{
+		Category:  []string{"运动"},
+		Condition: []string{"good"},
+	}, nil)
+	require.NoError(t, err)
+
+	// 分类分面忽略分类条件，只应用成色条件
+	assert.ElementsMatch(t, []api.FacetCount{{Value: "运动", Count: 1}, {Value: "书籍", Count: 1}}, facets.Categories)
+	// 成色分面忽略成色条件，只应用分类条件
+	assert.ElementsMatch(t, []api.FacetCount{{Value: "good", Count: 1}, {Value: "fair", Count: 1}}, facets.Conditions)
+
+	require.Len(t, facets.PriceRanges, len(priceBucketBounds)+1)
+	// 只有山地自行车（450）同时满足分类和成色条件
+	assert.Equal(t, 300.0, facets.PriceRanges[3].Min)
+	assert.Equal(t, int64(1), facets.PriceRanges[3].Count)
+	assert.Equal(t, int64(0), facets.PriceRanges[0].Count)
+	assert.Nil(t, facets.PriceRanges[len(priceBucketBounds)].Max)
+}
+
+func TestFilterProductIDsWithinSearchHits(t *testing.T) {
+	repo := newTestRepository(t)
+
+	ids, err := repo.FilterProductIDs(&api.FilterProductsRequest{
+		Keyword: "忽略",
+		Sort:    api.ProductSortPriceDesc,
+	}, []uint{1, 3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{4, 1, 3}, ids)
+}
+
+func TestListByUserIDCursor(t *testing.T) {
+	repo := newTestRepository(t)
+
+	p, err := pagination.NewParams("", 0, 3)
+	require.NoError(t, err)
+	products, info, err := repo.ListByUserID(1, "", p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"篮球", "iPad", "高等数学"}, titles(products))
+	assert.True(t, info.HasMore)
+
+	p, err = pagination.NewParams(info.NextCursor, 0, 3)
+	require.NoError(t, err)
+	products, info, err = repo.ListByUserID(1, "", p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"台灯", "山地自行车"}, titles(products))
+	assert.False(t, info.HasMore)
+}
+
+func TestPublicListsOnlyOnSale(t *testing.T) {
+	repo := newTestRepository(t)
+	require.NoError(t, repo.db.Model(&models.Product{}).Where("id IN ?", []uint{4, 5}).
+		Update("status", models.ProductStatusReviewing).Error)
+
+	p, err := pagination.NewParams("", 1, 10)
+	require.NoError(t, err)
+
+	products, info, err := repo.GetAll(p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"高等数学", "台灯", "山地自行车"}, titles(products))
+	assert.Equal(t, int64(3), info.Total)
+
+	products, _, err = repo.ListByUserID(1, models.ProductStatusOnSale, p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"高等数学", "台灯", "山地自行车"}, titles(products))
+
+	products, _, err = repo.ListByUserID(1, "", p)
+	require.NoError(t, err)
+	assert.Len(t, products, 5)
+}
+
+func TestModerationQueue(t *testing.T) {
+	repo := newTestRepository(t)
+	require.NoError(t, repo.db.Model(&models.Product{}).Where("id IN ?", []uint{1, 2}).
+		Update("status", models.ProductStatusReviewing).Error)
+	require.NoError(t, repo.SaveModeration(&models.ProductModeration{ProductID: 1, Status: models.ModerationPending, Flags: "包含违禁词: 代考"}))
+	require.NoError(t, repo.SaveModeration(&models.ProductModeration{ProductID: 2, Status: models.ModerationPending}))
+
+	p, err := pagination.NewParams("", 1, 10)
+	require.NoError(t, err)
+	flagged := true
+	records, info, err := repo.ListModerations(models.ModerationPending, &flagged, p)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Total)
+	require.Len(t, records, 1)
+	assert.Equal(t, "山地自行车", records[0].Product.Title)
+
+	record, err := repo.GetPendingModeration(1)
+	require.NoError(t, err)
+	record.Status = models.ModerationApproved
+	resolved, err := repo.ResolveModeration(record, models.ProductStatusOnSale)
+	require.NoError(t, err)
+	assert.True(t, resolved)
+
+	// 商品已不在审核中时不再处理
+	resolved, err = repo.ResolveModeration(record, models.ProductStatusRejected)
+	require.NoError(t, err)
+	assert.False(t, resolved)
+
+	product, err := repo.GetByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, models.ProductStatusOnSale, product.Status)
+
+	_, err = repo.GetPendingModeration(1)
+	assert.Equal(t, gorm.ErrRecordNotFound, err)
+}
+
+func TestChangeStatusResolvesPendingModeration(t *testing.T) {
+	repo := newTestRepository(t)
+	require.NoError(t, repo.db.Model(&models.Product{}).Where("id = ?", 1).
+		Update("status", models.ProductStatusReviewing).Error)
+	require.NoError(t, repo.db.Model(&models.Product{}).Where("id = ?", 2).
+		Update("status", models.ProductStatusReserved).Error)
+	require.NoError(t, repo.SaveModeration(&models.ProductModeration{ProductID: 1, Status: models.ModerationPending}))
+
+	changed, err := repo.ChangeStatus(1, 9, models.ProductStatusOffShelf, "违规")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	var moderation models.ProductModeration
+	require.NoError(t, repo.db.Where("product_id = ?", 1).First(&moderation).Error)
+	assert.Equal(t, models.ModerationRejected, moderation.Status)
+	assert.Equal(t, "违规", moderation.Reason)
+	assert.Equal(t, uint(9), moderation.ReviewerID)
+
+	// 交易中的商品不能修改
+	changed, err = repo.ChangeStatus(2, 9, models.ProductStatusOffShelf, "")
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	deleted, err := repo.DeleteWithImages(2)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.DeleteWithImages(4)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	var images int64
+	require.NoError(t, repo.db.Model(&models.ProductImage{}).Where("product_id = ?", 4).Count(&images).Error)
+	assert.Zero(t, images)
+}
+
+func TestAddViewsAndHotCandidates(t *testing.T) {
+	repo := newTestRepository(t)
+	day := time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.AddViews(map[uint]int64{1: 3, 2: 1}, day))
+	require.NoError(t, repo.AddViews(map[uint]int64{1: 2}, day.Add(time.Hour)))
+	require.NoError(t, repo.AddViews(map[uint]int64{1: 1}, day.AddDate(0, 0, 1)))
+
+	product, err := repo.GetByID("1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), product.ViewCount)
+
+	var daily []models.ProductDailyView
+	require.NoError(t, repo.db.Where("product_id = ?", 1).Order("day ASC").Find(&daily).Error)
+	require.Len(t, daily, 2)
+	assert.Equal(t, "2024-03-02", daily[0].Day)
+	assert.Equal(t, int64(5), daily[0].Views)
+
+	// 只返回since之后发布的在售商品，并带上收藏数
+	candidates, err := repo.GetHotCandidates(time.Date(2024, 3, 1, 2, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, candidates, 3)
+	byID := make(map[uint]HotCandidate)
+	for _, c := range candidates {
+		byID[c.ID] = c
+	}
+	assert.Equal(t, int64(2), byID[3].FavoriteCount)
+	assert.NotContains(t, byID, uint(1))
+}
@@ -1,14 +1,50 @@
 package product
 
 import (
+	"campus/internal/bootstrap"
 	"campus/internal/middleware"
+	messageRep "campus/internal/modules/message/repositories"
+	messageSrv "campus/internal/modules/message/services"
 	"campus/internal/modules/product/controllers"
+	"campus/internal/modules/product/moderation"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/modules/product/search"
+	"campus/internal/modules/product/services"
+	"campus/internal/modules/product/views"
+	"campus/internal/utils/logger"
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes 注册product模块的所有路由
 func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
-	productController := controllers.NewProductController()
+	// 新发布和编辑的商品先经过自动预审再进入人工审核队列，审核结果以系统消息通知卖家
+	moderationConfig := bootstrap.GetConfig().Moderation
+	screener := moderation.Chain{
+		moderation.NewKeywordScreener(moderationConfig.BannedKeywords),
+		moderation.NewPriceOutlierScreener(repositories.NewProductRepository(), moderationConfig.PriceOutlierRatio, moderationConfig.PriceMinSamples),
+	}
+	messageService := messageSrv.NewMessageService(messageRep.NewMessageRepository(bootstrap.GetDB()), bootstrap.GetMessagePublisher(), bootstrap.GetWebSocketManager(), bootstrap.GetConfig().Message.WithdrawWindow)
+	// 商品的每次修改都保存修改记录，降价后重新上架时通知收藏了该商品的用户
+	history := services.NewProductHistory(repositories.NewProductRepository(), messageService)
+	moderationService := services.NewModerationService(repositories.NewProductRepository(), screener, history, messageService)
+	moderationController := controllers.NewModerationController(moderationService)
+
+	// 商品全文索引在启动时从数据库构建，之后随商品的增删改同步更新
+	searchIndex := search.NewIndex()
+
+	// 浏览计数先在内存中累计，定期批量写入数据库，关闭应用时写入剩余的计数
+	viewConfig := bootstrap.GetConfig().Views
+	viewTracker := views.NewTracker(repositories.NewProductRepository(), viewConfig.DedupWindow)
+	viewTracker.Start(viewConfig.FlushInterval)
+	bootstrap.RegisterShutdownHook(viewTracker.Stop)
+
+	productService := services.NewProductService(searchIndex, moderationService, viewTracker, viewConfig, history)
+	if err := productService.RebuildSearchIndex(); err != nil {
+		logger.Errorf("构建商品全文索引失败: %v", err)
+	}
+	productController := controllers.NewProductController(productService)
+	batchService := services.NewProductBatchService(repositories.NewProductRepository(), searchIndex, moderationService, history, messageService)
+	batchController := controllers.NewProductBatchController(batchService)
 
 	// 商品路由 - 需要认证
 	productGroup := api.Group("/product")
@@ -20,6 +56,8 @@ func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
 	adminProductGroup.Use(middleware.JWTAuth())
 	adminProductGroup.Use(middleware.AuthorizeByRole("admin"))
 	registerAdminProductRoutes(adminProductGroup, productController)
+	registerModerationRoutes(adminProductGroup, moderationController)
+	registerBatchRoutes(adminProductGroup, batchController)
 }
 
 // registerProductRoutes 注册商品相关路由
@@ -33,6 +71,8 @@ func registerProductRoutes(router *gin.RouterGroup, controller *controllers.Prod
 	router.GET("/user", controller.GetUserProducts)
 	router.GET("/solving", controller.ListSolvingProducts)
 	router.GET("/latest", controller.GetLatestProducts)
+	router.GET("/hot", controller.GetHotProducts)
+	router.GET("/:id/revisions", controller.GetProductRevisions)
 }
 
 // registerAdminProductRoutes 注册管理员商品相关路由
@@ -41,20 +81,44 @@ func registerAdminProductRoutes(router *gin.RouterGroup, controller *controllers
 	router.GET("", middleware.AuthorizePermission("/api/v1/admin/products", "GET"), controller.AdminListProducts)
 
 	// 获取商品详情（基本功能和前台相同）
-	router.GET("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "GET"), controller.GetProductByID)
+	router.GET("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "GET"), controller.AdminGetProductByID)
+
+	// 更新商品（不校验发布者，不需要重新审核）
+	router.PUT("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "PUT"), controller.AdminUpdateProduct)
 
-	// 更新商品（基本功能和前台相同）
-	router.PUT("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "PUT"), controller.UpdateProduct)
+	// 删除商品（不校验发布者）
+	router.DELETE("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "DELETE"), controller.AdminDeleteProduct)
 
-	// 删除商品（基本功能和前台相同）
-	router.DELETE("/:id", middleware.AuthorizePermission("/api/v1/admin/products/:id", "DELETE"), controller.DeleteProduct)
+	// 商品修改记录
+	router.GET("/:id/revisions", middleware.AuthorizePermission("/api/v1/admin/products/:id/revisions", "GET"), controller.AdminGetProductRevisions)
 
 	// 更新商品状态
 	router.PUT("/:id/status", middleware.AuthorizePermission("/api/v1/admin/products/:id/status", "PUT"), controller.UpdateProductStatus)
 
-	// 批量更新商品状态
-	//router.PUT("/batch-status", middleware.AuthorizePermission("/api/v1/admin/products/batch-status", "PUT"), controller.BatchUpdateStatus)
-
 	// 最新商品（仪表盘使用的功能）
 	//router.GET("/latest", middleware.AuthorizePermission("/api/v1/admin/products/latest", "GET"), controller.GetLatestProducts)
 }
+
+// registerModerationRoutes 注册商品审核相关路由
+func registerModerationRoutes(router *gin.RouterGroup, controller *controllers.ModerationController) {
+	// 审核队列
+	router.GET("/moderation", middleware.AuthorizePermission("/api/v1/admin/products/moderation", "GET"), controller.ListModerationQueue)
+
+	// 审核通过
+	router.POST("/:id/approve", middleware.AuthorizePermission("/api/v1/admin/products/:id/approve", "POST"), controller.ApproveProduct)
+
+	// 驳回
+	router.POST("/:id/reject", middleware.AuthorizePermission("/api/v1/admin/products/:id/reject", "POST"), controller.RejectProduct)
+}
+
+// registerBatchRoutes 注册商品批量管理路由，返回每个商品的处理结果
+func registerBatchRoutes(router *gin.RouterGroup, controller *controllers.ProductBatchController) {
+	// 批量更新商品状态
+	router.PUT("/batch-status", middleware.AuthorizePermission("/api/v1/admin/products/batch-status", "PUT"), controller.BatchUpdateStatus)
+
+	// 批量调整商品分类
+	router.PUT("/batch-category", middleware.AuthorizePermission("/api/v1/admin/products/batch-category", "PUT"), controller.BatchUpdateCategory)
+
+	// 批量删除商品
+	router.POST("/batch-delete", middleware.AuthorizePermission("/api/v1/admin/products/batch-delete", "POST"), controller.BatchDelete)
+}
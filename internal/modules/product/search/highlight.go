@@ -0,0 +1,95 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// 高亮标签
+const (
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+)
+
+// Highlight 用<em>标签标记text中命中terms的片段，其余内容做HTML转义
+func Highlight(text string, terms []string) string {
+	runes := []rune(text)
+	return highlightRange(runes, matchRanges(runes, terms), 0, len(runes))
+}
+
+// Snippet 截取text中第一处命中附近约width个字符并高亮，未命中时返回空串
+func Snippet(text string, terms []string, width int) string {
+	runes := []rune(text)
+	ranges := matchRanges(runes, terms)
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	// 命中位置前保留约四分之一的上下文
+	start := ranges[0][0] - width/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + width
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	snippet := highlightRange(runes, ranges, start, end)
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(runes) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+// matchRanges 返回命中terms的rune区间，按起点排序并合并重叠部分
+func matchRanges(runes []rune, terms []string) [][2]int {
+	termSet := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		termSet[t] = true
+	}
+
+	var ranges [][2]int
+	for _, t := range Tokenize(string(runes)) {
+		if !termSet[t.Term] {
+			continue
+		}
+		// 分词结果按起点有序，只需与最后一个区间合并
+		if n := len(ranges); n > 0 && t.Start <= ranges[n-1][1] {
+			if t.End > ranges[n-1][1] {
+				ranges[n-1][1] = t.End
+			}
+			continue
+		}
+		ranges = append(ranges, [2]int{t.Start, t.End})
+	}
+	return ranges
+}
+
+// highlightRange 输出runes[start:end]，其中落在ranges内的部分加高亮标签
+func highlightRange(runes []rune, ranges [][2]int, start, end int) string {
+	var b strings.Builder
+	pos := start
+	for _, r := range ranges {
+		s, e := r[0], r[1]
+		if e <= start || s >= end {
+			continue
+		}
+		if s < start {
+			s = start
+		}
+		if e > end {
+			e = end
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:s])))
+		b.WriteString(highlightOpen)
+		b.WriteString(html.EscapeString(string(runes[s:e])))
+		b.WriteString(highlightClose)
+		pos = e
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	return b.String()
+}
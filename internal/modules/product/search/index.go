@@ -0,0 +1,316 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+// Document 被索引的商品字段
+type Document struct {
+	ID          uint
+	Title       string
+	Description string
+}
+
+// Hit 一条搜索结果，Terms 为命中的索引词，用于高亮
+type Hit struct {
+	ID    uint
+	Score float64
+	Terms []string
+}
+
+// ProductSearcher 商品全文检索接口
+type ProductSearcher interface {
+	// Index 添加或更新商品索引
+	Index(doc Document)
+	// Remove 删除商品索引
+	Remove(id uint)
+	// Rebuild 使用docs替换全部索引
+	Rebuild(docs []Document)
+	// Search 返回按相关度降序排列的全部命中结果
+	Search(keyword string) []Hit
+}
+
+// 字段权重：标题命中比描述命中更相关
+const (
+	titleWeight       = 3.0
+	descriptionWeight = 1.0
+)
+
+// BM25参数
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// 纠错匹配的得分折扣
+const fuzzyPenalty = 0.5
+
+// posting 一个词在一个商品中的词频
+type posting struct {
+	titleTF       int
+	descriptionTF int
+}
+
+type indexedDoc struct {
+	titleLen       int
+	descriptionLen int
+	terms          []string
+}
+
+// Index 进程内倒排索引，并发安全
+type Index struct {
+	mu       sync.RWMutex
+	docs     map[uint]*indexedDoc
+	postings map[string]map[uint]*posting
+
+	totalTitleLen       int
+	totalDescriptionLen int
+}
+
+// NewIndex 创建空的倒排索引
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[uint]*indexedDoc),
+		postings: make(map[string]map[uint]*posting),
+	}
+}
+
+func (idx *Index) Index(doc Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.ID)
+	idx.add(doc)
+}
+
+func (idx *Index) Remove(id uint) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) Rebuild(docs []Document) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.docs = make(map[uint]*indexedDoc, len(docs))
+	idx.postings = make(map[string]map[uint]*posting)
+	idx.totalTitleLen = 0
+	idx.totalDescriptionLen = 0
+	for _, doc := range docs {
+		idx.add(doc)
+	}
+}
+
+// Search 检索关键词。每个检索词先精确匹配，英文词在索引中不存在时按编辑距离纠错匹配；
+// 检索词多于一个时，只返回至少命中一半检索词的商品，得分按命中比例加权
+func (idx *Index) Search(keyword string) []Hit {
+	queryTerms := QueryTerms(keyword)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if len(idx.docs) == 0 {
+		return nil
+	}
+
+	scores := make(map[uint]float64)
+	matched := make(map[uint]map[string]bool) // 商品命中的检索词
+	hitTerms := make(map[uint][]string)       // 商品命中的索引词
+	for _, qt := range queryTerms {
+		for term, weight := range idx.expand(qt) {
+			for id, p := range idx.postings[term] {
+				scores[id] += weight * idx.score(term, id, p)
+				if matched[id] == nil {
+					matched[id] = make(map[string]bool)
+				}
+				matched[id][qt] = true
+				hitTerms[id] = append(hitTerms[id], term)
+			}
+		}
+	}
+
+	minMatch := (len(queryTerms) + 1) / 2
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		n := len(matched[id])
+		if n < minMatch {
+			continue
+		}
+		coverage := float64(n) / float64(len(queryTerms))
+		hits = append(hits, Hit{
+			ID:    id,
+			Score: score * coverage * coverage,
+			Terms: hitTerms[id],
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		// 相关度相同时新发布的商品在前
+		return hits[i].ID > hits[j].ID
+	})
+	return hits
+}
+
+// expand 返回检索词对应的索引词及权重，精确匹配权重为1
+func (idx *Index) expand(queryTerm string) map[string]float64 {
+	if _, ok := idx.postings[queryTerm]; ok {
+		return map[string]float64{queryTerm: 1}
+	}
+
+	maxDistance := fuzzyDistance(queryTerm)
+	if maxDistance == 0 {
+		return nil
+	}
+
+	terms := make(map[string]float64)
+	for term := range idx.postings {
+		if d := editDistance(queryTerm, term, maxDistance); d <= maxDistance {
+			terms[term] = fuzzyPenalty / float64(d)
+		}
+	}
+	return terms
+}
+
+// score 按BM25计算索引词在商品标题和描述中的加权得分
+func (idx *Index) score(term string, id uint, p *posting) float64 {
+	n := float64(len(idx.docs))
+	df := float64(len(idx.postings[term]))
+	idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+	doc := idx.docs[id]
+	avgTitle := float64(idx.totalTitleLen) / n
+	avgDescription := float64(idx.totalDescriptionLen) / n
+
+	return idf * (titleWeight*bm25(p.titleTF, doc.titleLen, avgTitle) +
+		descriptionWeight*bm25(p.descriptionTF, doc.descriptionLen, avgDescription))
+}
+
+func bm25(tf, length int, avgLength float64) float64 {
+	if tf == 0 {
+		return 0
+	}
+	norm := 1.0
+	if avgLength > 0 {
+		norm = 1 - bm25B + bm25B*float64(length)/avgLength
+	}
+	f := float64(tf)
+	return f * (bm25K1 + 1) / (f + bm25K1*norm)
+}
+
+func (idx *Index) add(doc Document) {
+	titleTokens := Tokenize(doc.Title)
+	descriptionTokens := Tokenize(doc.Description)
+
+	entry := &indexedDoc{
+		titleLen:       len(titleTokens),
+		descriptionLen: len(descriptionTokens),
+	}
+	postings := make(map[string]*posting)
+	for _, t := range titleTokens {
+		if postings[t.Term] == nil {
+			postings[t.Term] = &posting{}
+		}
+		postings[t.Term].titleTF++
+	}
+	for _, t := range descriptionTokens {
+		if postings[t.Term] == nil {
+			postings[t.Term] = &posting{}
+		}
+		postings[t.Term].descriptionTF++
+	}
+
+	for term, p := range postings {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[uint]*posting)
+		}
+		idx.postings[term][doc.ID] = p
+		entry.terms = append(entry.terms, term)
+	}
+
+	idx.docs[doc.ID] = entry
+	idx.totalTitleLen += entry.titleLen
+	idx.totalDescriptionLen += entry.descriptionLen
+}
+
+func (idx *Index) remove(id uint) {
+	entry, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range entry.terms {
+		delete(idx.postings[term], id)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalTitleLen -= entry.titleLen
+	idx.totalDescriptionLen -= entry.descriptionLen
+	delete(idx.docs, id)
+}
+
+// fuzzyDistance 返回检索词允许的最大编辑距离：中文和短词不纠错，4-7个字符允许1处错误，更长的允许2处
+func fuzzyDistance(term string) int {
+	r, _ := utf8.DecodeRuneInString(term)
+	if isCJK(r) {
+		return 0
+	}
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance 计算a、b之间的Damerau-Levenshtein距离（相邻换位算一次编辑），超过limit时提前返回limit+1
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,125 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestIndex() *Index {
+	idx := NewIndex()
+	idx.Rebuild([]Document{
+		{ID: 1, Title: "九成新自行车", Description: "捷安特山地车，通勤代步"},
+		{ID: 2, Title: "行车记录仪", Description: "全新未拆封"},
+		{ID: 3, Title: "高等数学教材", Description: "附带笔记，适合自行车队的同学"},
+		{ID: 4, Title: "iPhone 13 手机", Description: "Apple iPhone, 128GB"},
+		{ID: 5, Title: "二手台灯", Description: "护眼LED台灯"},
+	})
+	return idx
+}
+
+func hitIDs(hits []Hit) []uint {
+	ids := make([]uint, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	terms := func(text string) []string {
+		var out []string
+		for _, tok := range Tokenize(text) {
+			out = append(out, tok.Term)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"iphone", "13"}, terms("iPhone-13"))
+	assert.Equal(t, []string{"自", "自行", "行", "行车", "车"}, terms("自行车"))
+	assert.Equal(t, []string{"二", "二手", "手", "ipad"}, terms("二手iPad"))
+
+	tokens := Tokenize("二手iPad")
+	assert.Equal(t, 2, tokens[3].Start)
+	assert.Equal(t, 6, tokens[3].End)
+}
+
+func TestQueryTerms(t *testing.T) {
+	assert.Equal(t, []string{"自行", "行车"}, QueryTerms("自行车"))
+	assert.Equal(t, []string{"车"}, QueryTerms("车"))
+	assert.Equal(t, []string{"二手", "iphone"}, QueryTerms("二手 iPhone iphone"))
+	assert.Empty(t, QueryTerms("  ,. "))
+}
+
+func TestSearchRanksTitleAboveDescription(t *testing.T) {
+	hits := newTestIndex().Search("自行车")
+
+	// 标题命中排在描述命中之前；只命中“行车”的商品得分按命中比例降低
+	assert.Equal(t, uint(1), hits[0].ID)
+	assert.Equal(t, uint(2), hits[len(hits)-1].ID)
+	assert.Contains(t, hitIDs(hits), uint(3))
+	assert.Less(t, indexOf(hitIDs(hits), 1), indexOf(hitIDs(hits), 3))
+}
+
+func TestSearchMinimumMatch(t *testing.T) {
+	hits := newTestIndex().Search("二手自行车")
+
+	// 检索词为 二手、手自、自行、行车，至少需要命中两个
+	assert.ElementsMatch(t, []uint{1, 3}, hitIDs(hits))
+}
+
+func TestSearchTypoTolerance(t *testing.T) {
+	idx := newTestIndex()
+
+	assert.Equal(t, []uint{4}, hitIDs(idx.Search("iphnoe")))
+	assert.Equal(t, []uint{4}, hitIDs(idx.Search("IPHONE")))
+	assert.Equal(t, []uint{5}, hitIDs(idx.Search("leds")))
+	// 三个字符以内的短词不纠错
+	assert.Empty(t, idx.Search("lde"))
+}
+
+func TestIndexUpdateAndRemove(t *testing.T) {
+	idx := newTestIndex()
+
+	idx.Index(Document{ID: 5, Title: "宿舍小风扇"})
+	assert.Empty(t, idx.Search("台灯"))
+	assert.Equal(t, []uint{5}, hitIDs(idx.Search("风扇")))
+
+	idx.Remove(5)
+	assert.Empty(t, idx.Search("风扇"))
+	assert.Empty(t, idx.postings["风扇"])
+}
+
+func TestHighlight(t *testing.T) {
+	hits := newTestIndex().Search("自行车")
+	assert.Equal(t, "九成新<em>自行车</em>", Highlight("九成新自行车", hits[0].Terms))
+
+	assert.Equal(t, "Apple <em>iPhone</em>, 128GB &lt;b&gt;",
+		Highlight("Apple iPhone, 128GB <b>", []string{"iphone"}))
+	assert.Equal(t, "没有命中", Highlight("没有命中", []string{"iphone"}))
+}
+
+func TestSnippet(t *testing.T) {
+	text := "这是一段很长的商品描述，前面都是无关内容，最后提到了自行车和头盔"
+
+	snippet := Snippet(text, []string{"自行", "行车"}, 12)
+	assert.Equal(t, "...提到了<em>自行车</em>和头盔", snippet)
+
+	assert.Equal(t, "", Snippet(text, []string{"iphone"}, 12))
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("iphone", "iphone", 2))
+	assert.Equal(t, 1, editDistance("iphnoe", "iphone", 2))
+	assert.Equal(t, 1, editDistance("iphon", "iphone", 2))
+	assert.Equal(t, 3, editDistance("abc", "xyzabc", 2))
+}
+
+func indexOf(ids []uint, id uint) int {
+	for i, v := range ids {
+		if v == id {
+			return i
+		}
+	}
+	return -1
+}
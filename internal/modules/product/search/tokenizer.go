@@ -0,0 +1,94 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token 分词结果，Start/End 为词在原文中的rune下标区间 [Start, End)
+type Token struct {
+	Term  string
+	Start int
+	End   int
+	cjk   bool
+}
+
+// Tokenize 对中英文混合文本分词：
+// 英文和数字按连续字母数字切分并转为小写；
+// 中日韩文字同时切出单字和相邻两字（bigram），单字用于单字查询，bigram用于提高多字查询的准确度
+func Tokenize(text string) []Token {
+	runes := []rune(text)
+	var tokens []Token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case isCJK(r):
+			start := i
+			for i < len(runes) && isCJK(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, cjkTokens(runes, start, i)...)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && !isCJK(runes[i]) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, Token{
+				Term:  strings.ToLower(string(runes[start:i])),
+				Start: start,
+				End:   i,
+			})
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// QueryTerms 将查询关键词切分为检索词并去重：
+// 连续两个及以上汉字只使用bigram，单个汉字使用单字；英文和数字按词切分
+func QueryTerms(keyword string) []string {
+	tokens := Tokenize(keyword)
+
+	// 被bigram覆盖的中文单字不再单独检索，避免召回只包含其中一个字的结果
+	covered := make(map[int]bool)
+	for _, t := range tokens {
+		if t.cjk && t.End-t.Start == 2 {
+			covered[t.Start] = true
+			covered[t.Start+1] = true
+		}
+	}
+
+	var terms []string
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		if t.cjk && t.End-t.Start == 1 && covered[t.Start] {
+			continue
+		}
+		if !seen[t.Term] {
+			seen[t.Term] = true
+			terms = append(terms, t.Term)
+		}
+	}
+	return terms
+}
+
+// cjkTokens 为 runes[start:end] 的中文片段生成单字和bigram
+func cjkTokens(runes []rune, start, end int) []Token {
+	tokens := make([]Token, 0, 2*(end-start))
+	for i := start; i < end; i++ {
+		tokens = append(tokens, Token{Term: string(runes[i]), Start: i, End: i + 1, cjk: true})
+		if i+1 < end {
+			tokens = append(tokens, Token{Term: string(runes[i : i+2]), Start: i, End: i + 2, cjk: true})
+		}
+	}
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
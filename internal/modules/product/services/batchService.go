@@ -0,0 +1,180 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/modules/product/search"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"fmt"
+	"strconv"
+
+	msgapi "campus/internal/modules/message/api"
+	"gorm.io/gorm"
+)
+
+// ProductBatchService 管理员批量管理商品。每个商品在各自的事务中处理，单个商品失败不影响其他商品。
+// 全文索引只包含标题和描述，修改状态和分类不需要更新索引
+type ProductBatchService interface {
+	UpdateStatus(adminID uint, req *api.BatchUpdateStatusRequest) (*api.BatchResultResponse, error)
+	UpdateCategory(adminID uint, req *api.BatchUpdateCategoryRequest) (*api.BatchResultResponse, error)
+	Delete(req *api.BatchDeleteRequest) (*api.BatchResultResponse, error)
+}
+
+type ProductBatchServiceImpl struct {
+	productRep repositories.ProductRepository
+	searcher   search.ProductSearcher
+	moderation ModerationService
+	history    ProductHistory
+	notifier   UserNotifier
+}
+
+func NewProductBatchService(productRep repositories.ProductRepository, searcher search.ProductSearcher, moderation ModerationService, history ProductHistory, notifier UserNotifier) ProductBatchService {
+	return &ProductBatchServiceImpl{
+		productRep: productRep,
+		searcher:   searcher,
+		moderation: moderation,
+		history:    history,
+		notifier:   notifier,
+	}
+}
+
+// batchAction 处理单个商品，返回的错误记录到该商品的处理结果中
+type batchAction func(product *models.Product) error
+
+func (s *ProductBatchServiceImpl) UpdateStatus(adminID uint, req *api.BatchUpdateStatusRequest) (*api.BatchResultResponse, error) {
+	return s.run(req.ProductIDs, func(product *models.Product) error {
+		if err := checkProductNotInTrade(product); err != nil {
+			return err
+		}
+		if product.Status == req.Status {
+			return nil
+		}
+		changed, err := s.productRep.ChangeStatus(product.ID, adminID, req.Status, req.Reason)
+		if err != nil {
+			return errors.NewInternalServerError("更新商品状态失败", err)
+		}
+		if !changed {
+			return errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+		}
+
+		before := *product
+		product.Status = req.Status
+		s.history.Record(&before, product, adminID)
+		if req.Status == models.ProductStatusReviewing {
+			if err := s.moderation.Submit(product); err != nil {
+				logger.Errorf("商品%d提交审核失败: %v", product.ID, err)
+			}
+		}
+		s.notify(product, req.BatchNotice, fmt.Sprintf("您发布的商品《%s》已被管理员修改为%s", product.Title, req.Status))
+		return nil
+	})
+}
+
+func (s *ProductBatchServiceImpl) UpdateCategory(adminID uint, req *api.BatchUpdateCategoryRequest) (*api.BatchResultResponse, error) {
+	category, err := s.productRep.GetCategory(req.CategoryID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewBadRequestError("商品分类不存在", err)
+		}
+		return nil, errors.NewInternalServerError("查询商品分类失败", err)
+	}
+	if err := checkCategoryEnabled(s.productRep, category); err != nil {
+		return nil, err
+	}
+
+	return s.run(req.ProductIDs, func(product *models.Product) error {
+		if err := checkProductNotInTrade(product); err != nil {
+			return err
+		}
+		if product.CategoryID != nil && *product.CategoryID == category.ID {
+			return nil
+		}
+		changed, err := s.productRep.ChangeCategory(product.ID, category)
+		if err != nil {
+			return errors.NewInternalServerError("更新商品分类失败", err)
+		}
+		if !changed {
+			return errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+		}
+
+		before := *product
+		product.CategoryID = &category.ID
+		product.Category = category.Name
+		s.history.Record(&before, product, adminID)
+		s.notify(product, req.BatchNotice, fmt.Sprintf("您发布的商品《%s》的分类已被管理员调整为%s", product.Title, category.Name))
+		return nil
+	})
+}
+
+func (s *ProductBatchServiceImpl) Delete(req *api.BatchDeleteRequest) (*api.BatchResultResponse, error) {
+	return s.run(req.ProductIDs, func(product *models.Product) error {
+		if err := checkProductNotInTrade(product); err != nil {
+			return err
+		}
+		deleted, err := s.productRep.DeleteWithImages(product.ID)
+		if err != nil {
+			return errors.NewInternalServerError("删除商品失败", err)
+		}
+		if !deleted {
+			return errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+		}
+
+		s.searcher.Remove(product.ID)
+		s.notify(product, req.BatchNotice, fmt.Sprintf("您发布的商品《%s》已被管理员删除", product.Title))
+		return nil
+	})
+}
+
+// run 依次处理每个商品，重复的ID只处理一次
+func (s *ProductBatchServiceImpl) run(productIDs []uint, action batchAction) (*api.BatchResultResponse, error) {
+	resp := &api.BatchResultResponse{Results: make([]api.BatchItemResult, 0, len(productIDs))}
+	seen := make(map[uint]bool, len(productIDs))
+	for _, id := range productIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		result := api.BatchItemResult{ProductID: id, Success: true}
+		if err := s.apply(id, action); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+func (s *ProductBatchServiceImpl) apply(id uint, action batchAction) error {
+	product, err := s.productRep.GetByID(strconv.FormatUint(uint64(id), 10))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("商品", err)
+		}
+		return errors.NewInternalServerError("查询商品失败", err)
+	}
+	return action(product)
+}
+
+// notify 按请求选项通知卖家，通知失败不影响操作结果
+func (s *ProductBatchServiceImpl) notify(product *models.Product, notice api.BatchNotice, content string) {
+	if !notice.Notify {
+		return
+	}
+	if notice.Reason != "" {
+		content += "，原因：" + notice.Reason
+	}
+	err := s.notifier.SendSystemMessage(&msgapi.AdminSendSystemMessageRequest{
+		ReceiverID: product.UserID,
+		Title:      "商品管理通知",
+		Content:    content + "。",
+	})
+	if err != nil {
+		logger.Errorf("发送商品%d管理通知失败: %v", product.ID, err)
+	}
+}
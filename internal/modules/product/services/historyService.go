@@ -0,0 +1,141 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/pagination"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	msgapi "campus/internal/modules/message/api"
+)
+
+// ProductHistory 记录商品的修改历史，并在商品降价后上架时通知收藏了该商品的用户
+type ProductHistory interface {
+	// Record 比较修改前后的商品并保存修改记录，after应为修改后从数据库重新读取的商品。
+	// 修改已经生效，记录失败只写日志
+	Record(before, after *models.Product, editorID uint)
+	List(productID uint, p pagination.Params) (*api.RevisionListResponse, error)
+}
+
+type ProductHistoryImpl struct {
+	productRep repositories.ProductRepository
+	notifier   UserNotifier
+}
+
+func NewProductHistory(productRep repositories.ProductRepository, notifier UserNotifier) ProductHistory {
+	return &ProductHistoryImpl{
+		productRep: productRep,
+		notifier:   notifier,
+	}
+}
+
+func (h *ProductHistoryImpl) Record(before, after *models.Product, editorID uint) {
+	if changes := diffProduct(before, after); len(changes) > 0 {
+		data, err := json.Marshal(changes)
+		if err != nil {
+			logger.Errorf("序列化商品%d修改记录失败: %v", after.ID, err)
+		} else if err := h.productRep.SaveRevision(&models.ProductRevision{
+			ProductID: after.ID,
+			EditorID:  editorID,
+			Changes:   string(data),
+		}); err != nil {
+			logger.Errorf("保存商品%d修改记录失败: %v", after.ID, err)
+		}
+	}
+
+	if after.Status == models.ProductStatusOnSale {
+		h.checkPriceDrop(after)
+	}
+}
+
+// checkPriceDrop 与上次在售时的价格比较，降价时通知收藏了该商品的用户。
+// 卖家编辑后商品需要重新审核，因此在商品重新上架时才判断降价
+func (h *ProductHistoryImpl) checkPriceDrop(product *models.Product) {
+	if product.ListedPrice == product.Price {
+		return
+	}
+	if err := h.productRep.SetListedPrice(product.ID, product.Price); err != nil {
+		logger.Errorf("记录商品%d在售价格失败: %v", product.ID, err)
+		return
+	}
+	if product.ListedPrice <= 0 || product.Price >= product.ListedPrice {
+		return
+	}
+
+	userIDs, err := h.productRep.GetFavoriteUserIDs(product.ID)
+	if err != nil {
+		logger.Errorf("查询商品%d的收藏用户失败: %v", product.ID, err)
+		return
+	}
+	content := fmt.Sprintf("您收藏的商品《%s》降价了，价格从%.2f元降至%.2f元。", product.Title, product.ListedPrice, product.Price)
+	for _, userID := range userIDs {
+		if userID == product.UserID {
+			continue
+		}
+		err := h.notifier.SendSystemMessage(&msgapi.AdminSendSystemMessageRequest{
+			ReceiverID: userID,
+			Title:      "降价提醒",
+			Content:    content,
+		})
+		if err != nil {
+			logger.Errorf("发送商品%d降价提醒失败: %v", product.ID, err)
+		}
+	}
+}
+
+func (h *ProductHistoryImpl) List(productID uint, p pagination.Params) (*api.RevisionListResponse, error) {
+	revisions, info, err := h.productRep.ListRevisions(productID, p)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取商品修改记录失败", err)
+	}
+
+	resp := &api.RevisionListResponse{
+		List: make([]*api.RevisionResponse, 0, len(revisions)),
+		Info: info,
+	}
+	for _, revision := range revisions {
+		resp.List = append(resp.List, api.ConvertToRevisionResponse(revision))
+	}
+	return resp, nil
+}
+
+// diffProduct 比较标题、描述、价格、图片、分类和状态的变化
+func diffProduct(before, after *models.Product) []models.RevisionChange {
+	var changes []models.RevisionChange
+	add := func(field string, from, to interface{}) {
+		changes = append(changes, models.RevisionChange{Field: field, Old: from, New: to})
+	}
+
+	if before.Title != after.Title {
+		add("title", before.Title, after.Title)
+	}
+	if before.Description != after.Description {
+		add("description", before.Description, after.Description)
+	}
+	if before.Price != after.Price {
+		add("price", before.Price, after.Price)
+	}
+	if oldImages, newImages := imageURLs(before), imageURLs(after); !slices.Equal(oldImages, newImages) {
+		add("images", oldImages, newImages)
+	}
+	if before.Category != after.Category {
+		add("category", before.Category, after.Category)
+	}
+	if before.Status != after.Status {
+		add("status", before.Status, after.Status)
+	}
+	return changes
+}
+
+func imageURLs(product *models.Product) []string {
+	urls := make([]string, 0, len(product.ProductImages))
+	for _, image := range product.ProductImages {
+		urls = append(urls, image.ImageURL)
+	}
+	return urls
+}
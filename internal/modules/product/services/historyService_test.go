@@ -0,0 +1,100 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/repositories"
+	"encoding/json"
+	"testing"
+
+	msgapi "campus/internal/modules/message/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyRepo 只实现修改记录用到的仓库方法
+type historyRepo struct {
+	repositories.ProductRepository
+	revisions   []*models.ProductRevision
+	listedPrice map[uint]float64
+	favorites   []uint
+}
+
+func (r *historyRepo) SaveRevision(revision *models.ProductRevision) error {
+	r.revisions = append(r.revisions, revision)
+	return nil
+}
+
+func (r *historyRepo) SetListedPrice(productID uint, price float64) error {
+	r.listedPrice[productID] = price
+	return nil
+}
+
+func (r *historyRepo) GetFavoriteUserIDs(productID uint) ([]uint, error) {
+	return r.favorites, nil
+}
+
+type recordingNotifier struct {
+	sent []*msgapi.AdminSendSystemMessageRequest
+}
+
+func (n *recordingNotifier) SendSystemMessage(req *msgapi.AdminSendSystemMessageRequest) error {
+	n.sent = append(n.sent, req)
+	return nil
+}
+
+func TestRecordStoresChangedFields(t *testing.T) {
+	repo := &historyRepo{listedPrice: map[uint]float64{}}
+	history := NewProductHistory(repo, &recordingNotifier{})
+
+	before := &models.Product{Title: "台灯", Price: 50, Status: models.ProductStatusOnSale,
+		ProductImages: []models.ProductImage{{ImageURL: "a.jpg"}}}
+	before.ID = 1
+	after := *before
+	after.Price = 40
+	after.Status = models.ProductStatusReviewing
+	after.ProductImages = []models.ProductImage{{ImageURL: "b.jpg"}}
+
+	history.Record(before, &after, 7)
+	require.Len(t, repo.revisions, 1)
+	assert.Equal(t, uint(7), repo.revisions[0].EditorID)
+
+	var changes []models.RevisionChange
+	require.NoError(t, json.Unmarshal([]byte(repo.revisions[0].Changes), &changes))
+	var fields []string
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	assert.Equal(t, []string{"price", "images", "status"}, fields)
+
+	// 没有变化时不保存记录
+	history.Record(&after, &after, 7)
+	assert.Len(t, repo.revisions, 1)
+}
+
+func TestPriceDropNotifiesFavoritesWhenBackOnSale(t *testing.T) {
+	repo := &historyRepo{listedPrice: map[uint]float64{}, favorites: []uint{2, 3, 5}}
+	notifier := &recordingNotifier{}
+	history := NewProductHistory(repo, notifier)
+
+	// 审核中的商品不判断降价
+	product := &models.Product{Title: "台灯", Price: 40, ListedPrice: 50, UserID: 5, Status: models.ProductStatusReviewing}
+	product.ID = 1
+	history.Record(product, product, 5)
+	assert.Empty(t, notifier.sent)
+
+	// 审核通过重新上架时通知收藏的用户，不通知卖家自己
+	approved := *product
+	approved.Status = models.ProductStatusOnSale
+	history.Record(product, &approved, 9)
+	require.Len(t, notifier.sent, 2)
+	assert.Equal(t, uint(2), notifier.sent[0].ReceiverID)
+	assert.Equal(t, 40.0, repo.listedPrice[1])
+
+	// 首次上架和涨价只记录价格
+	notifier.sent = nil
+	first := &models.Product{Price: 60, Status: models.ProductStatusOnSale}
+	first.ID = 2
+	history.Record(first, first, 9)
+	assert.Empty(t, notifier.sent)
+	assert.Equal(t, 60.0, repo.listedPrice[2])
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"campus/internal/bootstrap"
+	"campus/internal/config"
+	"campus/internal/models"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/testdb"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	// 未配置输出时日志被丢弃
+	logger.Init(&config.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+// 测试数据中的卖家和其他用户
+const (
+	testSellerID uint = 1
+	testOtherID  uint = 2
+	testAdminID  uint = 99
+)
+
+// newTestProductRepository 使用内存SQLite创建商品仓库，并写入卖家和一件指定状态的商品
+func newTestProductRepository(t *testing.T, status string) (repositories.ProductRepository, *gorm.DB, *models.Product) {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.ProductImage{}, &models.Favorite{},
+		&models.ProductModeration{}, &models.ProductRevision{})
+	bootstrap.SetDB(db)
+
+	for _, user := range []models.User{
+		{Model: gorm.Model{ID: testSellerID}, Username: "seller", Password: "x", Email: "seller@example.com"},
+		{Model: gorm.Model{ID: testOtherID}, Username: "other", Password: "x", Email: "other@example.com"},
+	} {
+		require.NoError(t, db.Create(&user).Error)
+	}
+	product := models.Product{Title: "台灯", Description: "护眼台灯", Price: 35, Category: "生活",
+		UserID: testSellerID, Status: status}
+	require.NoError(t, db.Create(&product).Error)
+
+	return repositories.NewProductRepository(), db, &product
+}
+
+// reloadProduct 从数据库重新读取商品
+func reloadProduct(t *testing.T, db *gorm.DB, id uint) *models.Product {
+	var product models.Product
+	require.NoError(t, db.First(&product, id).Error)
+	return &product
+}
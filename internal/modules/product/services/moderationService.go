@@ -0,0 +1,192 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/moderation"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"fmt"
+	"strings"
+	"time"
+
+	msgapi "campus/internal/modules/message/api"
+	"gorm.io/gorm"
+)
+
+type ModerationService interface {
+	// Submit 商品发布或编辑后进入审核队列，执行自动预审并记录发现的问题
+	Submit(product *models.Product) error
+	ListQueue(req *api.ModerationListRequest) (*api.ModerationListResponse, error)
+	Approve(productID, adminID uint) error
+	Reject(productID, adminID uint, reason string) error
+}
+
+// UserNotifier 以系统消息通知用户商品的审核、管理和降价等事件，由消息服务实现
+type UserNotifier interface {
+	SendSystemMessage(req *msgapi.AdminSendSystemMessageRequest) error
+}
+
+type ModerationServiceImpl struct {
+	productRep repositories.ProductRepository
+	screener   moderation.Screener
+	history    ProductHistory
+	notifier   UserNotifier
+}
+
+func NewModerationService(productRep repositories.ProductRepository, screener moderation.Screener, history ProductHistory, notifier UserNotifier) ModerationService {
+	return &ModerationServiceImpl{
+		productRep: productRep,
+		screener:   screener,
+		history:    history,
+		notifier:   notifier,
+	}
+}
+
+// Submit 同一商品已有待审核记录时更新该记录的预审结果，不重复排队。预审出错时记录日志，商品仍进入人工审核
+func (s *ModerationServiceImpl) Submit(product *models.Product) error {
+	flags, err := s.screener.Screen(product)
+	if err != nil {
+		logger.Errorf("商品%d自动预审失败: %v", product.ID, err)
+		flags = []string{"自动预审失败，请人工检查"}
+	}
+
+	record, err := s.productRep.GetPendingModeration(product.ID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return errors.NewInternalServerError("查询审核记录失败", err)
+		}
+		record = &models.ProductModeration{
+			ProductID: product.ID,
+			Status:    models.ModerationPending,
+		}
+	}
+	record.Flags = strings.Join(flags, "\n")
+
+	if err := s.productRep.SaveModeration(record); err != nil {
+		return errors.NewInternalServerError("提交商品审核失败", err)
+	}
+	return nil
+}
+
+// ListQueue 分页获取审核记录，默认只返回待审核的记录
+func (s *ModerationServiceImpl) ListQueue(req *api.ModerationListRequest) (*api.ModerationListResponse, error) {
+	p, err := req.Params()
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+
+	status := req.Status
+	switch status {
+	case "":
+		status = models.ModerationPending
+	case "all":
+		status = ""
+	}
+
+	records, info, err := s.productRep.ListModerations(status, req.Flagged, p)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取审核队列失败", err)
+	}
+
+	resp := &api.ModerationListResponse{
+		List: make([]*api.ModerationResponse, 0, len(records)),
+		Info: info,
+	}
+	for _, record := range records {
+		resp.List = append(resp.List, api.ConvertToModerationResponse(record))
+	}
+	return resp, nil
+}
+
+// Approve 审核通过，商品上架
+func (s *ModerationServiceImpl) Approve(productID, adminID uint) error {
+	product, record, err := s.pending(productID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record.Status = models.ModerationApproved
+	record.ReviewerID = adminID
+	record.ReviewedAt = &now
+	if err := s.resolve(product, record, models.ProductStatusOnSale, adminID); err != nil {
+		return err
+	}
+
+	s.notify(product, fmt.Sprintf("您发布的商品《%s》已通过审核，现已上架。", product.Title))
+	return nil
+}
+
+// Reject 驳回商品，卖家修改后可重新提交审核
+func (s *ModerationServiceImpl) Reject(productID, adminID uint, reason string) error {
+	product, record, err := s.pending(productID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	record.Status = models.ModerationRejected
+	record.Reason = reason
+	record.ReviewerID = adminID
+	record.ReviewedAt = &now
+	if err := s.resolve(product, record, models.ProductStatusRejected, adminID); err != nil {
+		return err
+	}
+
+	s.notify(product, fmt.Sprintf("您发布的商品《%s》未通过审核，原因：%s。请修改后重新提交。", product.Title, reason))
+	return nil
+}
+
+// pending 获取审核中的商品及其待审核记录
+func (s *ModerationServiceImpl) pending(productID uint) (*models.Product, *models.ProductModeration, error) {
+	product, err := s.productRep.GetByID(fmt.Sprint(productID))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, errors.NewNotFoundError("商品", err)
+		}
+		return nil, nil, errors.NewInternalServerError("查询商品失败", err)
+	}
+	if product.Status != models.ProductStatusReviewing {
+		return nil, nil, errors.NewConflictError(fmt.Sprintf("商品当前不在审核中（%s）", product.Status), nil)
+	}
+
+	record, err := s.productRep.GetPendingModeration(productID)
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, nil, errors.NewInternalServerError("查询审核记录失败", err)
+		}
+		// 审核功能上线前已处于审核中的商品没有审核记录，审核时补建
+		record = &models.ProductModeration{ProductID: productID, Status: models.ModerationPending}
+	}
+	return product, record, nil
+}
+
+// resolve 保存审核结果并记录商品状态的变化
+func (s *ModerationServiceImpl) resolve(product *models.Product, record *models.ProductModeration, productStatus string, adminID uint) error {
+	resolved, err := s.productRep.ResolveModeration(record, productStatus)
+	if err != nil {
+		return errors.NewInternalServerError("保存审核结果失败", err)
+	}
+	if !resolved {
+		return errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+	}
+
+	before := *product
+	product.Status = productStatus
+	s.history.Record(&before, product, adminID)
+	return nil
+}
+
+// notify 通知卖家审核结果，通知失败不影响审核结果
+func (s *ModerationServiceImpl) notify(product *models.Product, content string) {
+	err := s.notifier.SendSystemMessage(&msgapi.AdminSendSystemMessageRequest{
+		ReceiverID: product.UserID,
+		Title:      "商品审核",
+		Content:    content,
+	})
+	if err != nil {
+		logger.Errorf("发送商品%d审核结果通知失败: %v", product.ID, err)
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/moderation"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/utils/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestModerationService(repo repositories.ProductRepository, notifier *recordingNotifier) ModerationService {
+	return NewModerationService(repo, moderation.NewKeywordScreener([]string{"代考"}), NewProductHistory(repo, notifier), notifier)
+}
+
+// loadModerations 按ID顺序读取商品的全部审核记录
+func loadModerations(t *testing.T, db *gorm.DB, productID uint) []models.ProductModeration {
+	var records []models.ProductModeration
+	require.NoError(t, db.Where("product_id = ?", productID).Order("id").Find(&records).Error)
+	return records
+}
+
+func TestSubmitReusesPendingRecord(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	service := newTestModerationService(repo, &recordingNotifier{})
+
+	require.NoError(t, service.Submit(product))
+	product.Description = "代考服务"
+	require.NoError(t, service.Submit(product))
+
+	records := loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationPending, records[0].Status)
+	assert.Contains(t, records[0].Flags, "代考")
+}
+
+func TestApproveProduct(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	notifier := &recordingNotifier{}
+	service := newTestModerationService(repo, notifier)
+	require.NoError(t, service.Submit(product))
+
+	require.NoError(t, service.Approve(product.ID, testAdminID))
+
+	assert.Equal(t, models.ProductStatusOnSale, reloadProduct(t, db, product.ID).Status)
+	records := loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationApproved, records[0].Status)
+	assert.Equal(t, testAdminID, records[0].ReviewerID)
+	assert.NotNil(t, records[0].ReviewedAt)
+	require.Len(t, notifier.sent, 1)
+	assert.Equal(t, testSellerID, notifier.sent[0].ReceiverID)
+}
+
+func TestRejectProduct(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	notifier := &recordingNotifier{}
+	service := newTestModerationService(repo, notifier)
+	require.NoError(t, service.Submit(product))
+
+	require.NoError(t, service.Reject(product.ID, testAdminID, "图片与描述不符"))
+
+	assert.Equal(t, models.ProductStatusRejected, reloadProduct(t, db, product.ID).Status)
+	records := loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationRejected, records[0].Status)
+	assert.Equal(t, "图片与描述不符", records[0].Reason)
+	require.Len(t, notifier.sent, 1)
+	assert.Contains(t, notifier.sent[0].Content, "图片与描述不符")
+}
+
+func TestModerationWithoutRecord(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	service := newTestModerationService(repo, &recordingNotifier{})
+
+	// 没有审核记录的审核中商品，审核时补建记录
+	require.NoError(t, service.Approve(product.ID, testAdminID))
+	records := loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationApproved, records[0].Status)
+}
+
+func TestModerationConflict(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	notifier := &recordingNotifier{}
+	service := newTestModerationService(repo, notifier)
+	require.NoError(t, service.Submit(product))
+	require.NoError(t, service.Approve(product.ID, testAdminID))
+
+	// 已审核的商品不能再次审核
+	assert.True(t, errors.IsConflict(service.Approve(product.ID, testAdminID)))
+	assert.True(t, errors.IsConflict(service.Reject(product.ID, testAdminID, "重复")))
+	assert.Equal(t, models.ProductStatusOnSale, reloadProduct(t, db, product.ID).Status)
+	assert.Len(t, notifier.sent, 1)
+
+	assert.True(t, errors.IsNotFound(service.Approve(product.ID+100, testAdminID)))
+}
+
+func TestAdminUpdateStatusSyncsModeration(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusOnSale)
+	notifier := &recordingNotifier{}
+	moderations := newTestModerationService(repo, notifier)
+	s := &ProductServiceImpl{productRep: repo, moderation: moderations, history: NewProductHistory(repo, notifier)}
+
+	// 改为审核中时提交审核
+	_, err := s.UpdateProductStatus(product.ID, testAdminID, models.ProductStatusReviewing)
+	require.NoError(t, err)
+	records := loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationPending, records[0].Status)
+
+	// 直接上架视为审核通过
+	updated, err := s.UpdateProductStatus(product.ID, testAdminID, models.ProductStatusOnSale)
+	require.NoError(t, err)
+	assert.Equal(t, models.ProductStatusOnSale, updated.Status)
+	assert.Equal(t, models.ProductStatusOnSale, reloadProduct(t, db, product.ID).Status)
+	records = loadModerations(t, db, product.ID)
+	require.Len(t, records, 1)
+	assert.Equal(t, models.ModerationApproved, records[0].Status)
+	assert.Equal(t, testAdminID, records[0].ReviewerID)
+}
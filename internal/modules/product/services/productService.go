@@ -1,72 +1,127 @@
 package services
 
 import (
+	"campus/internal/config"
 	"campus/internal/models"
 	"campus/internal/modules/product/api"
 	"campus/internal/modules/product/repositories"
+	"campus/internal/modules/product/search"
+	"campus/internal/modules/product/views"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/pagination"
 	"fmt"
+	"gorm.io/gorm"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
-const (
-	ProductStatusAvailable = "售卖中"
-	ProductStatusSold      = "已下架"
-	ProductStatusReviewing = "审核中"
-)
-
 type ProductService interface {
-	GetAllProducts(page, size uint) (*api.ProductListResponse, error)
+	GetAllProducts(p pagination.Params) (*api.ProductListResponse, error)
 	GetProductByID(id string) (*api.ProductResponse, error)
+	// ViewProduct 用户查看商品详情，并记录一次浏览。卖家查看自己的商品不计入浏览
+	ViewProduct(id string, viewerID uint) (*api.ProductResponse, error)
 	CreateProduct(data *api.CreateProductRequest) (*api.ProductResponse, error)
-	UpdateProduct(id string, data *api.UpdateProductRequest) (*api.ProductResponse, error)
-	DeleteProduct(id string) error
-	SearchProductsByKeyword(keyword string, page, size uint) (*api.ProductListResponse, error)
-	GetUserProducts(userID uint, page, size uint) (*api.ProductListResponse, error)
-	GetSolvingProducts(page, size uint) (*api.ProductListResponse, error)
+	// UpdateProduct 和 DeleteProduct 只允许商品的发布者操作
+	UpdateProduct(id string, userID uint, data *api.UpdateProductRequest) (*api.ProductResponse, error)
+	DeleteProduct(id string, userID uint) error
+	// AdminUpdateProduct 和 AdminDeleteProduct 供管理员接口使用，不校验发布者
+	AdminUpdateProduct(id string, adminID uint, data *api.UpdateProductRequest) (*api.ProductResponse, error)
+	AdminDeleteProduct(id string) error
+	SearchProductsByKeyword(keyword string, p pagination.Params) (*api.ProductListResponse, error)
+	// GetUserProducts 获取用户发布的商品，查看他人发布的商品时只返回在售商品
+	GetUserProducts(userID, viewerID uint, p pagination.Params) (*api.ProductListResponse, error)
+	GetSolvingProducts(p pagination.Params) (*api.ProductListResponse, error)
 	FilterProducts(filter *api.FilterProductsRequest) (*api.ProductListResponse, error)
 	GetLatestProducts(limit uint) (*api.ProductListResponse, error)
-	UpdateProductStatus(id uint, status string) (*models.Product, error)
+	// GetHotProducts 按浏览数、收藏数和发布时间计算热度，获取热门商品
+	GetHotProducts(limit uint) (*api.ProductListResponse, error)
+	UpdateProductStatus(id, adminID uint, status string) (*models.Product, error)
+	// GetProductRevisions 获取商品的修改记录，只有卖家可以查看
+	GetProductRevisions(id string, userID uint, p pagination.Params) (*api.RevisionListResponse, error)
+	AdminGetProductRevisions(id string, p pagination.Params) (*api.RevisionListResponse, error)
+	// RebuildSearchIndex 从数据库重建商品全文索引
+	RebuildSearchIndex() error
 }
 
 type ProductServiceImpl struct {
 	productRep repositories.ProductRepository
 	imageRep   repositories.ProductImageRepository
+	searcher   search.ProductSearcher
+	moderation ModerationService
+	views      *views.Tracker
+	viewConfig config.ViewConfig
+	history    ProductHistory
 }
 
+// 搜索结果描述摘要的长度
+const searchSnippetWidth = 80
+
+// 全文检索最多取相关度最高的命中数，命中ID会作为IN条件参与过滤和分面统计
+const maxSearchHits = 1000
+
+// FilterProducts 筛选商品。关键词搜索和按价格、收藏数排序时只支持page/size分页
 func (s *ProductServiceImpl) FilterProducts(filter *api.FilterProductsRequest) (*api.ProductListResponse, error) {
-	products, tot, err := s.productRep.FilterProducts(filter)
+	if err := filter.Validate(); err != nil {
+		return nil, errors.NewValidationError(err.Error(), err)
+	}
+	restrictToPublic(filter)
+	p, err := filter.Params()
+	if err != nil {
+		return nil, errors.NewBadRequestError("无效的分页游标", err)
+	}
+
+	if filter.Keyword != "" {
+		return s.searchProducts(filter, p)
+	}
+
+	if p.CursorMode() && filter.Sort != "" && filter.Sort != api.ProductSortNewest {
+		return nil, errors.NewBadRequestError("该排序方式不支持游标分页，请传入page参数", nil)
+	}
+
+	products, info, err := s.productRep.FilterProducts(filter, p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("赛选商品失败", err)
 	}
-	return api.ConvertToProductListResponse(products, uint(tot), filter.Page, filter.Size), nil
+	resp := api.ConvertToProductListResponse(products, info)
 
+	resp.Facets, err = s.productRep.GetProductFacets(filter, nil)
+	if err != nil {
+		return nil, errors.NewInternalServerError("统计商品分面失败", err)
+	}
+	return resp, nil
 }
 
-func NewProductService() ProductService {
+func NewProductService(searcher search.ProductSearcher, moderation ModerationService, tracker *views.Tracker, viewConfig config.ViewConfig, history ProductHistory) ProductService {
 	return &ProductServiceImpl{
 		productRep: repositories.NewProductRepository(),
 		imageRep:   repositories.NewProductImageRepository(),
+		searcher:   searcher,
+		moderation: moderation,
+		views:      tracker,
+		viewConfig: viewConfig,
+		history:    history,
 	}
 }
 
-func (s *ProductServiceImpl) GetAllProducts(page, size uint) (*api.ProductListResponse, error) {
-	products, total, err := s.productRep.GetAll(page, size)
+func (s *ProductServiceImpl) GetAllProducts(p pagination.Params) (*api.ProductListResponse, error) {
+	products, info, err := s.productRep.GetAll(p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("查询商品列表失败", err)
 	}
 
-	return api.ConvertToProductListResponse(products, uint(total), page, size), nil
+	return api.ConvertToProductListResponse(products, info), nil
 }
 
-func (s *ProductServiceImpl) GetSolvingProducts(page, size uint) (*api.ProductListResponse, error) {
-	products, total, err := s.productRep.GetSolvingProducts(page, size)
+func (s *ProductServiceImpl) GetSolvingProducts(p pagination.Params) (*api.ProductListResponse, error) {
+	products, info, err := s.productRep.GetSolvingProducts(p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("查询商品列表失败", err)
 	}
 
-	return api.ConvertToProductListResponse(products, uint(total), page, size), nil
+	return api.ConvertToProductListResponse(products, info), nil
 }
 
 func (s *ProductServiceImpl) GetProductByID(id string) (*api.ProductResponse, error) {
@@ -75,18 +130,49 @@ func (s *ProductServiceImpl) GetProductByID(id string) (*api.ProductResponse, er
 		return nil, errors.NewNotFoundError("商品", err)
 	}
 
-	return api.ConvertToProductResponse(product), nil
+	return s.withPendingViews(api.ConvertToProductResponse(product)), nil
+}
+
+// ViewProduct 查看商品详情，不在售的商品只有发布者可以查看
+func (s *ProductServiceImpl) ViewProduct(id string, viewerID uint) (*api.ProductResponse, error) {
+	product, err := s.productRep.GetByID(id)
+	if err != nil {
+		return nil, errors.NewNotFoundError("商品", err)
+	}
+	if product.Status != models.ProductStatusOnSale && product.UserID != viewerID {
+		return nil, errors.NewNotFoundError("商品", nil)
+	}
+
+	if product.UserID != viewerID {
+		s.views.Record(product.ID, viewerID)
+	}
+	return s.withPendingViews(api.ConvertToProductResponse(product)), nil
+}
+
+// withPendingViews 浏览次数加上内存中尚未写入数据库的部分
+func (s *ProductServiceImpl) withPendingViews(resp *api.ProductResponse) *api.ProductResponse {
+	resp.ViewCount += s.views.Pending(resp.ID)
+	return resp
 }
 
 func (s *ProductServiceImpl) CreateProduct(data *api.CreateProductRequest) (*api.ProductResponse, error) {
+	if data.CategoryID == 0 && strings.TrimSpace(data.Category) == "" {
+		return nil, errors.NewBadRequestError("请选择商品分类", nil)
+	}
+	category, err := s.resolveCategory(data.CategoryID, data.Category)
+	if err != nil {
+		return nil, err
+	}
+
 	product := &models.Product{
 		Title:       data.Title,
 		Description: data.Description,
 		Price:       data.Price,
-		Category:    data.Category,
+		CategoryID:  &category.ID,
+		Category:    category.Name,
 		Condition:   data.Condition,
 		UserID:      data.UserID,
-		Status:      ProductStatusReviewing,
+		Status:      models.ProductStatusReviewing,
 		SoldAt:      time.Now(),
 	}
 
@@ -110,29 +196,72 @@ func (s *ProductServiceImpl) CreateProduct(data *api.CreateProductRequest) (*api
 		return nil, err
 	}
 	product.ProductImages = images
+	s.searcher.Index(searchDocument(product))
+
+	// 新发布的商品进入审核队列，审核通过后才会上架。商品已保存，提交失败时记录日志，
+	// 商品保持审核中，管理员审核时会补建审核记录
+	if err := s.moderation.Submit(product); err != nil {
+		logger.Errorf("商品%d提交审核失败: %v", product.ID, err)
+	}
 
 	return api.ConvertToProductResponse(product), nil
 }
 
-func (s *ProductServiceImpl) UpdateProduct(id string, data *api.UpdateProductRequest) (*api.ProductResponse, error) {
-	_, err := s.productRep.GetByID(id)
+func (s *ProductServiceImpl) UpdateProduct(id string, userID uint, data *api.UpdateProductRequest) (*api.ProductResponse, error) {
+	product, err := s.getOwnedProduct(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 卖家主动下架时不需要审核，其他编辑都需要重新审核
+	status := models.ProductStatusReviewing
+	if data.Status == models.ProductStatusOffShelf {
+		status = models.ProductStatusOffShelf
+	}
+	return s.updateProduct(product, status, data, userID)
+}
+
+// AdminUpdateProduct 管理员编辑商品不需要重新审核，未传入status时保持原状态
+func (s *ProductServiceImpl) AdminUpdateProduct(id string, adminID uint, data *api.UpdateProductRequest) (*api.ProductResponse, error) {
+	product, err := s.productRep.GetByID(id)
 	if err != nil {
 		return nil, errors.NewNotFoundError("商品", err)
 	}
+	return s.updateProduct(product, data.Status, data, adminID)
+}
+
+// updateProduct 更新商品信息和图片并保存修改记录，status为空时不修改商品状态，修改为审核中时提交审核
+func (s *ProductServiceImpl) updateProduct(product *models.Product, status string, data *api.UpdateProductRequest, editorID uint) (*api.ProductResponse, error) {
+	if err := checkProductNotInTrade(product); err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(int(product.ID))
 
 	updatedProduct := &models.Product{
 		Title:       data.Title,
 		Description: data.Description,
 		Price:       data.Price,
-		Category:    data.Category,
 		Condition:   data.Condition,
-		Status:      data.Status,
+		Status:      status,
 		SoldAt:      time.Now(),
 	}
+	if data.CategoryID > 0 || strings.TrimSpace(data.Category) != "" {
+		category, err := s.resolveCategory(data.CategoryID, data.Category)
+		if err != nil {
+			return nil, err
+		}
+		updatedProduct.CategoryID = &category.ID
+		updatedProduct.Category = category.Name
+	}
 
-	if err := s.productRep.Update(id, updatedProduct); err != nil {
+	// 以商品不在交易中为条件更新，防止与下单并发时覆盖交易中状态
+	changed, err := s.productRep.Update(product.ID, updatedProduct)
+	if err != nil {
 		return nil, errors.NewInternalServerError("更新商品失败", err)
 	}
+	if !changed {
+		return nil, errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+	}
 
 	/*
 		更新图片
@@ -145,10 +274,9 @@ func (s *ProductServiceImpl) UpdateProduct(id string, data *api.UpdateProductReq
 
 	// 添加新图片
 	var images []models.ProductImage
-	pid, err := strconv.Atoi(id)
 	for _, imageURL := range data.Images {
 		image := &models.ProductImage{
-			ProductID: uint(pid),
+			ProductID: product.ID,
 			ImageURL:  imageURL,
 		}
 		images = append(images, *image)
@@ -162,33 +290,185 @@ func (s *ProductServiceImpl) UpdateProduct(id string, data *api.UpdateProductReq
 	if err != nil {
 		return nil, errors.NewNotFoundError("商品", err)
 	}
+	s.searcher.Index(searchDocument(updated))
+	s.history.Record(product, updated, editorID)
+
+	if status == models.ProductStatusReviewing {
+		if err := s.moderation.Submit(updated); err != nil {
+			logger.Errorf("商品%d提交审核失败: %v", updated.ID, err)
+		}
+	}
 
 	return api.ConvertToProductResponse(updated), nil
 }
 
-func (s *ProductServiceImpl) DeleteProduct(id string) error {
-	if err := s.productRep.Delete(id); err != nil {
+func (s *ProductServiceImpl) DeleteProduct(id string, userID uint) error {
+	product, err := s.getOwnedProduct(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.deleteProduct(product)
+}
+
+func (s *ProductServiceImpl) AdminDeleteProduct(id string) error {
+	product, err := s.productRep.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError("商品", err)
+		}
+		return errors.NewInternalServerError("查询商品失败", err)
+	}
+	return s.deleteProduct(product)
+}
+
+// deleteProduct 删除不在交易中的商品及其图片，并移出搜索索引
+func (s *ProductServiceImpl) deleteProduct(product *models.Product) error {
+	if err := checkProductNotInTrade(product); err != nil {
+		return err
+	}
+	deleted, err := s.productRep.DeleteWithImages(product.ID)
+	if err != nil {
 		return errors.NewInternalServerError("删除商品失败", err)
 	}
+	if !deleted {
+		return errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+	}
+	s.searcher.Remove(product.ID)
 	return nil
 }
 
-func (s *ProductServiceImpl) SearchProductsByKeyword(keyword string, page, size uint) (*api.ProductListResponse, error) {
-	products, total, err := s.productRep.SearchProductsByKeyword(keyword, page, size)
+// SearchProductsByKeyword 全文检索商品，按标题和描述的相关度排序并高亮命中内容
+func (s *ProductServiceImpl) SearchProductsByKeyword(keyword string, p pagination.Params) (*api.ProductListResponse, error) {
+	if keyword == "" {
+		return api.ConvertToProductListResponse(nil, pagination.Info{Page: p.Page, Size: p.Size}), nil
+	}
+	return s.searchProducts(&api.FilterProductsRequest{Keyword: keyword, Status: models.ProductStatusOnSale}, p)
+}
+
+// searchProducts 全文检索关键词，取相关度最高的 maxSearchHits 条命中按filter中的其他条件过滤后分页；
+// 未指定排序方式时按相关度排序。检索结果在内存中排序，只支持page/size分页，未传page时返回第一页
+func (s *ProductServiceImpl) searchProducts(filter *api.FilterProductsRequest, p pagination.Params) (*api.ProductListResponse, error) {
+	if p.CursorMode() {
+		p.Page = 1
+	}
+
+	hits := s.searcher.Search(filter.Keyword)
+	if len(hits) > maxSearchHits {
+		hits = hits[:maxSearchHits]
+	}
+	ids := make([]uint, 0, len(hits))
+	hitByID := make(map[uint]search.Hit, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+		hitByID[hit.ID] = hit
+	}
+
+	matchedIDs, err := s.productRep.FilterProductIDs(filter, ids)
+	if err != nil {
+		return nil, errors.NewInternalServerError("搜索商品失败", err)
+	}
+	ranked := make([]search.Hit, 0, len(matchedIDs))
+	if filter.Sort != "" {
+		for _, id := range matchedIDs {
+			ranked = append(ranked, hitByID[id])
+		}
+	} else {
+		matched := make(map[uint]bool, len(matchedIDs))
+		for _, id := range matchedIDs {
+			matched[id] = true
+		}
+		for _, hit := range hits {
+			if matched[hit.ID] {
+				ranked = append(ranked, hit)
+			}
+		}
+	}
+
+	// 分页
+	start := p.Offset()
+	if start > len(ranked) {
+		start = len(ranked)
+	}
+	end := start + p.Size
+	if end > len(ranked) {
+		end = len(ranked)
+	}
+	pageHits := ranked[start:end]
+
+	pageIDs := make([]uint, 0, len(pageHits))
+	for _, hit := range pageHits {
+		pageIDs = append(pageIDs, hit.ID)
+	}
+	products, err := s.productRep.GetByIDs(pageIDs)
 	if err != nil {
 		return nil, errors.NewInternalServerError("搜索商品失败", err)
 	}
+	byID := make(map[uint]*models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
 
-	return api.ConvertToProductListResponse(products, uint(total), page, size), nil
+	resp := api.ConvertToProductListResponse(nil, pagination.Info{
+		Total:   int64(len(ranked)),
+		Page:    p.Page,
+		Size:    p.Size,
+		HasMore: end < len(ranked),
+	})
+	for _, hit := range pageHits {
+		product, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		item := api.ConvertToProductResponse(product)
+		item.Highlight = &api.ProductHighlight{
+			Title:       search.Highlight(product.Title, hit.Terms),
+			Description: search.Snippet(product.Description, hit.Terms, searchSnippetWidth),
+		}
+		resp.Products = append(resp.Products, item)
+	}
+
+	resp.Facets, err = s.productRep.GetProductFacets(filter, ids)
+	if err != nil {
+		return nil, errors.NewInternalServerError("统计商品分面失败", err)
+	}
+	return resp, nil
+}
+
+func (s *ProductServiceImpl) RebuildSearchIndex() error {
+	products, err := s.productRep.GetSearchDocuments()
+	if err != nil {
+		return errors.NewInternalServerError("加载商品索引数据失败", err)
+	}
+
+	docs := make([]search.Document, 0, len(products))
+	for _, p := range products {
+		docs = append(docs, searchDocument(p))
+	}
+	s.searcher.Rebuild(docs)
+	logger.Infof("商品全文索引构建完成，共%d个商品", len(docs))
+	return nil
 }
 
-func (s *ProductServiceImpl) GetUserProducts(userID uint, page, size uint) (*api.ProductListResponse, error) {
-	products, total, err := s.productRep.GetByUserID(userID, page, size)
+// searchDocument 将商品转换为全文索引文档
+func searchDocument(product *models.Product) search.Document {
+	return search.Document{
+		ID:          product.ID,
+		Title:       product.Title,
+		Description: product.Description,
+	}
+}
+
+func (s *ProductServiceImpl) GetUserProducts(userID, viewerID uint, p pagination.Params) (*api.ProductListResponse, error) {
+	status := ""
+	if userID != viewerID {
+		status = models.ProductStatusOnSale
+	}
+	products, info, err := s.productRep.ListByUserID(userID, status, p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("查询用户发布商品失败", err)
 	}
 
-	return api.ConvertToProductListResponse(products, uint(total), page, size), nil
+	return api.ConvertToProductListResponse(products, info), nil
 }
 
 // GetLatestProducts 获取最新商品
@@ -202,20 +482,196 @@ func (s *ProductServiceImpl) GetLatestProducts(limit uint) (*api.ProductListResp
 		return nil, errors.NewInternalServerError("获取最新商品失败", err)
 	}
 
-	return api.ConvertToProductListResponse(products, uint(total), 1, limit), nil
+	return api.ConvertToProductListResponse(products, pagination.Info{Total: total, Page: 1, Size: int(limit)}), nil
+}
+
+// GetHotProducts 热门商品只从热门窗口内发布的在售商品中选取，热度在内存中计算
+func (s *ProductServiceImpl) GetHotProducts(limit uint) (*api.ProductListResponse, error) {
+	if limit == 0 {
+		limit = 8 // 默认获取8条
+	}
+
+	now := time.Now()
+	candidates, err := s.productRep.GetHotCandidates(now.Add(-s.viewConfig.HotWindow))
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取热门商品失败", err)
+	}
+
+	scores := make(map[uint]float64, len(candidates))
+	for _, c := range candidates {
+		scores[c.ID] = views.HotScore(c.ViewCount+s.views.Pending(c.ID), c.FavoriteCount, now.Sub(c.CreatedAt),
+			s.viewConfig.FavoriteWeight, s.viewConfig.HotGravity)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if scores[candidates[i].ID] != scores[candidates[j].ID] {
+			return scores[candidates[i].ID] > scores[candidates[j].ID]
+		}
+		return candidates[i].ID > candidates[j].ID
+	})
+	if len(candidates) > int(limit) {
+		candidates = candidates[:limit]
+	}
+
+	ids := make([]uint, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ID)
+	}
+	products, err := s.productRep.GetByIDs(ids)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取热门商品失败", err)
+	}
+	// GetByIDs 不保证顺序，按排行结果重新排序
+	rank := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		rank[id] = i
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return rank[products[i].ID] < rank[products[j].ID]
+	})
+
+	resp := api.ConvertToProductListResponse(products, pagination.Info{Total: int64(len(products)), Page: 1, Size: int(limit)})
+	for _, p := range resp.Products {
+		s.withPendingViews(p)
+	}
+	return resp, nil
 }
 
-// UpdateProductStatus 更新商品状态
-func (s *ProductServiceImpl) UpdateProductStatus(productID uint, status string) (*models.Product, error) {
+// UpdateProductStatus 管理员修改商品状态
+func (s *ProductServiceImpl) UpdateProductStatus(productID, adminID uint, status string) (*models.Product, error) {
 	product, err := s.productRep.GetByID(strconv.Itoa(int(productID)))
 	if err != nil {
 		return nil, errors.NewNotFoundError("找不到此商品", err)
 	}
-	fmt.Println(status)
-	product.Status = status
-	//fmt.Println(product)
-	if err := s.productRep.Update(strconv.Itoa(int(productID)), product); err != nil {
+	if err := checkProductNotInTrade(product); err != nil {
+		return nil, err
+	}
+	if product.Status == status {
+		return product, nil
+	}
+	// 与批量修改相同，经由ChangeStatus同步处理待审核记录
+	changed, err := s.productRep.ChangeStatus(productID, adminID, status, "")
+	if err != nil {
 		return nil, errors.NewInternalServerError("更新商品状态失败", err)
 	}
+	if !changed {
+		return nil, errors.NewConflictError("商品状态已变化，请刷新后重试", nil)
+	}
+
+	before := *product
+	product.Status = status
+	s.history.Record(&before, product, adminID)
+	if status == models.ProductStatusReviewing {
+		if err := s.moderation.Submit(product); err != nil {
+			logger.Errorf("商品%d提交审核失败: %v", product.ID, err)
+		}
+	}
 	return product, nil
 }
+
+func (s *ProductServiceImpl) GetProductRevisions(id string, userID uint, p pagination.Params) (*api.RevisionListResponse, error) {
+	product, err := s.getOwnedProduct(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.history.List(product.ID, p)
+}
+
+func (s *ProductServiceImpl) AdminGetProductRevisions(id string, p pagination.Params) (*api.RevisionListResponse, error) {
+	product, err := s.productRep.GetByID(id)
+	if err != nil {
+		return nil, errors.NewNotFoundError("商品", err)
+	}
+	return s.history.List(product.ID, p)
+}
+
+// resolveCategory 查找商品要关联的分类，优先按ID查找，未传入ID时按名称查找。分类及其上级分类必须存在且已启用
+func (s *ProductServiceImpl) resolveCategory(id uint, name string) (*models.Category, error) {
+	if id > 0 {
+		category, err := s.productRep.GetCategory(id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, errors.NewBadRequestError("商品分类不存在", err)
+			}
+			return nil, errors.NewInternalServerError("查询商品分类失败", err)
+		}
+		if err := checkCategoryEnabled(s.productRep, category); err != nil {
+			return nil, err
+		}
+		return category, nil
+	}
+
+	found, err := s.productRep.FindCategoriesByName(name)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询商品分类失败", err)
+	}
+	// 上级分类已停用的同名分类不可用
+	categories := make([]*models.Category, 0, len(found))
+	for _, category := range found {
+		if err := checkCategoryEnabled(s.productRep, category); err != nil {
+			if errors.IsBadRequest(err) {
+				continue
+			}
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	switch len(categories) {
+	case 0:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("商品分类不存在: %s", name), nil)
+	case 1:
+		return categories[0], nil
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("存在多个名为%s的分类，请传入category_id", name), nil)
+	}
+}
+
+// restrictToPublic 非管理员只能筛选在售商品，筛选自己发布的商品时不限状态
+func restrictToPublic(filter *api.FilterProductsRequest) {
+	if filter.AllStatuses || (filter.SellerID != 0 && filter.SellerID == filter.ViewerID) {
+		return
+	}
+	filter.Status = models.ProductStatusOnSale
+}
+
+// checkCategoryEnabled 沿上级分类逐级校验，分类自身或任一上级分类停用时都不能使用
+func checkCategoryEnabled(repo repositories.ProductRepository, category *models.Category) error {
+	for current := category; ; {
+		if !current.IsEnabled {
+			return errors.NewBadRequestError("商品分类已停用", nil)
+		}
+		if current.ParentID == nil {
+			return nil
+		}
+		parent, err := repo.GetCategory(*current.ParentID)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errors.NewBadRequestError("商品分类不存在", err)
+			}
+			return errors.NewInternalServerError("查询商品分类失败", err)
+		}
+		current = parent
+	}
+}
+
+// getOwnedProduct 获取商品并校验当前用户是商品的发布者
+func (s *ProductServiceImpl) getOwnedProduct(id string, userID uint) (*models.Product, error) {
+	product, err := s.productRep.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("商品", err)
+		}
+		return nil, errors.NewInternalServerError("查询商品失败", err)
+	}
+	if product.UserID != userID {
+		return nil, errors.NewForbiddenError("只能操作自己发布的商品", nil)
+	}
+	return product, nil
+}
+
+// checkProductNotInTrade 交易中或已售出的商品状态由订单流程维护，不允许直接修改
+func checkProductNotInTrade(product *models.Product) error {
+	if models.ProductStatusInTrade(product.Status) {
+		return errors.NewConflictError("商品"+product.Status+"，不能修改", nil)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/product/api"
+	"campus/internal/modules/product/repositories"
+	"campus/internal/modules/product/search"
+	"campus/internal/modules/product/views"
+	"campus/internal/utils/errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// categoryRepo 只实现分类查询用到的仓库方法
+type categoryRepo struct {
+	repositories.ProductRepository
+	categories map[uint]*models.Category
+}
+
+func (r *categoryRepo) GetCategory(id uint) (*models.Category, error) {
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return category, nil
+}
+
+func (r *categoryRepo) FindCategoriesByName(name string) ([]*models.Category, error) {
+	var found []*models.Category
+	for id := uint(1); id <= uint(len(r.categories)); id++ {
+		if c := r.categories[id]; c != nil && c.IsEnabled && strings.EqualFold(c.Name, name) {
+			found = append(found, c)
+		}
+	}
+	return found, nil
+}
+
+// newCategoryRepo 分类树：数码(停用) > 手机；图书 > 教材、手机(同名)
+func newCategoryRepo() *categoryRepo {
+	parent := func(id uint) *uint { return &id }
+	return &categoryRepo{categories: map[uint]*models.Category{
+		1: {Model: gorm.Model{ID: 1}, Name: "数码", IsEnabled: false},
+		2: {Model: gorm.Model{ID: 2}, Name: "手机", ParentID: parent(1), IsEnabled: true},
+		3: {Model: gorm.Model{ID: 3}, Name: "图书", IsEnabled: true},
+		4: {Model: gorm.Model{ID: 4}, Name: "教材", ParentID: parent(3), IsEnabled: true},
+		5: {Model: gorm.Model{ID: 5}, Name: "手机", ParentID: parent(3), IsEnabled: true},
+	}}
+}
+
+func TestResolveCategoryChecksAncestors(t *testing.T) {
+	s := &ProductServiceImpl{productRep: newCategoryRepo()}
+
+	category, err := s.resolveCategory(4, "")
+	require.NoError(t, err)
+	assert.Equal(t, "教材", category.Name)
+
+	// 上级分类停用时子分类同样不可用
+	_, err = s.resolveCategory(2, "")
+	assert.True(t, errors.IsBadRequest(err))
+
+	// 按名称查找时跳过上级分类已停用的同名分类
+	category, err = s.resolveCategory(0, "手机")
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), category.ID)
+}
+
+func TestBatchUpdateCategoryRejectsDisabledAncestor(t *testing.T) {
+	s := NewProductBatchService(newCategoryRepo(), nil, nil, nil, nil)
+
+	_, err := s.UpdateCategory(testAdminID, &api.BatchUpdateCategoryRequest{ProductIDs: []uint{1}, CategoryID: 2})
+	assert.True(t, errors.IsBadRequest(err))
+}
+
+func TestBatchUpdateCategorySkipsInTradeAndRecordsRevision(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusOnSale)
+	require.NoError(t, db.AutoMigrate(&models.Category{}))
+	category := models.Category{Name: "台灯", IsEnabled: true}
+	require.NoError(t, db.Create(&category).Error)
+	reserved := models.Product{Title: "书桌", Price: 80, Category: "生活", UserID: testSellerID, Status: models.ProductStatusReserved}
+	require.NoError(t, db.Create(&reserved).Error)
+	s := NewProductBatchService(repo, nil, nil, NewProductHistory(repo, &recordingNotifier{}), nil)
+
+	resp, err := s.UpdateCategory(testAdminID, &api.BatchUpdateCategoryRequest{
+		ProductIDs: []uint{product.ID, reserved.ID}, CategoryID: category.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 1, resp.Succeeded)
+	assert.Equal(t, 1, resp.Failed)
+
+	// 交易中的商品分类不变
+	assert.Equal(t, "生活", reloadProduct(t, db, reserved.ID).Category)
+	assert.Equal(t, "台灯", reloadProduct(t, db, product.ID).Category)
+
+	var revisions []models.ProductRevision
+	require.NoError(t, db.Find(&revisions).Error)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, product.ID, revisions[0].ProductID)
+	assert.Equal(t, testAdminID, revisions[0].EditorID)
+	assert.Contains(t, revisions[0].Changes, `"field":"category"`)
+}
+
+func TestViewProductHiddenUnlessOnSale(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusReviewing)
+	s := &ProductServiceImpl{productRep: repo, views: views.NewTracker(repo, time.Minute)}
+	id := strconv.Itoa(int(product.ID))
+
+	// 审核中的商品只有发布者可以查看
+	_, err := s.ViewProduct(id, testOtherID)
+	assert.True(t, errors.IsNotFound(err))
+	resp, err := s.ViewProduct(id, testSellerID)
+	require.NoError(t, err)
+	assert.Equal(t, product.ID, resp.ID)
+
+	require.NoError(t, db.Model(product).Update("status", models.ProductStatusOnSale).Error)
+	_, err = s.ViewProduct(id, testOtherID)
+	require.NoError(t, err)
+}
+
+func TestRestrictToPublic(t *testing.T) {
+	filter := &api.FilterProductsRequest{Status: models.ProductStatusReviewing, ViewerID: testOtherID}
+	restrictToPublic(filter)
+	assert.Equal(t, models.ProductStatusOnSale, filter.Status)
+
+	// 查看其他卖家的商品时同样只能看到在售商品
+	filter = &api.FilterProductsRequest{Status: models.ProductStatusReviewing, SellerID: testSellerID, ViewerID: testOtherID}
+	restrictToPublic(filter)
+	assert.Equal(t, models.ProductStatusOnSale, filter.Status)
+
+	filter = &api.FilterProductsRequest{Status: models.ProductStatusReviewing, SellerID: testSellerID, ViewerID: testSellerID}
+	restrictToPublic(filter)
+	assert.Equal(t, models.ProductStatusReviewing, filter.Status)
+
+	filter = &api.FilterProductsRequest{Status: models.ProductStatusRejected, AllStatuses: true}
+	restrictToPublic(filter)
+	assert.Equal(t, models.ProductStatusRejected, filter.Status)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusOnSale)
+	s := &ProductServiceImpl{productRep: repo, searcher: search.NewIndex()}
+	id := strconv.Itoa(int(product.ID))
+
+	assert.True(t, errors.IsForbidden(s.DeleteProduct(id, testOtherID)))
+
+	// 交易中的商品发布者和管理员都不能删除
+	require.NoError(t, db.Model(product).Update("status", models.ProductStatusReserved).Error)
+	assert.True(t, errors.IsConflict(s.DeleteProduct(id, testSellerID)))
+	assert.True(t, errors.IsConflict(s.AdminDeleteProduct(id)))
+	assert.Equal(t, models.ProductStatusReserved, reloadProduct(t, db, product.ID).Status)
+
+	require.NoError(t, db.Model(product).Update("status", models.ProductStatusOnSale).Error)
+	require.NoError(t, s.DeleteProduct(id, testSellerID))
+	assert.True(t, errors.IsNotFound(s.AdminDeleteProduct(id)))
+}
+
+func TestUpdateProductConflictsWhenTradeStarts(t *testing.T) {
+	repo, db, product := newTestProductRepository(t, models.ProductStatusOnSale)
+	require.NoError(t, db.Create(&models.ProductImage{ProductID: product.ID, ImageURL: "/static/uploads/lamp.jpg"}).Error)
+	s := &ProductServiceImpl{productRep: repo, imageRep: repositories.NewProductImageRepository()}
+
+	// 读取商品后订单将其预订，修改以过期的商品状态提交
+	require.NoError(t, db.Model(product).Update("status", models.ProductStatusReserved).Error)
+	_, err := s.updateProduct(product, models.ProductStatusReviewing, &api.UpdateProductRequest{Title: "新台灯", Price: 50}, testSellerID)
+	assert.True(t, errors.IsConflict(err))
+
+	saved := reloadProduct(t, db, product.ID)
+	assert.Equal(t, models.ProductStatusReserved, saved.Status)
+	assert.Equal(t, "台灯", saved.Title)
+	var images int64
+	require.NoError(t, db.Model(&models.ProductImage{}).Where("product_id = ?", product.ID).Count(&images).Error)
+	assert.Equal(t, int64(1), images)
+}
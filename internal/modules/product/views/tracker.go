@@ -0,0 +1,135 @@
+package views
+
+import (
+	"campus/internal/utils/logger"
+	"math"
+	"sync"
+	"time"
+)
+
+// Store 保存浏览计数
+type Store interface {
+	// AddViews 将各商品新增的浏览次数累加到总数和at所在日期的统计中
+	AddViews(counts map[uint]int64, at time.Time) error
+}
+
+type viewKey struct {
+	productID uint
+	userID    uint
+}
+
+// Tracker 在内存中累计商品浏览次数并定期批量写入数据库。
+// 同一用户在去重窗口内重复浏览同一商品只计一次，去重记录只保存在本实例内存中
+type Tracker struct {
+	store  Store
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	seen    map[viewKey]time.Time // 最近一次计数的时间
+	pending map[uint]int64        // 尚未写入数据库的浏览次数
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTracker 创建浏览计数器，window为去重窗口
+func NewTracker(store Store, window time.Duration) *Tracker {
+	return &Tracker{
+		store:   store,
+		window:  window,
+		now:     time.Now,
+		seen:    make(map[viewKey]time.Time),
+		pending: make(map[uint]int64),
+	}
+}
+
+// Record 记录一次浏览，被去重时返回false
+func (t *Tracker) Record(productID, userID uint) bool {
+	now := t.now()
+	key := viewKey{productID: productID, userID: userID}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if last, ok := t.seen[key]; ok && now.Sub(last) < t.window {
+		return false
+	}
+	t.seen[key] = now
+	t.pending[productID]++
+	return true
+}
+
+// Pending 获取商品尚未写入数据库的浏览次数
+func (t *Tracker) Pending(productID uint) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pending[productID]
+}
+
+// Flush 将累计的浏览次数写入数据库，并清理过期的去重记录。写入失败时计数保留到下次写入
+func (t *Tracker) Flush() error {
+	now := t.now()
+
+	t.mu.Lock()
+	counts := t.pending
+	t.pending = make(map[uint]int64)
+	for key, last := range t.seen {
+		if now.Sub(last) >= t.window {
+			delete(t.seen, key)
+		}
+	}
+	t.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+	if err := t.store.AddViews(counts, now); err != nil {
+		t.mu.Lock()
+		for id, n := range counts {
+			t.pending[id] += n
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Start 每隔interval写入一次浏览计数。
+// 浏览计数只在本实例内存中，多实例部署时每个实例都要写入，因此不使用带租约的定时任务调度器
+func (t *Tracker) Start(interval time.Duration) {
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	go func() {
+		defer close(t.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				if err := t.Flush(); err != nil {
+					logger.Errorf("写入商品浏览计数失败: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止定期写入，并写入剩余的浏览计数
+func (t *Tracker) Stop() {
+	if t.stop != nil {
+		close(t.stop)
+		<-t.done
+		t.stop = nil
+	}
+	if err := t.Flush(); err != nil {
+		logger.Errorf("写入商品浏览计数失败: %v", err)
+	}
+}
+
+// HotScore 计算商品热度：浏览和加权后的收藏数之和随发布时间按gravity指数衰减
+func HotScore(views, favorites int64, age time.Duration, favoriteWeight, gravity float64) float64 {
+	hours := math.Max(age.Hours(), 0)
+	return (float64(views) + favoriteWeight*float64(favorites)) / math.Pow(hours+2, gravity)
+}
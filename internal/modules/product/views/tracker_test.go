@@ -0,0 +1,68 @@
+package views
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	totals map[uint]int64
+	fail   bool
+}
+
+func (s *fakeStore) AddViews(counts map[uint]int64, at time.Time) error {
+	if s.fail {
+		return errors.New("db down")
+	}
+	for id, n := range counts {
+		s.totals[id] += n
+	}
+	return nil
+}
+
+func TestTrackerDeduplicatesWithinWindow(t *testing.T) {
+	store := &fakeStore{totals: map[uint]int64{}}
+	tracker := NewTracker(store, 30*time.Minute)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	assert.True(t, tracker.Record(1, 10))
+	assert.False(t, tracker.Record(1, 10))
+	assert.True(t, tracker.Record(1, 11))
+	assert.True(t, tracker.Record(2, 10))
+	assert.Equal(t, int64(2), tracker.Pending(1))
+
+	// 超过去重窗口后再次计数
+	now = now.Add(31 * time.Minute)
+	assert.True(t, tracker.Record(1, 10))
+
+	require.NoError(t, tracker.Flush())
+	assert.Equal(t, map[uint]int64{1: 3, 2: 1}, store.totals)
+	assert.Zero(t, tracker.Pending(1))
+}
+
+func TestTrackerKeepsCountsWhenFlushFails(t *testing.T) {
+	store := &fakeStore{totals: map[uint]int64{}, fail: true}
+	tracker := NewTracker(store, time.Minute)
+	tracker.Record(1, 10)
+
+	assert.Error(t, tracker.Flush())
+	assert.Equal(t, int64(1), tracker.Pending(1))
+
+	store.fail = false
+	tracker.Stop()
+	assert.Equal(t, int64(1), store.totals[1])
+}
+
+func TestHotScoreDecaysWithAge(t *testing.T) {
+	fresh := HotScore(10, 0, time.Hour, 5, 1.5)
+	old := HotScore(10, 0, 48*time.Hour, 5, 1.5)
+	assert.Greater(t, fresh, old)
+
+	// 收藏按权重计入热度
+	assert.Equal(t, HotScore(15, 0, time.Hour, 5, 1.5), HotScore(10, 1, time.Hour, 5, 1.5))
+}
@@ -0,0 +1,29 @@
+package api
+
+// CreateReviewRequest 评价订单交易对方请求，评价人取自登录用户
+type CreateReviewRequest struct {
+	OrderID uint   `json:"order_id" binding:"required"`
+	Rating  int    `json:"rating" binding:"required,min=1,max=5"`
+	Content string `json:"content" binding:"max=500"`
+}
+
+// ReviewListRequest 分页查询评价请求
+type ReviewListRequest struct {
+	Page uint   `json:"page" form:"page" binding:"required,min=1"`
+	Size uint   `json:"size" form:"size" binding:"required,min=1,max=100"`
+	Role string `json:"role" form:"role" binding:"omitempty,oneof=buyer seller"` // 被评价用户在交易中的身份，为空时不限
+}
+
+// AdminReviewListRequest 管理员获取评价列表请求
+type AdminReviewListRequest struct {
+	Page     uint   `json:"page" form:"page"`
+	PageSize uint   `json:"pageSize" form:"pageSize"`
+	Search   string `json:"search" form:"search"` // 按评价内容搜索
+	Rating   int    `json:"rating" form:"rating" binding:"omitempty,min=1,max=5"`
+	Hidden   string `json:"hidden" form:"hidden" binding:"omitempty,oneof=true false"`
+}
+
+// HideReviewRequest 管理员隐藏评价请求
+type HideReviewRequest struct {
+	Reason string `json:"reason" binding:"required,max=200"`
+}
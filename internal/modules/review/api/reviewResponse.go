@@ -0,0 +1,67 @@
+package api
+
+import (
+	"campus/internal/models"
+	"time"
+)
+
+// ReviewResponse 评价信息
+type ReviewResponse struct {
+	ID             uint       `json:"id"`
+	OrderID        uint       `json:"order_id"`
+	UserID         uint       `json:"user_id"`
+	ProductID      uint       `json:"product_id"`
+	ProductTitle   string     `json:"product_title"`
+	Rating         int        `json:"rating"`
+	Content        string     `json:"content"`
+	ReviewerID     uint       `json:"reviewer_id"`
+	ReviewerName   string     `json:"reviewer_name"`
+	ReviewerAvatar string     `json:"reviewer_avatar"`
+	ReviewerRole   string     `json:"reviewer_role"`
+	IsHidden       bool       `json:"is_hidden,omitempty"`
+	HiddenReason   string     `json:"hidden_reason,omitempty"`
+	HiddenAt       *time.Time `json:"hidden_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ReviewListResponse 评价列表
+type ReviewListResponse struct {
+	Total int64             `json:"total"`
+	List  []*ReviewResponse `json:"list"`
+}
+
+// UserReviewListResponse 用户收到的评价列表及信誉评分
+type UserReviewListResponse struct {
+	Reputation  float64           `json:"reputation"`
+	ReviewCount int64             `json:"review_count"`
+	Total       int64             `json:"total"`
+	List        []*ReviewResponse `json:"list"`
+}
+
+func ConvertToReviewResponse(review *models.Review) *ReviewResponse {
+	return &ReviewResponse{
+		ID:             review.ID,
+		OrderID:        review.OrderID,
+		UserID:         review.UserID,
+		ProductID:      review.ProductID,
+		ProductTitle:   review.Product.Title,
+		Rating:         review.Rating,
+		Content:        review.Content,
+		ReviewerID:     review.ReviewerID,
+		ReviewerName:   review.Reviewer.Username,
+		ReviewerAvatar: review.Reviewer.Avatar,
+		ReviewerRole:   review.ReviewerRole,
+		IsHidden:       review.IsHidden,
+		HiddenReason:   review.HiddenReason,
+		HiddenAt:       review.HiddenAt,
+		CreatedAt:      review.CreatedAt,
+	}
+}
+
+func ConvertToReviewResponses(reviews []*models.Review) []*ReviewResponse {
+	list := make([]*ReviewResponse, 0, len(reviews))
+	for _, r := range reviews {
+		list = append(list, ConvertToReviewResponse(r))
+	}
+	return list
+}
@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"campus/internal/modules/review/api"
+	"campus/internal/modules/review/services"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/response"
+	"github.com/gin-gonic/gin"
+	"strconv"
+)
+
+type ReviewController struct {
+	service services.ReviewService
+}
+
+func NewReviewController(srv services.ReviewService) *ReviewController {
+	return &ReviewController{
+		service: srv,
+	}
+}
+
+// CreateReview 评价订单交易对方
+func (c *ReviewController) CreateReview(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	var req api.CreateReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	review, err := c.service.CreateReview(userID.(uint), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "评价成功", review)
+}
+
+// GetUserReviews 获取用户收到的评价
+func (c *ReviewController) GetUserReviews(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("userId"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效用户ID", err))
+		return
+	}
+
+	var req api.ReviewListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	reviews, err := c.service.GetUserReviews(uint(id), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, reviews)
+}
+
+// GetProductReviews 获取商品的评价
+func (c *ReviewController) GetProductReviews(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("productId"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效商品ID", err))
+		return
+	}
+
+	var req api.ReviewListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	reviews, err := c.service.GetProductReviews(uint(id), &req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, reviews)
+}
+
+// GetOrderReviews 买卖双方查看订单的评价
+func (c *ReviewController) GetOrderReviews(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("orderId"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效订单ID", err))
+		return
+	}
+
+	reviews, err := c.service.GetOrderReviews(uint(id), userID.(uint))
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, reviews)
+}
+
+// AdminListReviews 管理员获取评价列表
+func (c *ReviewController) AdminListReviews(ctx *gin.Context) {
+	var req api.AdminReviewListRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	reviews, err := c.service.AdminListReviews(&req)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.Success(ctx, reviews)
+}
+
+// HideReview 管理员隐藏评价
+func (c *ReviewController) HideReview(ctx *gin.Context) {
+	userID, exists := ctx.Get("user_id")
+	if !exists {
+		response.HandleError(ctx, errors.ErrUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效评价ID", err))
+		return
+	}
+
+	var req api.HideReviewRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
+		return
+	}
+
+	if err := c.service.HideReview(uint(id), userID.(uint), &req); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "评价已隐藏", nil)
+}
+
+// UnhideReview 管理员恢复显示评价
+func (c *ReviewController) UnhideReview(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效评价ID", err))
+		return
+	}
+
+	if err := c.service.UnhideReview(uint(id)); err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
+	response.SuccessWithMessage(ctx, "评价已恢复显示", nil)
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"gorm.io/gorm"
+	"math"
+)
+
+// ReviewQuery 评价查询条件，零值字段不参与过滤
+type ReviewQuery struct {
+	UserID        uint // 被评价用户
+	ProductID     uint
+	ReviewerRole  string // 评价方角色，取值见 models.ReviewRole*
+	Rating        int
+	Search        string // 按评价内容模糊搜索
+	Hidden        *bool  // 为nil时不限
+	IncludeHidden bool   // 为false时只返回未隐藏的评价
+	Page          uint
+	Size          uint
+}
+
+// Reputation 用户收到的未隐藏评价的平均分和数量
+type Reputation struct {
+	Average float64
+	Count   int64
+}
+
+type ReviewRepository interface {
+	Create(review *models.Review) error
+	GetByID(id uint) (*models.Review, error)
+	GetByOrderID(orderID uint) ([]*models.Review, error)
+	// ExistsForOrder 判断评价人是否已评价过该订单
+	ExistsForOrder(orderID, reviewerID uint) (bool, error)
+	List(query *ReviewQuery) ([]*models.Review, int64, error)
+	// GetReputation 统计用户收到的未隐藏评价的平均分（保留一位小数）和数量
+	GetReputation(userID uint) (float64, int64, error)
+	// GetReputations 按用户分组批量统计信誉评分，没有评价的用户不在结果中
+	GetReputations(userIDs []uint) (map[uint]Reputation, error)
+	UpdateHidden(id uint, updates map[string]interface{}) error
+	GetOrder(orderID uint) (*models.Order, error)
+}
+
+type ReviewRepositoryImpl struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &ReviewRepositoryImpl{
+		db: db,
+	}
+}
+
+func (r *ReviewRepositoryImpl) Create(review *models.Review) error {
+	return r.db.Create(review).Error
+}
+
+func (r *ReviewRepositoryImpl) GetByID(id uint) (*models.Review, error) {
+	var review models.Review
+	err := r.db.Preload("Reviewer").Preload("Product").First(&review, id).Error
+	return &review, err
+}
+
+// GetByOrderID 获取订单的全部评价，包括已隐藏的
+func (r *ReviewRepositoryImpl) GetByOrderID(orderID uint) ([]*models.Review, error) {
+	var reviews []*models.Review
+	err := r.db.Preload("Reviewer").Preload("Product").
+		Where("order_id = ?", orderID).
+		Order("id ASC").
+		Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *ReviewRepositoryImpl) ExistsForOrder(orderID, reviewerID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Review{}).
+		Where("order_id = ? AND reviewer_id = ?", orderID, reviewerID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// List 按条件分页查询评价，按创建时间倒序
+func (r *ReviewRepositoryImpl) List(query *ReviewQuery) ([]*models.Review, int64, error) {
+	var reviews []*models.Review
+	var total int64
+
+	db := r.db.Model(&models.Review{})
+	if query.UserID > 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.ProductID > 0 {
+		db = db.Where("product_id = ?", query.ProductID)
+	}
+	if query.ReviewerRole != "" {
+		db = db.Where("reviewer_role = ?", query.ReviewerRole)
+	}
+	if query.Rating > 0 {
+		db = db.Where("rating = ?", query.Rating)
+	}
+	if query.Search != "" {
+		db = db.Where("content LIKE ?", "%"+query.Search+"%")
+	}
+	if query.Hidden != nil {
+		db = db.Where("is_hidden = ?", *query.Hidden)
+	} else if !query.IncludeHidden {
+		db = db.Where("is_hidden = ?", false)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.Size
+	err := db.Preload("Reviewer").Preload("Product").
+		Order("created_at DESC, id DESC").
+		Offset(int(offset)).
+		Limit(int(query.Size)).
+		Find(&reviews).Error
+	return reviews, total, err
+}
+
+func (r *ReviewRepositoryImpl) GetReputation(userID uint) (float64, int64, error) {
+	var result struct {
+		Average float64
+		Count   int64
+	}
+	err := r.db.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("user_id = ? AND is_hidden = ?", userID, false).
+		Scan(&result).Error
+	return math.Round(result.Average*10) / 10, result.Count, err
+}
+
+func (r *ReviewRepositoryImpl) GetReputations(userIDs []uint) (map[uint]Reputation, error) {
+	reputations := make(map[uint]Reputation, len(userIDs))
+	if len(userIDs) == 0 {
+		return reputations, nil
+	}
+
+	var rows []struct {
+		UserID  uint
+		Average float64
+		Count   int64
+	}
+	err := r.db.Model(&models.Review{}).
+		Select("user_id, AVG(rating) AS average, COUNT(*) AS count").
+		Where("user_id IN ? AND is_hidden = ?", userIDs, false).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		reputations[row.UserID] = Reputation{Average: math.Round(row.Average*10) / 10, Count: row.Count}
+	}
+	return reputations, nil
+}
+
+func (r *ReviewRepositoryImpl) UpdateHidden(id uint, updates map[string]interface{}) error {
+	return r.db.Model(&models.Review{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// GetOrder 获取待评价的订单
+func (r *ReviewRepositoryImpl) GetOrder(orderID uint) (*models.Order, error) {
+	var order models.Order
+	err := r.db.First(&order, orderID).Error
+	return &order, err
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/testdb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetReputations(t *testing.T) {
+	db := testdb.New(t, &models.Review{})
+	repo := NewReviewRepository(db)
+
+	reviews := []models.Review{
+		{OrderID: 1, UserID: 1, ProductID: 1, ReviewerID: 10, Rating: 5},
+		{OrderID: 2, UserID: 1, ProductID: 2, ReviewerID: 11, Rating: 4},
+		{OrderID: 3, UserID: 1, ProductID: 3, ReviewerID: 12, Rating: 4},
+		{OrderID: 4, UserID: 1, ProductID: 4, ReviewerID: 13, Rating: 1, IsHidden: true},
+		{OrderID: 5, UserID: 2, ProductID: 5, ReviewerID: 10, Rating: 3},
+	}
+	require.NoError(t, db.Create(&reviews).Error)
+
+	reputations, err := repo.GetReputations([]uint{1, 2, 3})
+	require.NoError(t, err)
+
+	// 已隐藏的评价不计入，平均分保留一位小数，没有评价的用户不在结果中
+	assert.Equal(t, Reputation{Average: 4.3, Count: 3}, reputations[1])
+	assert.Equal(t, Reputation{Average: 3, Count: 1}, reputations[2])
+	assert.NotContains(t, reputations, uint(3))
+
+	// 与单个用户的统计结果一致
+	average, count, err := repo.GetReputation(1)
+	require.NoError(t, err)
+	assert.Equal(t, reputations[1], Reputation{Average: average, Count: count})
+
+	empty, err := repo.GetReputations(nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
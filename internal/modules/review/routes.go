@@ -0,0 +1,47 @@
+package review
+
+import (
+	"campus/internal/bootstrap"
+	"campus/internal/middleware"
+	"campus/internal/modules/review/controllers"
+	"campus/internal/modules/review/repositories"
+	"campus/internal/modules/review/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes 注册review模块的所有路由
+func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
+	reviewRep := repositories.NewReviewRepository(bootstrap.GetDB())
+	reviewController := controllers.NewReviewController(services.NewReviewService(reviewRep))
+
+	// 评价路由 - 需要认证
+	reviewGroup := api.Group("/reviews")
+	reviewGroup.Use(middleware.JWTAuth())
+	registerReviewRoutes(reviewGroup, reviewController)
+
+	// 管理员评价路由 - 需要管理员权限
+	adminReviewGroup := api.Group("/admin/reviews")
+	adminReviewGroup.Use(middleware.JWTAuth())
+	adminReviewGroup.Use(middleware.AuthorizeByRole("admin"))
+	registerAdminReviewRoutes(adminReviewGroup, reviewController)
+}
+
+// registerReviewRoutes 注册评价相关路由
+func registerReviewRoutes(router *gin.RouterGroup, controller *controllers.ReviewController) {
+	router.POST("", controller.CreateReview)
+	router.GET("/user/:userId", controller.GetUserReviews)
+	router.GET("/product/:productId", controller.GetProductReviews)
+	router.GET("/order/:orderId", controller.GetOrderReviews)
+}
+
+// registerAdminReviewRoutes 注册管理员评价相关路由
+func registerAdminReviewRoutes(router *gin.RouterGroup, controller *controllers.ReviewController) {
+	// 获取评价列表
+	router.GET("", middleware.AuthorizePermission("/api/v1/admin/reviews", "GET"), controller.AdminListReviews)
+
+	// 隐藏评价
+	router.PUT("/:id/hide", middleware.AuthorizePermission("/api/v1/admin/reviews/:id/hide", "PUT"), controller.HideReview)
+
+	// 恢复显示评价
+	router.PUT("/:id/unhide", middleware.AuthorizePermission("/api/v1/admin/reviews/:id/unhide", "PUT"), controller.UnhideReview)
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/review/api"
+	"campus/internal/modules/review/repositories"
+	"campus/internal/utils/errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReviewService interface {
+	CreateReview(reviewerID uint, req *api.CreateReviewRequest) (*api.ReviewResponse, error)
+	GetUserReviews(userID uint, req *api.ReviewListRequest) (*api.UserReviewListResponse, error)
+	GetProductReviews(productID uint, req *api.ReviewListRequest) (*api.ReviewListResponse, error)
+	GetOrderReviews(orderID, userID uint) ([]*api.ReviewResponse, error)
+
+	// 管理员接口
+	AdminListReviews(req *api.AdminReviewListRequest) (*api.ReviewListResponse, error)
+	HideReview(id, adminID uint, req *api.HideReviewRequest) error
+	UnhideReview(id uint) error
+}
+
+type ReviewServiceImpl struct {
+	repository repositories.ReviewRepository
+}
+
+func NewReviewService(reviewRep repositories.ReviewRepository) ReviewService {
+	return &ReviewServiceImpl{
+		repository: reviewRep,
+	}
+}
+
+// CreateReview 订单完成后，买卖双方各可评价对方一次
+func (s *ReviewServiceImpl) CreateReview(reviewerID uint, req *api.CreateReviewRequest) (*api.ReviewResponse, error) {
+	order, err := s.repository.GetOrder(req.OrderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+
+	review := &models.Review{
+		OrderID:    order.ID,
+		ProductID:  order.ProductID,
+		Rating:     req.Rating,
+		Content:    req.Content,
+		ReviewerID: reviewerID,
+	}
+	switch reviewerID {
+	case order.BuyerID:
+		review.UserID = order.SellerID
+		review.ReviewerRole = models.ReviewRoleBuyer
+	case order.SellerID:
+		review.UserID = order.BuyerID
+		review.ReviewerRole = models.ReviewRoleSeller
+	default:
+		return nil, errors.NewForbiddenError("只有订单的买卖双方可以评价", nil)
+	}
+
+	if order.Status != models.OrderStatusCompleted {
+		return nil, errors.NewConflictError("订单完成后才能评价", nil)
+	}
+
+	exists, err := s.repository.ExistsForOrder(order.ID, reviewerID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("查询评价失败", err)
+	}
+	if exists {
+		return nil, errors.NewConflictError("您已评价过该订单", nil)
+	}
+
+	if err := s.repository.Create(review); err != nil {
+		return nil, errors.NewInternalServerError("创建评价失败", err)
+	}
+
+	created, err := s.repository.GetByID(review.ID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取评价失败", err)
+	}
+	return api.ConvertToReviewResponse(created), nil
+}
+
+// GetUserReviews 获取用户收到的评价及信誉评分，不包含已隐藏的评价
+func (s *ReviewServiceImpl) GetUserReviews(userID uint, req *api.ReviewListRequest) (*api.UserReviewListResponse, error) {
+	query := &repositories.ReviewQuery{
+		UserID:       userID,
+		ReviewerRole: reviewerRoleOf(req.Role),
+		Page:         req.Page,
+		Size:         req.Size,
+	}
+
+	reviews, total, err := s.repository.List(query)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取评价列表失败", err)
+	}
+
+	reputation, count, err := s.repository.GetReputation(userID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取信誉评分失败", err)
+	}
+
+	return &api.UserReviewListResponse{
+		Reputation:  reputation,
+		ReviewCount: count,
+		Total:       total,
+		List:        api.ConvertToReviewResponses(reviews),
+	}, nil
+}
+
+// GetProductReviews 获取商品交易产生的评价，不包含已隐藏的评价
+func (s *ReviewServiceImpl) GetProductReviews(productID uint, req *api.ReviewListRequest) (*api.ReviewListResponse, error) {
+	query := &repositories.ReviewQuery{
+		ProductID:    productID,
+		ReviewerRole: reviewerRoleOf(req.Role),
+		Page:         req.Page,
+		Size:         req.Size,
+	}
+
+	reviews, total, err := s.repository.List(query)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取评价列表失败", err)
+	}
+
+	return &api.ReviewListResponse{
+		Total: total,
+		List:  api.ConvertToReviewResponses(reviews),
+	}, nil
+}
+
+// GetOrderReviews 获取订单的双方评价，仅买卖双方可查看
+func (s *ReviewServiceImpl) GetOrderReviews(orderID, userID uint) ([]*api.ReviewResponse, error) {
+	order, err := s.repository.GetOrder(orderID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("订单", err)
+	}
+	if order.BuyerID != userID && order.SellerID != userID {
+		return nil, errors.NewForbiddenError("无权查看该订单的评价", nil)
+	}
+
+	reviews, err := s.repository.GetByOrderID(orderID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取评价失败", err)
+	}
+	return api.ConvertToReviewResponses(reviews), nil
+}
+
+// AdminListReviews 管理员分页获取评价，包括已隐藏的评价
+func (s *ReviewServiceImpl) AdminListReviews(req *api.AdminReviewListRequest) (*api.ReviewListResponse, error) {
+	// 设置默认值
+	if req.Page == 0 {
+		req.Page = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 10
+	}
+
+	query := &repositories.ReviewQuery{
+		Rating:        req.Rating,
+		Search:        req.Search,
+		IncludeHidden: true,
+		Page:          req.Page,
+		Size:          req.PageSize,
+	}
+	if req.Hidden != "" {
+		hidden := req.Hidden == "true"
+		query.Hidden = &hidden
+	}
+
+	reviews, total, err := s.repository.List(query)
+	if err != nil {
+		return nil, errors.NewInternalServerError("获取评价列表失败", err)
+	}
+
+	return &api.ReviewListResponse{
+		Total: total,
+		List:  api.ConvertToReviewResponses(reviews),
+	}, nil
+}
+
+// HideReview 管理员隐藏违规评价，隐藏后不再展示也不计入信誉评分
+func (s *ReviewServiceImpl) HideReview(id, adminID uint, req *api.HideReviewRequest) error {
+	if _, err := s.getReview(id); err != nil {
+		return err
+	}
+
+	err := s.repository.UpdateHidden(id, map[string]interface{}{
+		"is_hidden":     true,
+		"hidden_reason": req.Reason,
+		"hidden_by":     adminID,
+		"hidden_at":     time.Now(),
+	})
+	if err != nil {
+		return errors.NewInternalServerError("隐藏评价失败", err)
+	}
+	return nil
+}
+
+// UnhideReview 管理员恢复显示评价
+func (s *ReviewServiceImpl) UnhideReview(id uint) error {
+	if _, err := s.getReview(id); err != nil {
+		return err
+	}
+
+	err := s.repository.UpdateHidden(id, map[string]interface{}{
+		"is_hidden":     false,
+		"hidden_reason": "",
+		"hidden_by":     0,
+		"hidden_at":     nil,
+	})
+	if err != nil {
+		return errors.NewInternalServerError("恢复评价失败", err)
+	}
+	return nil
+}
+
+func (s *ReviewServiceImpl) getReview(id uint) (*models.Review, error) {
+	review, err := s.repository.GetByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("评价", err)
+		}
+		return nil, errors.NewInternalServerError("查询评价失败", err)
+	}
+	return review, nil
+}
+
+// reviewerRoleOf 被评价用户作为卖家时评价来自买家，反之亦然；role为空时不限
+func reviewerRoleOf(role string) string {
+	switch role {
+	case models.ReviewRoleSeller:
+		return models.ReviewRoleBuyer
+	case models.ReviewRoleBuyer:
+		return models.ReviewRoleSeller
+	}
+	return ""
+}
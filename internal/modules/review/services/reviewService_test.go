@@ -0,0 +1,128 @@
+package services
+
+import (
+	"campus/internal/models"
+	"campus/internal/modules/review/api"
+	"campus/internal/modules/review/repositories"
+	"campus/internal/utils/errors"
+	"campus/internal/utils/testdb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// 测试数据中的买家、卖家、其他用户和管理员
+const (
+	testBuyerID  uint = 1
+	testSellerID uint = 2
+	testOtherID  uint = 3
+	testAdminID  uint = 99
+)
+
+// newTestReviewService 使用内存SQLite创建评价服务，并写入一个已完成的订单
+func newTestReviewService(t *testing.T) (ReviewService, *gorm.DB, *models.Order) {
+	db := testdb.New(t, &models.User{}, &models.Product{}, &models.Order{}, &models.Review{})
+
+	for _, user := range []models.User{
+		{Model: gorm.Model{ID: testBuyerID}, Username: "buyer", Password: "x", Email: "buyer@example.com"},
+		{Model: gorm.Model{ID: testSellerID}, Username: "seller", Password: "x", Email: "seller@example.com"},
+		{Model: gorm.Model{ID: testOtherID}, Username: "other", Password: "x", Email: "other@example.com"},
+	} {
+		require.NoError(t, db.Create(&user).Error)
+	}
+	product := models.Product{Title: "台灯", Price: 100, UserID: testSellerID, Status: models.ProductStatusSold}
+	require.NoError(t, db.Create(&product).Error)
+	order := models.Order{BuyerID: testBuyerID, SellerID: testSellerID, ProductID: product.ID,
+		Status: models.OrderStatusCompleted}
+	require.NoError(t, db.Create(&order).Error)
+
+	return NewReviewService(repositories.NewReviewRepository(db)), db, &order
+}
+
+func TestCreateReview(t *testing.T) {
+	service, _, order := newTestReviewService(t)
+
+	resp, err := service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 5, Content: "很好"})
+	require.NoError(t, err)
+	assert.Equal(t, testSellerID, resp.UserID)
+	assert.Equal(t, models.ReviewRoleBuyer, resp.ReviewerRole)
+	assert.Equal(t, "buyer", resp.ReviewerName)
+	assert.Equal(t, "台灯", resp.ProductTitle)
+
+	resp, err = service.CreateReview(testSellerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 4})
+	require.NoError(t, err)
+	assert.Equal(t, testBuyerID, resp.UserID)
+	assert.Equal(t, models.ReviewRoleSeller, resp.ReviewerRole)
+
+	// 每方只能评价一次
+	_, err = service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 1})
+	assert.True(t, errors.IsConflict(err))
+}
+
+func TestCreateReviewRejected(t *testing.T) {
+	service, db, order := newTestReviewService(t)
+
+	_, err := service.CreateReview(testOtherID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 5})
+	assert.True(t, errors.IsForbidden(err))
+
+	_, err = service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID + 1, Rating: 5})
+	assert.True(t, errors.IsNotFound(err))
+
+	require.NoError(t, db.Model(order).Update("status", models.OrderStatusAwaitReceipt).Error)
+	_, err = service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 5})
+	assert.True(t, errors.IsConflict(err))
+}
+
+func TestHiddenReviewsExcludedFromReputation(t *testing.T) {
+	service, db, order := newTestReviewService(t)
+
+	first, err := service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 1, Content: "违规内容"})
+	require.NoError(t, err)
+	second := models.Order{BuyerID: testOtherID, SellerID: testSellerID, ProductID: order.ProductID,
+		Status: models.OrderStatusCompleted}
+	require.NoError(t, db.Create(&second).Error)
+	_, err = service.CreateReview(testOtherID, &api.CreateReviewRequest{OrderID: second.ID, Rating: 5})
+	require.NoError(t, err)
+
+	list, err := service.GetUserReviews(testSellerID, &api.ReviewListRequest{Page: 1, Size: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, list.Reputation)
+	assert.Equal(t, int64(2), list.ReviewCount)
+
+	require.NoError(t, service.HideReview(first.ID, testAdminID, &api.HideReviewRequest{Reason: "辱骂"}))
+
+	list, err = service.GetUserReviews(testSellerID, &api.ReviewListRequest{Page: 1, Size: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, list.Reputation)
+	assert.Equal(t, int64(1), list.ReviewCount)
+	assert.Equal(t, int64(1), list.Total)
+
+	// 管理员仍能看到已隐藏的评价
+	hidden, err := service.AdminListReviews(&api.AdminReviewListRequest{Hidden: "true"})
+	require.NoError(t, err)
+	require.Len(t, hidden.List, 1)
+	assert.Equal(t, "辱骂", hidden.List[0].HiddenReason)
+
+	require.NoError(t, service.UnhideReview(first.ID))
+	list, err = service.GetUserReviews(testSellerID, &api.ReviewListRequest{Page: 1, Size: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.ReviewCount)
+
+	assert.True(t, errors.IsNotFound(service.HideReview(first.ID+100, testAdminID, &api.HideReviewRequest{Reason: "x"})))
+}
+
+func TestGetOrderReviews(t *testing.T) {
+	service, _, order := newTestReviewService(t)
+
+	_, err := service.CreateReview(testBuyerID, &api.CreateReviewRequest{OrderID: order.ID, Rating: 5})
+	require.NoError(t, err)
+
+	reviews, err := service.GetOrderReviews(order.ID, testSellerID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 1)
+
+	_, err = service.GetOrderReviews(order.ID, testOtherID)
+	assert.True(t, errors.IsForbidden(err))
+}
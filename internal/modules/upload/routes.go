@@ -37,6 +37,11 @@ func RegisterRoutes(r *gin.Engine, api *gin.RouterGroup) {
 	messageUploadGroup.Use(middleware.JWTAuth())
 	messageUploadGroup.POST("/upload", uploadController.UploadImage)
 
+	// 为订单售后添加凭证上传路由
+	orderUploadGroup := api.Group("/order")
+	orderUploadGroup.Use(middleware.JWTAuth())
+	orderUploadGroup.POST("/disputes/upload", uploadController.UploadImage)
+
 	// 添加静态文件服务，使用配置文件中的上传路径
 	config := bootstrap.GetConfig()
 	r.Static("/static", config.Upload.SavePath)
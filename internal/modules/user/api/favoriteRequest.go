@@ -1,5 +1,7 @@
 package api
 
+import "campus/internal/utils/pagination"
+
 // FavoriteRequest 收藏商品的请求
 type FavoriteRequest struct {
 	ProductID uint `json:"product_id" binding:"required"`
@@ -7,6 +9,5 @@ type FavoriteRequest struct {
 
 // QueryPageRequest 分页查询请求参数
 type QueryPageRequest struct {
-	Page uint `form:"page" binding:"required,min=1"`
-	Size uint `form:"size" binding:"required,min=1,max=100"`
+	pagination.Request
 }
@@ -3,6 +3,7 @@ package api
 import (
 	"campus/internal/models"
 	"campus/internal/modules/product/api"
+	"campus/internal/utils/pagination"
 	"time"
 )
 
@@ -17,9 +18,7 @@ type FavoriteResponse struct {
 // FavoriteListResponse 收藏列表响应
 type FavoriteListResponse struct {
 	Favorites []FavoriteResponse `json:"favorites"`
-	Total     int64              `json:"total"`
-	Page      uint               `json:"page"`
-	Size      uint               `json:"size"`
+	pagination.Info
 }
 
 // ConvertToFavoriteResponse 将数据库模型转换为响应对象
@@ -33,7 +32,7 @@ func ConvertToFavoriteResponse(favorite *models.Favorite) FavoriteResponse {
 }
 
 // ConvertToFavoriteListResponse 将收藏列表转换为响应对象
-func ConvertToFavoriteListResponse(favorites []*models.Favorite, total int64, page, size uint) FavoriteListResponse {
+func ConvertToFavoriteListResponse(favorites []*models.Favorite, info pagination.Info) FavoriteListResponse {
 	var responses []FavoriteResponse
 	for _, favorite := range favorites {
 		responses = append(responses, ConvertToFavoriteResponse(favorite))
@@ -41,8 +40,6 @@ func ConvertToFavoriteListResponse(favorites []*models.Favorite, total int64, pa
 
 	return FavoriteListResponse{
 		Favorites: responses,
-		Total:     total,
-		Page:      page,
-		Size:      size,
+		Info:      info,
 	}
 }
@@ -44,6 +44,7 @@ type PasswordUpdate struct {
 
 // AdminUserListQuery 管理员用户列表查询参数
 type AdminUserListQuery struct {
+	Cursor    string `form:"cursor" json:"cursor"`        // 分页游标，传入时使用游标分页
 	Page      int    `form:"page" json:"page"`            // 页码
 	Size      int    `form:"size" json:"pageSize"`        // 每页数量
 	Search    string `form:"search" json:"search"`        // 搜索关键词
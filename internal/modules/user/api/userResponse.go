@@ -1,6 +1,9 @@
 package api
 
-import "time"
+import (
+	"campus/internal/utils/pagination"
+	"time"
+)
 
 // UserResponse 用户信息响应
 type UserResponse struct {
@@ -14,24 +17,22 @@ type UserResponse struct {
 	Description  string    `json:"description"`
 	Status       string    `json:"status"` // 用户状态
 	ProductCount int       `json:"product_count"` // 用户发布的产品数量
+	Reputation   float64   `json:"reputation"`    // 信誉评分，收到评价的平均分
+	ReviewCount  int64     `json:"review_count"`  // 收到的评价数量
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
 // UserListResponse 用户列表响应
 type UserListResponse struct {
-	Total int64          `json:"total"`
-	List  []UserResponse `json:"list"`
-	Page  int            `json:"page"`
-	Size  int            `json:"size"`
+	List []UserResponse `json:"list"`
+	pagination.Info
 }
 
 // AdminUserListResponse 管理员用户列表响应
 type AdminUserListResponse struct {
-	Total int64                `json:"total"`
-	List  []AdminUserResponse  `json:"list"`
-	Page  int                  `json:"page"`
-	Size  int                  `json:"size"`
+	List []AdminUserResponse `json:"list"`
+	pagination.Info
 }
 
 // AdminUserResponse 管理员用户信息响应
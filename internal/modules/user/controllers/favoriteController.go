@@ -65,7 +65,14 @@ func (f *FavoriteController) ListFavorites(ctx *gin.Context) {
 		response.HandleError(ctx, errors.NewValidationError("请求参数错误", err))
 		return
 	}
-	favorites, err := f.service.ListUserFavorites(userID.(uint), req.Page, req.Size)
+
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	favorites, err := f.service.ListUserFavorites(userID.(uint), params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -109,7 +116,13 @@ func (f *FavoriteController) ListUserProducts(ctx *gin.Context) {
 		return
 	}
 
-	products, err := f.service.GetUserProducts(userID.(uint), req.Page, req.Size)
+	params, err := req.Params()
+	if err != nil {
+		response.HandleError(ctx, errors.NewBadRequestError("无效的分页游标", err))
+		return
+	}
+
+	products, err := f.service.GetUserProducts(userID.(uint), params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
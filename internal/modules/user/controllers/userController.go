@@ -4,6 +4,7 @@ import (
 	"campus/internal/modules/user/api"
 	"campus/internal/modules/user/services"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/pagination"
 	"campus/internal/utils/response"
 	"github.com/gin-gonic/gin"
 
@@ -164,17 +165,15 @@ func (c *UserController) ListUsers(ctx *gin.Context) {
 		return
 	}
 
+	params, err := userListParams(&query)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
+	}
+
 	// 如果没有使用高级查询参数，使用基本列表功能
 	if query.Search == "" && query.Status == "" && query.StartDate == "" && query.EndDate == "" {
-		// 设置默认值
-		if query.Page <= 0 {
-			query.Page = 1
-		}
-		if query.Size <= 0 {
-			query.Size = 10
-		}
-
-		result, err := c.userService.List(query.Page, query.Size)
+		result, err := c.userService.List(params)
 		if err != nil {
 			response.HandleError(ctx, err)
 			return
@@ -184,7 +183,7 @@ func (c *UserController) ListUsers(ctx *gin.Context) {
 	}
 
 	// 使用高级查询
-	result, err := c.userService.AdminList(&query)
+	result, err := c.userService.AdminList(&query, params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -203,16 +202,14 @@ func (c *UserController) AdminListUsers(ctx *gin.Context) {
 		return
 	}
 
-	// 设置默认值
-	if query.Page <= 0 {
-		query.Page = 1
-	}
-	if query.Size <= 0 {
-		query.Size = 10
+	params, err := userListParams(&query)
+	if err != nil {
+		response.HandleError(ctx, err)
+		return
 	}
 
 	// 调用服务层方法
-	result, err := c.userService.AdminList(&query)
+	result, err := c.userService.AdminList(&query, params)
 	if err != nil {
 		response.HandleError(ctx, err)
 		return
@@ -221,6 +218,23 @@ func (c *UserController) AdminListUsers(ctx *gin.Context) {
 	response.SuccessWithMessage(ctx, "获取成功", result)
 }
 
+// userListParams 解析用户列表的分页参数。
+// 传入cursor时使用游标分页，否则保持原有的page/size分页，默认第一页每页10条
+func userListParams(query *api.AdminUserListQuery) (pagination.Params, error) {
+	if query.Cursor == "" && query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Size <= 0 {
+		query.Size = 10
+	}
+
+	params, err := pagination.NewParams(query.Cursor, uint(query.Page), uint(query.Size))
+	if err != nil {
+		return params, errors.NewBadRequestError("无效的分页游标", err)
+	}
+	return params, nil
+}
+
 // UpdateUserStatus 更新用户状态
 func (c *UserController) UpdateUserStatus(ctx *gin.Context) {
 	// 获取用户ID
@@ -3,6 +3,7 @@ package repositories
 import (
 	"campus/internal/bootstrap"
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +13,8 @@ type FavoriteRepository interface {
 	Create(favorite *models.Favorite) error
 	// Delete 删除收藏记录
 	Delete(userID, productID uint) error
-	// GetByUser 获取用户的所有收藏
-	GetByUser(userID uint, page, size uint) ([]*models.Favorite, int64, error)
+	// GetByUser 分页获取用户的收藏，最近收藏的在前
+	GetByUser(userID uint, p pagination.Params) ([]*models.Favorite, pagination.Info, error)
 	// CheckIsFavorite 检查是否已收藏
 	CheckIsFavorite(userID, productID uint) (bool, error)
 }
@@ -40,25 +41,30 @@ func (r *FavoriteRepositoryImpl) Delete(userID, productID uint) error {
 	return r.db.Where("user_id = ? AND product_id = ?", userID, productID).Delete(&models.Favorite{}).Error
 }
 
-// GetByUser 获取用户的所有收藏
-func (r *FavoriteRepositoryImpl) GetByUser(userID uint, page, size uint) ([]*models.Favorite, int64, error) {
+// GetByUser 分页获取用户的收藏，最近收藏的在前
+func (r *FavoriteRepositoryImpl) GetByUser(userID uint, p pagination.Params) ([]*models.Favorite, pagination.Info, error) {
 	var favorites []*models.Favorite
 	var total int64
 
-	err := r.db.Model(&models.Favorite{}).Where("user_id = ?", userID).Count(&total).Error
-	if err != nil {
-		return nil, 0, err
+	if !p.CursorMode() {
+		err := r.db.Model(&models.Favorite{}).Where("user_id = ?", userID).Count(&total).Error
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
 	}
 
-	offset := (page - 1) * size
-	err = r.db.Where("user_id = ?", userID).
+	err := pagination.Apply(r.db.Where("user_id = ?", userID), p, "").
 		Preload("Product").
 		Preload("Product.ProductImages").
-		Offset(int(offset)).
-		Limit(int(size)).
 		Find(&favorites).Error
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
 
-	return favorites, total, err
+	favorites, info := pagination.Trim(favorites, p, total, func(f *models.Favorite) pagination.Cursor {
+		return pagination.ModelCursor(f.Model)
+	})
+	return favorites, info, nil
 }
 
 // CheckIsFavorite 检查是否已收藏
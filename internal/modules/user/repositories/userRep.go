@@ -3,6 +3,7 @@ package repositories
 import (
 	"campus/internal/bootstrap"
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"gorm.io/gorm"
 	"time"
 )
@@ -14,8 +15,8 @@ type UserRepository interface {
 	GetByEmail(email string) (*models.User, error)
 	Update(user *models.User) error
 	Delete(id uint) error
-	List(page, pageSize int) ([]*models.User, int64, error)
-	ListForAdmin(page, pageSize int, search, status, startDate, endDate string) ([]*models.User, int64, error)
+	List(p pagination.Params) ([]*models.User, pagination.Info, error)
+	ListForAdmin(p pagination.Params, search, status, startDate, endDate string) ([]*models.User, pagination.Info, error)
 	UpdateStatus(userID uint, status string) error
 }
 
@@ -59,17 +60,27 @@ func (u *userRepository) Delete(id uint) error {
 	return u.db.Delete(&models.User{}, id).Error
 }
 
-func (u *userRepository) List(page, pageSize int) ([]*models.User, int64, error) {
+func (u *userRepository) List(p pagination.Params) ([]*models.User, pagination.Info, error) {
+	return paginateUsers(u.db.Model(&models.User{}), p)
+}
+
+// paginateUsers 按注册时间倒序分页查询用户，page/size模式下同时统计总数
+func paginateUsers(query *gorm.DB, p pagination.Params) ([]*models.User, pagination.Info, error) {
 	var userList []*models.User
-	var tot int64
-	offset := (page - 1) * pageSize
-	if err := u.db.Model(&models.User{}).Count(&tot).Error; err != nil {
-		return nil, 0, err
+	var total int64
+	if !p.CursorMode() {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, pagination.Info{}, err
+		}
 	}
-	if err := u.db.Offset(offset).Limit(pageSize).Find(&userList).Error; err != nil {
-		return nil, 0, err
+	if err := pagination.Apply(query, p, "").Find(&userList).Error; err != nil {
+		return nil, pagination.Info{}, err
 	}
-	return userList, tot, nil
+
+	userList, info := pagination.Trim(userList, p, total, func(user *models.User) pagination.Cursor {
+		return pagination.ModelCursor(user.Model)
+	})
+	return userList, info, nil
 }
 
 // UpdateStatus 更新用户状态
@@ -78,10 +89,7 @@ func (u *userRepository) UpdateStatus(userID uint, status string) error {
 }
 
 // ListForAdmin 管理员用户列表高级查询
-func (u *userRepository) ListForAdmin(page, pageSize int, search, status, startDate, endDate string) ([]*models.User, int64, error) {
-	var userList []*models.User
-	var total int64
-
+func (u *userRepository) ListForAdmin(p pagination.Params, search, status, startDate, endDate string) ([]*models.User, pagination.Info, error) {
 	query := u.db.Model(&models.User{})
 
 	// 添加搜索条件
@@ -112,18 +120,7 @@ func (u *userRepository) ListForAdmin(page, pageSize int, search, status, startD
 		}
 	}
 
-	// 先获取总数
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// 然后获取分页数据
-	offset := (page - 1) * pageSize
-	if err := query.Offset(offset).Limit(pageSize).Find(&userList).Error; err != nil {
-		return nil, 0, err
-	}
-
-	return userList, total, nil
+	return paginateUsers(query, p)
 }
 
 func NewUserRepository() UserRepository {
@@ -4,6 +4,7 @@ import (
 	"campus/internal/config"
 	"campus/internal/database"
 	"campus/internal/models"
+	"campus/internal/utils/pagination"
 	"fmt"
 	"golang.org/x/crypto/bcrypt"
 	"os"
@@ -322,22 +323,22 @@ func (suite *RealDatabaseTestSuite) TestDelete() {
 // TestList 测试列出用户
 func (suite *RealDatabaseTestSuite) TestList() {
 	// 测试第一页，每页1条记录
-	users, total, err := suite.repository.List(1, 1)
+	users, info, err := suite.repository.List(pagination.Params{Page: 1, Size: 1})
 	assert.NoError(suite.T(), err)
-	assert.GreaterOrEqual(suite.T(), total, int64(2)) // 至少有2条测试记录
-	assert.Equal(suite.T(), 1, len(users))            // 返回1条记录
+	assert.GreaterOrEqual(suite.T(), info.Total, int64(2)) // 至少有2条测试记录
+	assert.Equal(suite.T(), 1, len(users))                 // 返回1条记录
 
 	// 测试第二页，每页1条记录
-	users, total, err = suite.repository.List(2, 1)
+	users, info, err = suite.repository.List(pagination.Params{Page: 2, Size: 1})
 	assert.NoError(suite.T(), err)
-	assert.GreaterOrEqual(suite.T(), total, int64(2)) // 至少有2条测试记录
-	assert.Equal(suite.T(), 1, len(users))            // 返回1条记录
+	assert.GreaterOrEqual(suite.T(), info.Total, int64(2)) // 至少有2条测试记录
+	assert.Equal(suite.T(), 1, len(users))                 // 返回1条记录
 
 	// 测试每页10条记录
-	users, total, err = suite.repository.List(1, 10)
+	users, info, err = suite.repository.List(pagination.Params{Page: 1, Size: 10})
 	assert.NoError(suite.T(), err)
-	assert.GreaterOrEqual(suite.T(), total, int64(2)) // 至少有2条测试记录
-	assert.GreaterOrEqual(suite.T(), len(users), 2)   // 至少返回2条记录
+	assert.GreaterOrEqual(suite.T(), info.Total, int64(2)) // 至少有2条测试记录
+	assert.GreaterOrEqual(suite.T(), len(users), 2)        // 至少返回2条记录
 }
 
 // TestRealDatabaseSuite 运行测试套件
@@ -7,6 +7,7 @@ import (
 	"campus/internal/modules/user/api"
 	userRep "campus/internal/modules/user/repositories"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/pagination"
 )
 
 type FavoriteService interface {
@@ -15,11 +16,11 @@ type FavoriteService interface {
 	// RemoveFavorite 取消收藏
 	RemoveFavorite(userID uint, productID uint) error
 	// ListUserFavorites 获取用户收藏列表
-	ListUserFavorites(userID uint, page, size uint) (*api.FavoriteListResponse, error)
+	ListUserFavorites(userID uint, p pagination.Params) (*api.FavoriteListResponse, error)
 	// CheckIsFavorite 检查是否已收藏
 	CheckIsFavorite(userID uint, productID uint) (bool, error)
 	// GetUserProducts 获取用户发布的商品
-	GetUserProducts(userID uint, page, size uint) (*api2.ProductListResponse, error)
+	GetUserProducts(userID uint, p pagination.Params) (*api2.ProductListResponse, error)
 }
 
 type favoriteService struct {
@@ -68,12 +69,12 @@ func (f *favoriteService) RemoveFavorite(userID uint, productID uint) error {
 }
 
 // ListUserFavorites 获取用户收藏列表
-func (f *favoriteService) ListUserFavorites(userID uint, page, size uint) (*api.FavoriteListResponse, error) {
-	favorites, tot, err := f.favoriteRepo.GetByUser(userID, page, size)
+func (f *favoriteService) ListUserFavorites(userID uint, p pagination.Params) (*api.FavoriteListResponse, error) {
+	favorites, info, err := f.favoriteRepo.GetByUser(userID, p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取收藏列表失败", err)
 	}
-	response := api.ConvertToFavoriteListResponse(favorites, tot, page, size)
+	response := api.ConvertToFavoriteListResponse(favorites, info)
 	return &response, err
 }
 
@@ -85,25 +86,13 @@ func (f *favoriteService) CheckIsFavorite(userID uint, productID uint) (bool, er
 	return isFavorite, nil
 }
 
-func (f *favoriteService) GetUserProducts(userID uint, page, size uint) (*api2.ProductListResponse, error) {
-	products, tot, err := f.productRepo.GetByUserID(userID, page, size)
+func (f *favoriteService) GetUserProducts(userID uint, p pagination.Params) (*api2.ProductListResponse, error) {
+	products, info, err := f.productRepo.ListByUserID(userID, "", p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取用户发布的商品失败", err)
 	}
 
-	var response api2.ProductListResponse
-	var productResponse []*api2.ProductResponse
-
-	for _, product := range products {
-		productResponse = append(productResponse, api2.ConvertToProductResponse(product))
-	}
-	response = api2.ProductListResponse{
-		Products: productResponse,
-		Total:    uint(tot),
-		Page:     page,
-		Size:     size,
-	}
-	return &response, nil
+	return api2.ConvertToProductListResponse(products, info), nil
 }
 
 func NewFavoriteService() FavoriteService {
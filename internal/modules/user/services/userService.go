@@ -4,9 +4,11 @@ import (
 	"campus/internal/bootstrap"
 	"campus/internal/models"
 	"campus/internal/modules/product/repositories"
+	reviewRepo "campus/internal/modules/review/repositories"
 	"campus/internal/modules/user/api"
 	userRepo "campus/internal/modules/user/repositories"
 	"campus/internal/utils/errors"
+	"campus/internal/utils/pagination"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -20,8 +22,8 @@ type UserService interface {
 	GetByID(id uint) (*api.UserResponse, error)
 	UpdateUser(id uint, dto api.UserUpdate) (*api.UserResponse, error)
 	ChangePassword(id uint, oldPassword, newPassword string) error
-	List(page, pageSize int) (*api.UserListResponse, error)
-	AdminList(query *api.AdminUserListQuery) (*api.AdminUserListResponse, error)
+	List(p pagination.Params) (*api.UserListResponse, error)
+	AdminList(query *api.AdminUserListQuery, p pagination.Params) (*api.AdminUserListResponse, error)
 	UpdateStatus(id uint, status string) error
 	ResetPassword(id uint) (*api.ResetPasswordResponse, error)
 	GetUserDetail(id uint) (*api.UserDetailResponse, error)
@@ -29,6 +31,7 @@ type UserService interface {
 type userService struct {
 	userRep    userRepo.UserRepository
 	productRep repositories.ProductRepository
+	reviewRep  reviewRepo.ReviewRepository
 }
 
 // convertToUserResponse 将User模型转换为UserResponse
@@ -52,6 +55,8 @@ func convertToUserResponse(user *models.User) *api.UserResponse {
 		Description:  user.Description,
 		Status:       user.Status,
 		ProductCount: user.ProductCount,
+		Reputation:   user.Reputation,
+		ReviewCount:  user.ReviewCount,
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 	}
@@ -237,9 +242,43 @@ func (u *userService) GetByID(id uint) (*api.UserResponse, error) {
 		user.ProductCount = int(total)
 	}
 
+	// 获取用户信誉评分
+	u.loadReputation(user)
+
 	return convertToUserResponse(user), nil
 }
 
+// loadReputation 统计用户收到的评价，填充信誉评分和评价数量
+func (u *userService) loadReputation(user *models.User) {
+	reputation, count, err := u.reviewRep.GetReputation(user.ID)
+	if err != nil {
+		fmt.Printf("获取用户信誉评分失败: %v\n", err)
+		return
+	}
+	user.Reputation = reputation
+	user.ReviewCount = count
+}
+
+// loadReputations 按用户分组一次统计列表中所有用户的信誉评分
+func (u *userService) loadReputations(users []*models.User) {
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+
+	reputations, err := u.reviewRep.GetReputations(ids)
+	if err != nil {
+		fmt.Printf("获取用户信誉评分失败: %v\n", err)
+		return
+	}
+	for _, user := range users {
+		if reputation, ok := reputations[user.ID]; ok {
+			user.Reputation = reputation.Average
+			user.ReviewCount = reputation.Count
+		}
+	}
+}
+
 func (u *userService) UpdateUser(id uint, data api.UserUpdate) (*api.UserResponse, error) {
 	user, err := u.userRep.GetByID(id)
 	if err != nil {
@@ -301,8 +340,8 @@ func (u *userService) ChangePassword(id uint, oldPassword, newPassword string) e
 }
 
 // List 获取用户列表
-func (u *userService) List(page, pageSize int) (*api.UserListResponse, error) {
-	users, total, err := u.userRep.List(page, pageSize)
+func (u *userService) List(p pagination.Params) (*api.UserListResponse, error) {
+	users, info, err := u.userRep.List(p)
 	if err != nil {
 		return nil, errors.NewInternalServerError("获取用户列表失败", err)
 	}
@@ -322,33 +361,25 @@ func (u *userService) List(page, pageSize int) (*api.UserListResponse, error) {
 		}
 	}
 
+	// 批量获取用户信誉评分
+	u.loadReputations(users)
+
 	userResponses := make([]api.UserResponse, 0, len(users))
 	for _, user := range users {
 		userResponses = append(userResponses, *convertToUserResponse(user))
 	}
 
 	return &api.UserListResponse{
-		List:  userResponses,
-		Total: total,
-		Page:  page,
-		Size:  pageSize,
+		List: userResponses,
+		Info: info,
 	}, nil
 }
 
 // AdminList 管理员获取用户列表（支持高级搜索）
-func (u *userService) AdminList(query *api.AdminUserListQuery) (*api.AdminUserListResponse, error) {
-	// 设置默认值
-	if query.Page <= 0 {
-		query.Page = 1
-	}
-	if query.Size <= 0 {
-		query.Size = 10
-	}
-
+func (u *userService) AdminList(query *api.AdminUserListQuery, p pagination.Params) (*api.AdminUserListResponse, error) {
 	// 调用仓库层的高级查询方法
-	users, total, err := u.userRep.ListForAdmin(
-		query.Page,
-		query.Size,
+	users, info, err := u.userRep.ListForAdmin(
+		p,
 		query.Search,
 		query.Status,
 		query.StartDate,
@@ -374,10 +405,8 @@ func (u *userService) AdminList(query *api.AdminUserListQuery) (*api.AdminUserLi
 	}
 
 	return &api.AdminUserListResponse{
-		Total: total,
-		List:  userResponses,
-		Page:  query.Page,
-		Size:  query.Size,
+		List: userResponses,
+		Info: info,
 	}, nil
 }
 
@@ -551,5 +580,6 @@ func NewUserService() UserService {
 	return &userService{
 		userRep:    userRepo.NewUserRepository(),
 		productRep: repositories.NewProductRepository(),
+		reviewRep:  reviewRepo.NewReviewRepository(bootstrap.GetDB()),
 	}
 }
@@ -98,20 +98,29 @@ func processMessage(d amqp.Delivery, wsManager *websocket.Manager) {
 	receiverID := msgResponse.ReceiverID
 	logger.Debug("Processing message for user", zap.Uint("userID", receiverID))
 
-	if wsManager.IsUserOnline(receiverID) {
-		logger.Debug("User is online, attempting to send via WebSocket", zap.Uint("userID", receiverID))
-		if ok := wsManager.SendMessage(receiverID, d.Body); ok {
+	payload, err := websocket.EncodeFrame(websocket.FrameMessage, "", json.RawMessage(d.Body))
+	if err != nil {
+		logger.Error("Failed to encode message frame", zap.Error(err))
+		d.Nack(false, false)
+		return
+	}
+	delivery := websocket.Delivery{ID: msgResponse.ID, SenderID: msgResponse.SenderID, Payload: payload}
+
+	// 消息已保存在数据库中，离线设备重连时按最后收到的消息ID补发，
+	// 因此这里只推送给当前在线的设备，无论是否推送成功都确认消息，避免队列堆积
+	if receiverID == 0 {
+		// 全员系统消息
+		wsManager.Broadcast(delivery)
+	} else {
+		if wsManager.Deliver(receiverID, delivery) {
 			logger.Info("Successfully sent message via WebSocket", zap.Uint("userID", receiverID))
-			d.Ack(false) // Acknowledge the message
 		} else {
-			logger.Warn("Failed to send message via WebSocket, user might have just disconnected.", zap.Uint("userID", receiverID))
-			d.Nack(false, true) // Requeue the message
+			logger.Debug("User is offline, message will be replayed on reconnect.", zap.Uint("userID", receiverID))
+		}
+		// 同步到发送者的其他设备
+		if msgResponse.SenderID != 0 {
+			wsManager.Deliver(msgResponse.SenderID, delivery)
 		}
-	} else {
-		logger.Debug("User is offline. Discarding message from queue since it's already in DB.", zap.Uint("userID", receiverID))
-		// If user is offline, we still Ack the message to remove it from the queue.
-		// The authoritative store is the database, which the user will query for history.
-		// This prevents the queue from filling with messages for offline users.
-		d.Ack(false)
 	}
+	d.Ack(false)
 }
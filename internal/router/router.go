@@ -2,11 +2,13 @@ package router
 
 import (
 	"campus/internal/bootstrap"
+	Category "campus/internal/modules/category"
 	Dashboard "campus/internal/modules/dashboard"
 	Message "campus/internal/modules/message"
 	Order "campus/internal/modules/order"
 	Permission "campus/internal/modules/permission"
 	Product "campus/internal/modules/product"
+	Review "campus/internal/modules/review"
 	Upload "campus/internal/modules/upload"
 	User "campus/internal/modules/user"
 	"github.com/gin-gonic/gin"
@@ -23,8 +25,7 @@ func RegisterRoutes(r *gin.Engine) {
 
 // registerModuleRoutes 注册各个模块的路由
 func registerModuleRoutes(r *gin.Engine, api *gin.RouterGroup) {
-	// 获取配置和WebSocket管理器
-	config := bootstrap.GetConfig()
+	// 获取WebSocket管理器
 	wsManager := bootstrap.GetWebSocketManager()
 
 	// 用户模块路由
@@ -39,8 +40,14 @@ func registerModuleRoutes(r *gin.Engine, api *gin.RouterGroup) {
 	// 权限模块路由
 	Permission.RegisterRoutes(r, api)
 
+	// 评价模块路由
+	Review.RegisterRoutes(r, api)
+
+	// 分类模块路由
+	Category.RegisterRoutes(r, api)
+
 	// 消息模块路由
-	Message.RegisterRoutes(r, api, wsManager, config.RabbitMQ.URL)
+	Message.RegisterRoutes(r, api, wsManager)
 
 	// 仪表盘模块路由
 	Dashboard.RegisterRoutes(r, api)
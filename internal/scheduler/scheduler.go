@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"campus/internal/models"
+	"campus/internal/utils/logger"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobFunc 定时任务的执行函数，ctx在调度器停止时取消
+type JobFunc func(ctx context.Context) error
+
+// job 已注册的定时任务
+type job struct {
+	name     string
+	interval time.Duration
+	fn       JobFunc
+}
+
+// Scheduler 进程内定时任务调度器
+// 每次执行前通过数据库租约抢占任务，多实例部署时同一任务在一个周期内只会执行一次
+type Scheduler struct {
+	db     *gorm.DB
+	holder string
+
+	mu      sync.Mutex
+	jobs    []*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(db *gorm.DB) *Scheduler {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:     db,
+		holder: fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), time.Now().UnixNano()),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 注册定时任务，调度器已启动时立即开始调度
+func (s *Scheduler) Register(name string, interval time.Duration, fn JobFunc) {
+	j := &job{name: name, interval: interval, fn: fn}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, j)
+	if s.started {
+		s.run(j)
+	}
+}
+
+// Start 启动所有已注册的任务
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, j := range s.jobs {
+		s.run(j)
+	}
+	logger.Info("定时任务调度器已启动", zap.String("holder", s.holder))
+}
+
+// Stop 停止调度并等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(j *job) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			s.execute(j)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// execute 抢到租约后执行一次任务，任务panic不影响后续调度
+func (s *Scheduler) execute(j *job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("定时任务异常", zap.String("job", j.name), zap.Any("panic", r))
+		}
+	}()
+
+	acquired, err := s.acquireLease(j.name, j.interval)
+	if err != nil {
+		logger.Error("获取任务租约失败", zap.String("job", j.name), zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	start := time.Now()
+	if err := j.fn(s.ctx); err != nil {
+		logger.Error("定时任务执行失败", zap.String("job", j.name), zap.Error(err))
+		return
+	}
+	logger.Debug("定时任务执行完成", zap.String("job", j.name), zap.Duration("elapsed", time.Since(start)))
+}
+
+// acquireLease 尝试获取任务租约，租约未过期且不属于本实例时返回false
+func (s *Scheduler) acquireLease(name string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	// 首次执行时创建租约记录，已存在则忽略
+	lease := &models.JobLease{Name: name, ExpiresAt: now.Add(-time.Second)}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(lease).Error; err != nil {
+		return false, err
+	}
+
+	result := s.db.Model(&models.JobLease{}).
+		Where("name = ? AND (expires_at <= ? OR holder = ?)", name, now, s.holder).
+		Updates(map[string]interface{}{
+			"holder":     s.holder,
+			"expires_at": now.Add(ttl),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
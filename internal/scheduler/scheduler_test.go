@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"campus/internal/config"
+	"campus/internal/models"
+	"campus/internal/utils/logger"
+	"campus/internal/utils/testdb"
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// 未配置输出时日志被丢弃
+	logger.Init(&config.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+func TestLeaseHeldByOneInstance(t *testing.T) {
+	db := testdb.New(t, &models.JobLease{})
+	first, second := NewScheduler(db), NewScheduler(db)
+
+	acquired, err := first.acquireLease("job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// 租约未过期时其他实例不能获取，持有者可以续期
+	acquired, err = second.acquireLease("job", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+	acquired, err = first.acquireLease("job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// 租约过期后其他实例可以接管
+	require.NoError(t, db.Model(&models.JobLease{}).Where("name = ?", "job").
+		Update("expires_at", time.Now().Add(-time.Second)).Error)
+	acquired, err = second.acquireLease("job", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = first.acquireLease("job", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+}
+
+func TestExecuteRunsOnlyWithLease(t *testing.T) {
+	db := testdb.New(t, &models.JobLease{})
+	first, second := NewScheduler(db), NewScheduler(db)
+
+	var runs int32
+	j := &job{name: "job", interval: time.Minute, fn: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}}
+	first.execute(j)
+	second.execute(j)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	// 任务panic不影响后续调度
+	panicking := &job{name: "panicking", interval: time.Minute, fn: func(ctx context.Context) error {
+		panic("boom")
+	}}
+	assert.NotPanics(t, func() { first.execute(panicking) })
+}
+
+func TestStopWaitsForRunningJobs(t *testing.T) {
+	s := NewScheduler(testdb.New(t, &models.JobLease{}))
+
+	started := make(chan struct{})
+	var finished int32
+	s.Register("job", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		atomic.StoreInt32(&finished, 1)
+		return ctx.Err()
+	})
+	s.Start()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("任务未执行")
+	}
+	s.Stop()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&finished))
+}
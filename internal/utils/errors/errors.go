@@ -27,6 +27,9 @@ var (
 
 	// ErrValidation 表示验证错误
 	ErrValidation = errors.New("验证错误")
+
+	// ErrConflict 表示资源状态冲突
+	ErrConflict = errors.New("资源状态冲突")
 )
 
 // ErrorType 错误类型
@@ -41,6 +44,7 @@ const (
 	ErrorTypeInternalServer ErrorType = "INTERNAL_SERVER"
 	ErrorTypeDuplicate      ErrorType = "DUPLICATE"
 	ErrorTypeValidation     ErrorType = "VALIDATION"
+	ErrorTypeConflict       ErrorType = "CONFLICT"
 )
 
 // AppError 应用错误结构体
@@ -147,6 +151,18 @@ func NewValidationError(message string, err error) *AppError {
 	}
 }
 
+// NewConflictError 创建资源状态冲突错误
+func NewConflictError(message string, err error) *AppError {
+	if message == "" {
+		message = "资源状态冲突"
+	}
+	return &AppError{
+		Type:    ErrorTypeConflict,
+		Message: message,
+		Err:     err,
+	}
+}
+
 // IsNotFound 判断是否为资源未找到错误
 func IsNotFound(err error) bool {
 	var appErr *AppError
@@ -209,3 +225,12 @@ func IsValidation(err error) bool {
 	}
 	return errors.Is(err, ErrValidation)
 }
+
+// IsConflict 判断是否为资源状态冲突错误
+func IsConflict(err error) bool {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Type == ErrorTypeConflict
+	}
+	return errors.Is(err, ErrConflict)
+}
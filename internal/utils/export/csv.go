@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM Excel依赖BOM识别UTF-8编码的CSV，否则中文会乱码
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+type csvWriter struct {
+	out         io.Writer
+	w           *csv.Writer
+	columns     []Column
+	wroteHeader bool
+}
+
+func newCSVWriter(w io.Writer, columns []Column) *csvWriter {
+	return &csvWriter{out: w, w: csv.NewWriter(w), columns: columns}
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.wroteHeader {
+		return nil
+	}
+	c.wroteHeader = true
+
+	if _, err := c.out.Write(utf8BOM); err != nil {
+		return err
+	}
+	titles := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		titles[i] = col.Title
+	}
+	return c.w.Write(titles)
+}
+
+func (c *csvWriter) WriteRow(values []string) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	// encoding/csv 会对包含逗号、引号和换行的字段加引号转义
+	cells := make([]string, len(values))
+	for i, value := range values {
+		cells[i] = escapeFormula(value)
+	}
+	return c.w.Write(cells)
+}
+
+// escapeFormula 以=、+、-、@、制表符或回车开头的单元格会被Excel当作公式执行，
+// 商品标题、地址等内容由用户填写，加上单引号前缀按文本显示
+func escapeFormula(value string) string {
+	if value == "" {
+		return value
+	}
+	switch value[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + value
+	}
+	return value
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.Flush()
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// 支持的导出格式
+const (
+	FormatCSV   = "csv"
+	FormatXLSX  = "xlsx"
+	FormatJSONL = "jsonl"
+)
+
+// Column 导出列，Title用于CSV/XLSX表头，Key用于JSON Lines字段名
+type Column struct {
+	Key   string
+	Title string
+}
+
+// Writer 流式导出写入器，逐行写入目标io.Writer，不在内存中缓存全部数据
+// 表头在写入第一行或Close时才输出，出错前未写入任何字节，便于调用方返回错误响应
+type Writer interface {
+	// WriteRow 写入一行，值的顺序与列定义一致
+	WriteRow(values []string) error
+	// Flush 将缓冲的数据写入底层io.Writer
+	Flush() error
+	// Close 写入文件结尾并刷新，不关闭底层io.Writer
+	Close() error
+}
+
+// NewWriter 根据格式创建导出写入器
+func NewWriter(format string, w io.Writer, columns []Column) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns), nil
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// ContentType 返回导出格式对应的HTTP Content-Type
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatJSONL:
+		return "application/x-ndjson; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testColumns = []Column{
+	{Key: "id", Title: "订单ID"},
+	{Key: "title", Title: "商品标题"},
+}
+
+// TestCSVWriter 测试CSV导出的BOM和转义
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, testColumns)
+	assert.NoError(t, err)
+
+	// 写入前不输出任何内容
+	assert.Equal(t, 0, buf.Len())
+
+	assert.NoError(t, w.WriteRow([]string{"1", `二手书, "九成新"`}))
+	assert.NoError(t, w.Close())
+
+	assert.True(t, bytes.HasPrefix(buf.Bytes(), utf8BOM))
+	assert.Equal(t, "订单ID,商品标题\n1,\"二手书, \"\"九成新\"\"\"\n", string(buf.Bytes()[len(utf8BOM):]))
+}
+
+// TestCSVWriterEscapesFormulas 测试CSV导出时转义公式
+func TestCSVWriterEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, testColumns)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteRow([]string{"1", `=HYPERLINK("http://evil","点击")`}))
+	assert.NoError(t, w.WriteRow([]string{"2", "@SUM(A1)"}))
+	assert.NoError(t, w.WriteRow([]string{"3", "+1"}))
+	assert.NoError(t, w.WriteRow([]string{"4", "-1"}))
+	assert.NoError(t, w.WriteRow([]string{"5", "台灯=9成新"}))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "订单ID,商品标题\n"+
+		"1,\"'=HYPERLINK(\"\"http://evil\"\",\"\"点击\"\")\"\n"+
+		"2,'@SUM(A1)\n"+
+		"3,'+1\n"+
+		"4,'-1\n"+
+		"5,台灯=9成新\n", string(buf.Bytes()[len(utf8BOM):]))
+}
+
+// TestJSONLWriter 测试JSON Lines导出
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatJSONL, &buf, testColumns)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteRow([]string{"1", "台灯\n"}))
+	assert.NoError(t, w.WriteRow([]string{"2", "自行车"}))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "{\"id\":\"1\",\"title\":\"台灯\\n\"}\n{\"id\":\"2\",\"title\":\"自行车\"}\n", buf.String())
+}
+
+// TestXLSXWriter 测试XLSX导出生成合法的zip包和工作表内容
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf, testColumns)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteRow([]string{"1", "<耳机>&"}))
+	assert.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	var sheet string
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			assert.NoError(t, err)
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			sheet = string(data)
+		}
+	}
+
+	assert.True(t, strings.Contains(sheet, `<c r="B1" t="inlineStr"><is><t xml:space="preserve">商品标题</t></is></c>`))
+	assert.True(t, strings.Contains(sheet, `&lt;耳机&gt;&amp;`))
+	assert.True(t, strings.HasSuffix(sheet, xlsxSheetEnd))
+}
+
+// TestColumnName 测试Excel列名转换
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+}
+
+// TestUnsupportedFormat 测试不支持的格式
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := NewWriter("pdf", io.Discard, testColumns)
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor 游标格式错误或签名校验失败
+var ErrInvalidCursor = errors.New("无效的分页游标")
+
+// 游标签名密钥，启动时通过 SetSecret 设置
+var secret []byte
+
+// SetSecret 设置游标签名密钥
+func SetSecret(key string) {
+	secret = []byte(key)
+}
+
+// Cursor 游标，指向上一页最后一条记录的排序键
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// Encode 将游标编码为不透明字符串：base64url(纳秒时间戳+ID).base64url(HMAC-SHA256签名)
+func Encode(c Cursor) string {
+	payload := make([]byte, 16)
+	binary.BigEndian.PutUint64(payload[:8], uint64(c.CreatedAt.UnixNano()))
+	binary.BigEndian.PutUint64(payload[8:], uint64(c.ID))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(payload))
+}
+
+// Decode 解析并校验游标
+func Decode(s string) (Cursor, error) {
+	encoded, signature, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(payload) != 16 {
+		return Cursor{}, ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return Cursor{
+		CreatedAt: time.Unix(0, int64(binary.BigEndian.Uint64(payload[:8]))),
+		ID:        uint(binary.BigEndian.Uint64(payload[8:])),
+	}, nil
+}
+
+func sign(payload []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// Request 列表接口的分页请求参数，传入cursor时使用游标分页，否则使用page/size分页，page默认为1
+type Request struct {
+	Cursor string `json:"cursor" form:"cursor"`
+	Page   uint   `json:"page" form:"page" binding:"omitempty,min=1"`
+	Size   uint   `json:"size" form:"size" binding:"required,min=1,max=100"`
+}
+
+// Params 解析请求中的分页参数
+func (r Request) Params() (Params, error) {
+	return NewParams(r.Cursor, r.Page, r.Size)
+}
+
+// Params 分页参数。Page为0时使用游标模式，游标为空表示第一页；Page大于0时使用page/size兼容模式
+type Params struct {
+	Page   int
+	Size   int
+	cursor *Cursor
+}
+
+// NewParams 由请求中的分页参数创建Params。传入游标时忽略page使用游标模式，游标无效时返回 ErrInvalidCursor；
+// 未传入游标时使用page/size模式，page为0时返回第一页。page/size模式的结果同样返回下一页游标
+func NewParams(cursor string, page, size uint) (Params, error) {
+	if page == 0 {
+		page = 1
+	}
+	p := Params{Page: int(page), Size: int(size)}
+	if cursor != "" {
+		p.Page = 0
+		c, err := Decode(cursor)
+		if err != nil {
+			return p, err
+		}
+		p.cursor = &c
+	}
+	return p, nil
+}
+
+// CursorMode 是否使用游标分页
+func (p Params) CursorMode() bool {
+	return p.Page == 0
+}
+
+// Offset page/size模式下的偏移量
+func (p Params) Offset() int {
+	if p.Page == 0 {
+		return 0
+	}
+	return (p.Page - 1) * p.Size
+}
+
+// Info 分页结果信息。游标模式返回next_cursor和has_more，不统计总数；
+// page/size兼容模式同时返回total、page、size
+type Info struct {
+	Total      int64  `json:"total"`
+	Page       int    `json:"page"`
+	Size       int    `json:"size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Apply 为查询添加排序和分页条件，按(created_at, id)倒序排列。
+// 游标模式多取一条记录用于判断是否还有下一页；table为排序字段所属的表，用于联表查询时消除歧义
+func Apply(query *gorm.DB, p Params, table string) *gorm.DB {
+	createdAt, id := "created_at", "id"
+	if table != "" {
+		createdAt, id = table+".created_at", table+".id"
+	}
+	query = query.Order(createdAt + " DESC").Order(id + " DESC")
+
+	if !p.CursorMode() {
+		return query.Offset(p.Offset()).Limit(p.Size)
+	}
+
+	if p.cursor != nil {
+		query = query.Where(fmt.Sprintf("(%s < ? OR (%s = ? AND %s < ?))", createdAt, createdAt, id),
+			p.cursor.CreatedAt, p.cursor.CreatedAt, p.cursor.ID)
+	}
+	return query.Limit(p.Size + 1)
+}
+
+// Trim 根据查询结果生成分页信息。游标模式下去掉Apply多取的一条记录；page/size模式下total为总记录数。
+// 还有下一页时用本页最后一条记录生成下一页游标，page/size模式的客户端也可以据此切换到游标分页
+func Trim[T any](items []T, p Params, total int64, key func(T) Cursor) ([]T, Info) {
+	info := Info{Size: p.Size}
+	if p.CursorMode() {
+		if len(items) > p.Size {
+			items = items[:p.Size]
+			info.HasMore = true
+		}
+	} else {
+		info.Total = total
+		info.Page = p.Page
+		info.HasMore = int64(p.Page*p.Size) < total
+	}
+
+	if info.HasMore && len(items) > 0 {
+		info.NextCursor = Encode(key(items[len(items)-1]))
+	}
+	return items, info
+}
+
+// ModelCursor 返回gorm.Model记录的游标，用作 Trim 的key
+func ModelCursor(m gorm.Model) Cursor {
+	return Cursor{CreatedAt: m.CreatedAt, ID: m.ID}
+}
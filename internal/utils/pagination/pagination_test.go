@@ -0,0 +1,130 @@
+package pagination
+
+import (
+	"campus/internal/utils/testdb"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	gorm.Model
+	Name string
+}
+
+func itemCursor(i *item) Cursor {
+	return ModelCursor(i.Model)
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db := testdb.New(t, &item{})
+
+	// 前两条记录创建时间相同，验证按ID区分先后
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []time.Duration{0, 0, time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		require.NoError(t, db.Create(&item{Model: gorm.Model{CreatedAt: base.Add(offset)}, Name: string(rune('a' + i))}).Error)
+	}
+	return db
+}
+
+func fetch(t *testing.T, db *gorm.DB, cursor string, page, size uint) ([]*item, Info) {
+	p, err := NewParams(cursor, page, size)
+	require.NoError(t, err)
+	var items []*item
+	require.NoError(t, Apply(db.Model(&item{}), p, "").Find(&items).Error)
+	return Trim(items, p, 5, itemCursor)
+}
+
+func names(items []*item) string {
+	var s string
+	for _, i := range items {
+		s += i.Name
+	}
+	return s
+}
+
+func TestEncodeDecode(t *testing.T) {
+	c := Cursor{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC), ID: 42}
+
+	decoded, err := Decode(Encode(c))
+	require.NoError(t, err)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.ID, decoded.ID)
+}
+
+func TestDecodeRejectsTamperedCursor(t *testing.T) {
+	encoded := Encode(Cursor{CreatedAt: time.Now(), ID: 1})
+	other := Encode(Cursor{CreatedAt: time.Now(), ID: 2})
+
+	payload, _, _ := strings.Cut(encoded, ".")
+	_, signature, _ := strings.Cut(other, ".")
+
+	_, err := Decode(payload + "." + signature)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = Decode("not-a-cursor")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestCursorMode(t *testing.T) {
+	db := newTestDB(t)
+
+	// 未传入游标和page时返回第一页，同时返回下一页游标
+	items, info := fetch(t, db, "", 0, 2)
+	assert.Equal(t, "ed", names(items))
+	assert.True(t, info.HasMore)
+	assert.Equal(t, 1, info.Page)
+	assert.Equal(t, int64(5), info.Total)
+
+	// 翻页期间插入的新记录不会导致后续页面出现重复
+	require.NoError(t, db.Create(&item{Name: "z"}).Error)
+
+	items, info = fetch(t, db, info.NextCursor, 0, 2)
+	assert.Equal(t, "cb", names(items))
+	assert.True(t, info.HasMore)
+
+	items, info = fetch(t, db, info.NextCursor, 0, 2)
+	assert.Equal(t, "a", names(items))
+	assert.False(t, info.HasMore)
+	assert.Empty(t, info.NextCursor)
+}
+
+func TestPageMode(t *testing.T) {
+	db := newTestDB(t)
+
+	items, info := fetch(t, db, "", 2, 2)
+	assert.Equal(t, "cb", names(items))
+	assert.Equal(t, int64(5), info.Total)
+	assert.Equal(t, 2, info.Page)
+	assert.True(t, info.HasMore)
+
+	// page/size模式返回的游标可以继续按游标翻页
+	items, info = fetch(t, db, info.NextCursor, 2, 2)
+	assert.Equal(t, "a", names(items))
+	assert.Zero(t, info.Page)
+
+	_, info = fetch(t, db, "", 3, 2)
+	assert.False(t, info.HasMore)
+	assert.Empty(t, info.NextCursor)
+}
+
+func TestNewParams(t *testing.T) {
+	_, err := NewParams("bad", 0, 10)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = NewParams("bad", 2, 10)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	p, err := NewParams("", 2, 10)
+	require.NoError(t, err)
+	assert.False(t, p.CursorMode())
+	assert.Equal(t, 10, p.Offset())
+
+	p, err = NewParams("", 0, 10)
+	require.NoError(t, err)
+	assert.False(t, p.CursorMode())
+	assert.Equal(t, 1, p.Page)
+	assert.Zero(t, p.Offset())
+}
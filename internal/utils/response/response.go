@@ -77,6 +77,8 @@ func handleAppError(c *gin.Context, err *appErrors.AppError) {
 		Fail(c, http.StatusForbidden, err.Message)
 	case appErrors.ErrorTypeBadRequest, appErrors.ErrorTypeDuplicate, appErrors.ErrorTypeValidation:
 		Fail(c, http.StatusBadRequest, err.Message)
+	case appErrors.ErrorTypeConflict:
+		Fail(c, http.StatusConflict, err.Message)
 	default:
 		if err.Err != nil {
 			Fail(c, http.StatusInternalServerError, err.Message+": "+err.Err.Error())
@@ -97,6 +99,8 @@ func handleStandardError(c *gin.Context, err error) {
 		Fail(c, http.StatusForbidden, err.Error())
 	case appErrors.IsBadRequest(err), appErrors.IsDuplicate(err), appErrors.IsValidation(err):
 		Fail(c, http.StatusBadRequest, err.Error())
+	case appErrors.IsConflict(err):
+		Fail(c, http.StatusConflict, err.Error())
 	default:
 		Fail(c, http.StatusInternalServerError, "服务器内部错误: "+err.Error())
 	}
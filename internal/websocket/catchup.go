@@ -0,0 +1,114 @@
+package websocket
+
+import (
+	"campus/internal/utils/logger"
+
+	"go.uber.org/zap"
+)
+
+// replayBatchSize 每次从数据库读取的补发消息数量
+const replayBatchSize = 200
+
+// Backlog 查询用户错过的消息，用于重连补发
+type Backlog interface {
+	// Since 按ID升序返回afterID之后与用户相关的最多limit条消息，编码为与实时推送相同的message帧
+	Since(userID, afterID uint, limit int) ([]Delivery, error)
+
+	// EventsSince 按ID升序返回afterEventID之后用户的最多limit条消息撤回、删除事件，
+	// 只包含maxMessageID及之前的消息，编码为与实时推送相同的帧，Delivery.ID为事件ID
+	EventsSince(userID, afterEventID, maxMessageID uint, limit int) ([]Delivery, error)
+}
+
+// catchUp 补发afterID之后的消息和afterEventID之后的变更事件，然后推送补发期间暂存的实时消息并切换为实时推送。
+// 连接注册后才查询数据库：查询时已保存的消息由补发覆盖，之后保存的消息由实时推送覆盖，重叠部分按消息ID去重；
+// 变更事件可能与暂存的实时事件重复，客户端按事件ID忽略已处理的事件
+func (m *Manager) catchUp(c *Connection, afterID, afterEventID uint) {
+	// afterID之后的消息补发时已是撤回、删除后的状态，只需补发设备已有消息的变更事件
+	maxMessageID := afterID
+	replayed := make(map[uint]bool)
+	for {
+		batch, err := m.backlog.Since(c.UserID, afterID, replayBatchSize)
+		if err != nil {
+			m.abortCatchUp(c, "补发离线消息失败", err)
+			return
+		}
+		for _, d := range batch {
+			if !c.enqueue(d.Payload) {
+				return
+			}
+			replayed[d.ID] = true
+			afterID = d.ID
+		}
+		m.sendDelivered(c.UserID, batch)
+		if len(batch) < replayBatchSize {
+			break
+		}
+	}
+
+	replayedEvents := 0
+	for {
+		batch, err := m.backlog.EventsSince(c.UserID, afterEventID, maxMessageID, replayBatchSize)
+		if err != nil {
+			m.abortCatchUp(c, "补发消息变更事件失败", err)
+			return
+		}
+		for _, d := range batch {
+			if !c.enqueue(d.Payload) {
+				return
+			}
+			afterEventID = d.ID
+		}
+		replayedEvents += len(batch)
+		if len(batch) < replayBatchSize {
+			break
+		}
+	}
+
+	for {
+		c.mu.Lock()
+		pending := c.pending
+		c.pending = nil
+		if len(pending) == 0 {
+			c.syncing = false
+			c.mu.Unlock()
+			break
+		}
+		c.mu.Unlock()
+
+		for _, d := range pending {
+			if d.ID != 0 && replayed[d.ID] {
+				continue
+			}
+			if !c.enqueue(d.Payload) {
+				return
+			}
+		}
+	}
+	logger.Debug("离线消息补发完成",
+		zap.Uint("用户ID", c.UserID),
+		zap.String("设备ID", c.DeviceID),
+		zap.Int("补发数量", len(replayed)),
+		zap.Int("补发事件数量", replayedEvents))
+}
+
+// abortCatchUp 补发失败时关闭连接，由客户端重连后重新补发，避免漏发
+func (m *Manager) abortCatchUp(c *Connection, msg string, err error) {
+	logger.Error(msg,
+		zap.Uint("用户ID", c.UserID),
+		zap.String("设备ID", c.DeviceID),
+		zap.Error(err))
+	c.Close()
+}
+
+// sendDelivered 按发送者分组返回补发消息的送达回执
+func (m *Manager) sendDelivered(receiverID uint, batch []Delivery) {
+	bySender := make(map[uint][]uint)
+	for _, d := range batch {
+		if d.SenderID != 0 && d.SenderID != receiverID {
+			bySender[d.SenderID] = append(bySender[d.SenderID], d.ID)
+		}
+	}
+	for senderID, ids := range bySender {
+		m.SendFrame(senderID, FrameMessageDelivered, DeliveredData{ReceiverID: receiverID, MessageIDs: ids})
+	}
+}
@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBacklog 返回固定的离线消息，并在第一次查询时模拟补发期间到达的实时推送
+type fakeBacklog struct {
+	messages []uint
+	events   []fakeEvent
+	onQuery  func()
+	fail     bool
+}
+
+// fakeEvent 消息变更事件，payload为"e"加事件ID
+type fakeEvent struct {
+	id        uint
+	messageID uint
+}
+
+func (b *fakeBacklog) Since(userID, afterID uint, limit int) ([]Delivery, error) {
+	if b.fail {
+		return nil, errors.New("db down")
+	}
+	if b.onQuery != nil {
+		b.onQuery()
+		b.onQuery = nil
+	}
+	var batch []Delivery
+	for _, id := range b.messages {
+		if id > afterID && len(batch) < limit {
+			batch = append(batch, delivery(id))
+		}
+	}
+	return batch, nil
+}
+
+func (b *fakeBacklog) EventsSince(userID, afterEventID, maxMessageID uint, limit int) ([]Delivery, error) {
+	var batch []Delivery
+	for _, e := range b.events {
+		if e.id > afterEventID && e.messageID <= maxMessageID && len(batch) < limit {
+			batch = append(batch, Delivery{ID: e.id, Payload: []byte("e" + strconv.Itoa(int(e.id)))})
+		}
+	}
+	return batch, nil
+}
+
+func delivery(id uint) Delivery {
+	return Delivery{ID: id, Payload: []byte(strconv.Itoa(int(id)))}
+}
+
+func drain(c *Connection) []string {
+	var got []string
+	for {
+		select {
+		case payload := <-c.Send:
+			got = append(got, string(payload))
+		default:
+			return got
+		}
+	}
+}
+
+func TestCatchUpReplaysThenSwitchesToLive(t *testing.T) {
+	m := NewManager()
+	c := newConnection(1, "phone", nil)
+	c.syncing = true
+	m.register(c)
+
+	backlog := &fakeBacklog{messages: []uint{5, 6, 7}}
+	// 补发查询期间到达的实时推送：7已在补发范围内，8是新消息
+	backlog.onQuery = func() {
+		m.Deliver(1, delivery(7))
+		m.Deliver(1, delivery(8))
+	}
+	m.SetBacklog(backlog)
+
+	m.catchUp(c, 4, 0)
+	assert.Equal(t, []string{"5", "6", "7", "8"}, drain(c))
+
+	// 补发完成后直接推送
+	m.Deliver(1, delivery(9))
+	assert.Equal(t, []string{"9"}, drain(c))
+}
+
+func TestCatchUpPagesThroughBacklog(t *testing.T) {
+	m := NewManager()
+	c := newConnection(1, "phone", nil)
+	c.Send = make(chan []byte, replayBatchSize*2)
+	c.syncing = true
+	m.register(c)
+
+	backlog := &fakeBacklog{}
+	for id := uint(1); id <= replayBatchSize+3; id++ {
+		backlog.messages = append(backlog.messages, id)
+	}
+	m.SetBacklog(backlog)
+
+	m.catchUp(c, 0, 0)
+	assert.Len(t, drain(c), replayBatchSize+3)
+}
+
+func TestCatchUpReplaysMessageEvents(t *testing.T) {
+	m := NewManager()
+	c := newConnection(1, "phone", nil)
+	c.syncing = true
+	m.register(c)
+
+	// 事件1已处理；事件2、3针对设备已有的消息；事件4针对补发的消息6，补发时已是最新状态
+	m.SetBacklog(&fakeBacklog{
+		messages: []uint{5, 6},
+		events:   []fakeEvent{{id: 1, messageID: 3}, {id: 2, messageID: 3}, {id: 3, messageID: 4}, {id: 4, messageID: 6}},
+	})
+
+	m.catchUp(c, 4, 1)
+	assert.Equal(t, []string{"5", "6", "e2", "e3"}, drain(c))
+}
+
+func TestCatchUpClosesConnectionOnError(t *testing.T) {
+	m := NewManager()
+	c := newConnection(1, "phone", nil)
+	c.syncing = true
+	m.register(c)
+	m.SetBacklog(&fakeBacklog{fail: true})
+
+	m.catchUp(c, 4, 0)
+	assert.True(t, isClosed(c))
+}
@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"campus/internal/utils/logger"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Delivery 推送给客户端的一帧数据。对应消息记录时ID为消息ID、SenderID为发送者ID，否则均为0
+type Delivery struct {
+	ID       uint
+	SenderID uint
+	Payload  []byte
+}
+
+// Connection 表示一个设备的websocket连接
+type Connection struct {
+	UserID      uint
+	DeviceID    string
+	ConnectedAt time.Time
+	Conn        *websocket.Conn
+	Send        chan []byte
+
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	syncing bool       // 正在补发离线消息，期间的实时推送暂存到pending
+	pending []Delivery // 补发期间收到的实时推送
+
+	watching []uint // 订阅在线状态的用户，由Manager.presenceMux保护
+}
+
+func newConnection(userID uint, deviceID string, conn *websocket.Conn) *Connection {
+	return &Connection{
+		UserID:      userID,
+		DeviceID:    deviceID,
+		ConnectedAt: time.Now(),
+		Conn:        conn,
+		Send:        make(chan []byte, 256),
+		closed:      make(chan struct{}),
+	}
+}
+
+// Close 关闭连接，writePump随后关闭底层websocket连接。可以重复调用
+func (c *Connection) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+// Done 连接关闭后可读
+func (c *Connection) Done() <-chan struct{} {
+	return c.closed
+}
+
+// enqueue 阻塞写入发送缓冲区，连接已关闭时返回false
+func (c *Connection) enqueue(payload []byte) bool {
+	select {
+	case c.Send <- payload:
+		return true
+	case <-c.closed:
+		return false
+	}
+}
+
+// push 推送实时数据。补发期间先暂存；发送缓冲区已满时关闭连接，
+// 由客户端重连后从最后收到的消息补发，避免静默丢失消息
+func (c *Connection) push(d Delivery) bool {
+	c.mu.Lock()
+	if c.syncing {
+		c.pending = append(c.pending, d)
+		c.mu.Unlock()
+		return true
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.Send <- d.Payload:
+		return true
+	case <-c.closed:
+		return false
+	default:
+		logger.Warn("WebSocket发送缓冲区已满，关闭连接等待客户端重连",
+			zap.Uint("用户ID", c.UserID),
+			zap.String("设备ID", c.DeviceID))
+		c.Close()
+		return false
+	}
+}
+
+// SendFrame 向该设备推送一帧
+func (c *Connection) SendFrame(frameType, id string, data interface{}) bool {
+	payload, err := EncodeFrame(frameType, id, data)
+	if err != nil {
+		logger.Error("WebSocket帧序列化失败",
+			zap.String("帧类型", frameType),
+			zap.Error(err))
+		return false
+	}
+	return c.push(Delivery{Payload: payload})
+}
+
+// SendError 向该设备返回错误帧，id为出错的客户端帧ID
+func (c *Connection) SendError(id string, err error) bool {
+	return c.SendFrame(FrameError, id, errorData(err))
+}
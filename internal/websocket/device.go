@@ -0,0 +1,26 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// DeviceIDHeader 服务端生成设备ID时通过该响应头返回给客户端
+const DeviceIDHeader = "X-Device-ID"
+
+var deviceIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ValidDeviceID 检查设备ID格式：1-64位字母、数字、下划线或短横线
+func ValidDeviceID(deviceID string) bool {
+	return deviceIDPattern.MatchString(deviceID)
+}
+
+// NewDeviceID 生成随机设备ID
+func NewDeviceID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -2,11 +2,13 @@ package websocket
 
 import (
 	"campus/internal/models"
+	"campus/internal/utils/errors"
 	"campus/internal/utils/logger"
 	"encoding/json"
 	"github.com/gorilla/websocket"
 	"go.uber.org/zap"
 	"net/http"
+	"sort"
 	"sync"
 	"time"
 )
@@ -21,82 +23,200 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Connection 表示websocket连接的包装
-type Connection struct {
-	Conn *websocket.Conn
-	Send chan []byte
+// Session 用户的一个在线设备
+type Session struct {
+	DeviceID    string    `json:"device_id"`
+	ConnectedAt time.Time `json:"connected_at"`
 }
 
-// Manager 管理Websocket连接
+// Manager 管理Websocket连接。同一用户可以同时在多个设备上连接，每个设备保留一个连接
 type Manager struct {
-	// 客户端连接映射表： 用户ID -> 连接
-	Clients   map[uint]*Connection
+	// 客户端连接映射表： 用户ID -> 设备ID -> 连接
+	Clients   map[uint]map[string]*Connection
 	ClientMux sync.RWMutex
 
-	// 注册和注销通道
-	Register   chan *ClientRegistration
-	Unregister chan uint
+	// 注销通道。注册在HandleConnection中同步完成，保证补发查询前连接已能收到实时推送
+	Unregister chan *Connection
+
+	// 在线状态订阅：被订阅的用户ID -> 订阅的连接
+	watchers    map[uint]map[*Connection]bool
+	presenceMux sync.RWMutex
+
+	backlog  Backlog
+	handler  FrameHandler
+	presence PresenceStore
 }
 
-type ClientRegistration struct {
+// ConnectOptions 建立连接的参数
+type ConnectOptions struct {
 	UserID uint
-	Conn   *Connection
+	// DeviceID 为空时生成新的设备ID，并通过响应头X-Device-ID返回
+	DeviceID string
+	// LastMessageID 客户端已收到的最后一条消息ID，大于0时先补发之后的消息再推送实时消息
+	LastMessageID uint
+	// LastEventID 客户端已处理的最后一个消息变更事件ID，补发消息时同时补发之后的撤回、删除事件
+	LastEventID uint
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		Clients:    make(map[uint]*Connection),
-		Register:   make(chan *ClientRegistration),
-		Unregister: make(chan uint),
+		Clients:    make(map[uint]map[string]*Connection),
+		Unregister: make(chan *Connection),
 		ClientMux:  sync.RWMutex{},
+		watchers:   make(map[uint]map[*Connection]bool),
 	}
 }
 
+// SetBacklog 设置重连补发的消息来源，未设置时不补发
+func (m *Manager) SetBacklog(backlog Backlog) {
+	m.backlog = backlog
+}
+
+// SetHandler 设置客户端帧的处理器，未设置时客户端帧返回错误
+func (m *Manager) SetHandler(handler FrameHandler) {
+	m.handler = handler
+}
+
 func (m *Manager) Start() {
-	for {
-		select {
-		case clientReg := <-m.Register:
-			m.ClientMux.Lock()
-			// 如果该用户已经有连接 ，先关闭连接
-			if conn, ok := m.Clients[clientReg.UserID]; ok {
-				close(conn.Send)
-				delete(m.Clients, clientReg.UserID)
-				logger.Debugf("用户 %d 的旧连接已关闭", clientReg.UserID)
-			}
-			m.Clients[clientReg.UserID] = clientReg.Conn
-			m.ClientMux.Unlock()
-			logger.Info("WebSocket连接建立", zap.Uint("用户ID", clientReg.UserID))
-
-		case userID := <-m.Unregister:
-			m.ClientMux.Lock()
-			if conn, ok := m.Clients[userID]; ok {
-				close(conn.Send)
-				delete(m.Clients, userID)
-				logger.Info("WebSocket连接断开", zap.Uint("用户ID", userID))
-			}
-			m.ClientMux.Unlock()
-		}
+	for conn := range m.Unregister {
+		m.unregister(conn)
+	}
+}
+
+// register 添加设备连接。同一设备重复连接时关闭旧连接，其他设备的连接不受影响
+func (m *Manager) register(c *Connection) {
+	m.ClientMux.Lock()
+	sessions, online := m.Clients[c.UserID]
+	if !online {
+		sessions = make(map[string]*Connection)
+		m.Clients[c.UserID] = sessions
+	}
+	if old, ok := sessions[c.DeviceID]; ok {
+		old.Close()
+		logger.Debugf("用户 %d 设备 %s 的旧连接已关闭", c.UserID, c.DeviceID)
+	}
+	sessions[c.DeviceID] = c
+	count := len(sessions)
+	m.ClientMux.Unlock()
+
+	logger.Info("WebSocket连接建立",
+		zap.Uint("用户ID", c.UserID),
+		zap.String("设备ID", c.DeviceID),
+		zap.Int("在线设备数", count))
+	if !online {
+		m.wentOnline(c.UserID)
+	}
+}
+
+// unregister 移除设备连接。连接已被同一设备的新连接替换时只关闭该连接
+func (m *Manager) unregister(c *Connection) {
+	c.Close()
+
+	m.presenceMux.Lock()
+	m.unwatch(c)
+	m.presenceMux.Unlock()
+
+	m.ClientMux.Lock()
+	sessions := m.Clients[c.UserID]
+	if sessions[c.DeviceID] != c {
+		m.ClientMux.Unlock()
+		return
+	}
+	delete(sessions, c.DeviceID)
+	offline := len(sessions) == 0
+	if offline {
+		delete(m.Clients, c.UserID)
+	}
+	m.ClientMux.Unlock()
+
+	logger.Info("WebSocket连接断开",
+		zap.Uint("用户ID", c.UserID),
+		zap.String("设备ID", c.DeviceID))
+	if offline {
+		m.wentOffline(c.UserID)
 	}
 }
 
-// IsUserOnline 检查用户是否在线
+// IsUserOnline 检查用户是否在线，任一设备在线即视为在线
 func (m *Manager) IsUserOnline(userID uint) bool {
 	m.ClientMux.RLock()
-	_, exists := m.Clients[userID]
+	defer m.ClientMux.RUnlock()
+	return len(m.Clients[userID]) > 0
+}
+
+// Sessions 获取用户的在线设备，按连接时间排序
+func (m *Manager) Sessions(userID uint) []Session {
+	m.ClientMux.RLock()
+	sessions := make([]Session, 0, len(m.Clients[userID]))
+	for _, c := range m.Clients[userID] {
+		sessions = append(sessions, Session{DeviceID: c.DeviceID, ConnectedAt: c.ConnectedAt})
+	}
 	m.ClientMux.RUnlock()
-	return exists
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ConnectedAt.Before(sessions[j].ConnectedAt)
+	})
+	return sessions
 }
 
-// SendMessage 向指定用户发送消息
+// SendMessage 向用户的所有在线设备发送不对应消息记录的帧，至少发送到一个设备时返回true
 func (m *Manager) SendMessage(userID uint, message []byte) bool {
+	return m.Deliver(userID, Delivery{Payload: message})
+}
+
+// SendFrame 向用户的所有在线设备发送一帧
+func (m *Manager) SendFrame(userID uint, frameType string, data interface{}) bool {
+	payload, err := EncodeFrame(frameType, "", data)
+	if err != nil {
+		logger.Error("WebSocket帧序列化失败",
+			zap.String("帧类型", frameType),
+			zap.Error(err))
+		return false
+	}
+	return m.SendMessage(userID, payload)
+}
+
+// Deliver 向用户的所有在线设备推送，至少推送到一个设备时返回true。
+// 正在补发的设备在补发完成后按消息ID去重推送。推送给接收者的消息会向发送者的设备返回送达回执
+func (m *Manager) Deliver(userID uint, d Delivery) bool {
+	delivered := false
+	for _, c := range m.connections(userID) {
+		if c.push(d) {
+			delivered = true
+		}
+	}
+	if delivered && d.SenderID != 0 && d.SenderID != userID {
+		m.SendFrame(d.SenderID, FrameMessageDelivered, DeliveredData{ReceiverID: userID, MessageIDs: []uint{d.ID}})
+	}
+	return delivered
+}
+
+// Broadcast 向所有在线设备推送
+func (m *Manager) Broadcast(d Delivery) {
 	m.ClientMux.RLock()
-	conn, exists := m.Clients[userID]
+	var conns []*Connection
+	for _, sessions := range m.Clients {
+		for _, c := range sessions {
+			conns = append(conns, c)
+		}
+	}
 	m.ClientMux.RUnlock()
-	if exists {
-		conn.Send <- message
-		return true
+
+	for _, c := range conns {
+		c.push(d)
 	}
-	return false
+}
+
+// connections 获取用户当前的连接。推送在锁外进行，连接关闭后推送直接返回false
+func (m *Manager) connections(userID uint) []*Connection {
+	m.ClientMux.RLock()
+	defer m.ClientMux.RUnlock()
+
+	conns := make([]*Connection, 0, len(m.Clients[userID]))
+	for _, c := range m.Clients[userID] {
+		conns = append(conns, c)
+	}
+	return conns
 }
 
 // SendMessageToUser 发送消息模型到指定用户
@@ -120,7 +240,7 @@ func (m *Manager) SendMessageToUser(message *models.Message) bool {
 		ProductID:  message.ProductID,
 	}
 
-	messageJSON, err := json.Marshal(messageResponse)
+	payload, err := EncodeFrame(FrameMessage, "", messageResponse)
 	if err != nil {
 		logger.Error("消息序列化失败",
 			zap.Uint("接收者ID", message.ReceiverID),
@@ -129,13 +249,23 @@ func (m *Manager) SendMessageToUser(message *models.Message) bool {
 	}
 
 	// 发送消息到接收者
-	return m.SendMessage(message.ReceiverID, messageJSON)
+	return m.Deliver(message.ReceiverID, Delivery{ID: message.ID, SenderID: message.SenderID, Payload: payload})
 }
 
 // HandleConnection 处理WebSocket连接
-func (m *Manager) HandleConnection(w http.ResponseWriter, r *http.Request, userID uint) {
+func (m *Manager) HandleConnection(w http.ResponseWriter, r *http.Request, opts ConnectOptions) {
+	userID, deviceID := opts.UserID, opts.DeviceID
+	if deviceID == "" {
+		var err error
+		if deviceID, err = NewDeviceID(); err != nil {
+			logger.Error("生成设备ID失败", zap.Uint("用户ID", userID), zap.Error(err))
+			http.Error(w, "生成设备ID失败", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// 升级HTTP连接为WebSocket
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := upgrader.Upgrade(w, r, http.Header{DeviceIDHeader: []string{deviceID}})
 	if err != nil {
 		logger.Error("WebSocket连接升级失败",
 			zap.Uint("用户ID", userID),
@@ -153,27 +283,25 @@ func (m *Manager) HandleConnection(w http.ResponseWriter, r *http.Request, userI
 	})
 
 	// 连接成功， 创建用户连接
-	client := &Connection{
-		Conn: conn,
-		Send: make(chan []byte, 256),
-	}
+	client := newConnection(userID, deviceID, conn)
+	client.syncing = opts.LastMessageID > 0 && m.backlog != nil
 
 	// 注册连接
-	m.Register <- &ClientRegistration{
-		UserID: userID,
-		Conn:   client,
-	}
+	m.register(client)
 
 	// 启动消息读取与写入协程
 	go m.readPump(client, userID)
 	go m.writePump(client, userID)
+	if client.syncing {
+		go m.catchUp(client, opts.LastMessageID, opts.LastEventID)
+	}
 
-	logger.Debug("WebSocket处理协程已启动", zap.Uint("用户ID", userID))
+	logger.Debug("WebSocket处理协程已启动", zap.Uint("用户ID", userID), zap.String("设备ID", deviceID))
 }
 
 func (m *Manager) readPump(c *Connection, userID uint) {
 	defer func() {
-		m.Unregister <- userID
+		m.Unregister <- c
 		c.Conn.Close()
 	}()
 
@@ -191,13 +319,31 @@ func (m *Manager) readPump(c *Connection, userID uint) {
 		}
 
 		// 已通过 SetPongHandler 处理协议层的 Pong，无需 JSON ping
-		// 只在调试级别记录收到的消息
 		logger.Debug("收到WebSocket消息",
 			zap.Uint("用户ID", userID),
 			zap.String("消息", string(message)))
+		m.dispatch(c, message)
 	}
 }
 
+// dispatch 解析客户端帧并交给处理器
+func (m *Manager) dispatch(c *Connection, message []byte) {
+	var frame Frame
+	if err := json.Unmarshal(message, &frame); err != nil || frame.Type == "" {
+		c.SendError("", errors.NewBadRequestError("无效的帧格式", err))
+		return
+	}
+	if frame.Version != ProtocolVersion {
+		c.SendError(frame.ID, errors.NewBadRequestError("不支持的协议版本", nil))
+		return
+	}
+	if m.handler == nil {
+		c.SendError(frame.ID, errors.NewBadRequestError("不支持的帧类型", nil))
+		return
+	}
+	m.handler.HandleFrame(c, &frame)
+}
+
 func (m *Manager) writePump(c *Connection, userID uint) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer func() {
@@ -207,16 +353,16 @@ func (m *Manager) writePump(c *Connection, userID uint) {
 
 	for {
 		select {
-		case message, ok := <-c.Send:
-			// 通道关闭
-			if !ok {
-				if err := c.Conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
-					logger.Debug("关闭WebSocket连接失败",
-						zap.Uint("用户ID", userID),
-						zap.Error(err))
-				}
-				return
+		case <-c.closed:
+			// 连接关闭
+			if err := c.Conn.WriteMessage(websocket.CloseMessage, []byte{}); err != nil {
+				logger.Debug("关闭WebSocket连接失败",
+					zap.Uint("用户ID", userID),
+					zap.Error(err))
 			}
+			return
+
+		case message := <-c.Send:
 
 			// 设置写入超时
 			c.Conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"campus/internal/config"
+	"campus/internal/utils/logger"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	// 未配置输出时日志被丢弃
+	logger.Init(&config.LogConfig{Level: "error"})
+	os.Exit(m.Run())
+}
+
+func newTestConnection(userID uint, deviceID string) *Connection {
+	c := newConnection(userID, deviceID, nil)
+	c.Send = make(chan []byte, 1)
+	return c
+}
+
+func TestSendMessageFansOutToAllDevices(t *testing.T) {
+	m := NewManager()
+	phone := newTestConnection(1, "phone")
+	laptop := newTestConnection(1, "laptop")
+	m.register(phone)
+	m.register(laptop)
+
+	assert.True(t, m.IsUserOnline(1))
+	assert.Len(t, m.Sessions(1), 2)
+	assert.True(t, m.SendMessage(1, []byte("hi")))
+	assert.Equal(t, []byte("hi"), <-phone.Send)
+	assert.Equal(t, []byte("hi"), <-laptop.Send)
+
+	// 缓冲区已满的设备被关闭等待重连补发，不阻塞其他设备
+	phone.Send <- []byte("pending")
+	assert.True(t, m.SendMessage(1, []byte("again")))
+	assert.Equal(t, []byte("again"), <-laptop.Send)
+	assert.True(t, isClosed(phone))
+
+	assert.False(t, m.SendMessage(2, []byte("hi")))
+}
+
+func TestRegisterReplacesOnlySameDevice(t *testing.T) {
+	m := NewManager()
+	old := newTestConnection(1, "phone")
+	laptop := newTestConnection(1, "laptop")
+	m.register(old)
+	m.register(laptop)
+
+	current := newTestConnection(1, "phone")
+	m.register(current)
+	assert.True(t, isClosed(old), "同一设备的旧连接应被关闭")
+	assert.False(t, isClosed(current))
+	assert.Len(t, m.Sessions(1), 2)
+
+	// 旧连接的读协程退出时不能注销新连接
+	m.unregister(old)
+	assert.Len(t, m.Sessions(1), 2)
+
+	m.unregister(current)
+	m.unregister(laptop)
+	assert.False(t, m.IsUserOnline(1))
+	assert.Empty(t, m.Clients)
+}
+
+func isClosed(c *Connection) bool {
+	select {
+	case <-c.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+func TestValidDeviceID(t *testing.T) {
+	id, err := NewDeviceID()
+	require.NoError(t, err)
+	assert.True(t, ValidDeviceID(id))
+	assert.True(t, ValidDeviceID("ios_phone-1"))
+	assert.False(t, ValidDeviceID(""))
+	assert.False(t, ValidDeviceID("a b"))
+}
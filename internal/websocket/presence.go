@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"campus/internal/utils/logger"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PresenceStore 保存用户的最后在线时间
+type PresenceStore interface {
+	SetLastSeen(userID uint, at time.Time) error
+}
+
+// Presence 用户在线状态
+type Presence struct {
+	UserID   uint       `json:"user_id"`
+	Online   bool       `json:"online"`
+	LastSeen *time.Time `json:"last_seen,omitempty"` // 离线时为最后在线时间
+}
+
+// SetPresenceStore 设置最后在线时间的存储，未设置时不保存
+func (m *Manager) SetPresenceStore(store PresenceStore) {
+	m.presence = store
+}
+
+// Subscribe 用userIDs替换连接订阅的在线状态，订阅的用户上线或离线时向该连接推送presence帧。
+// 调用方负责检查订阅的用户与连接的用户有会话
+func (m *Manager) Subscribe(c *Connection, userIDs []uint) {
+	m.presenceMux.Lock()
+	defer m.presenceMux.Unlock()
+
+	m.unwatch(c)
+	for _, userID := range userIDs {
+		if m.watchers[userID] == nil {
+			m.watchers[userID] = make(map[*Connection]bool)
+		}
+		m.watchers[userID][c] = true
+	}
+	c.watching = userIDs
+}
+
+// unwatch 取消连接的所有订阅，调用方需持有presenceMux
+func (m *Manager) unwatch(c *Connection) {
+	for _, userID := range c.watching {
+		delete(m.watchers[userID], c)
+		if len(m.watchers[userID]) == 0 {
+			delete(m.watchers, userID)
+		}
+	}
+	c.watching = nil
+}
+
+// wentOnline 用户的第一个设备连接后通知订阅者
+func (m *Manager) wentOnline(userID uint) {
+	m.notifyPresence(Presence{UserID: userID, Online: true})
+}
+
+// wentOffline 用户的最后一个设备断开后保存最后在线时间并通知订阅者
+func (m *Manager) wentOffline(userID uint) {
+	now := time.Now()
+	if m.presence != nil {
+		if err := m.presence.SetLastSeen(userID, now); err != nil {
+			logger.Error("保存最后在线时间失败", zap.Uint("用户ID", userID), zap.Error(err))
+		}
+	}
+	m.notifyPresence(Presence{UserID: userID, LastSeen: &now})
+}
+
+func (m *Manager) notifyPresence(p Presence) {
+	m.presenceMux.RLock()
+	conns := make([]*Connection, 0, len(m.watchers[p.UserID]))
+	for c := range m.watchers[p.UserID] {
+		conns = append(conns, c)
+	}
+	m.presenceMux.RUnlock()
+
+	for _, c := range conns {
+		c.SendFrame(FramePresence, "", []Presence{p})
+	}
+}
@@ -0,0 +1,74 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryPresenceStore struct {
+	lastSeen map[uint]time.Time
+}
+
+func (s *memoryPresenceStore) SetLastSeen(userID uint, at time.Time) error {
+	s.lastSeen[userID] = at
+	return nil
+}
+
+func decodePresence(t *testing.T, payload []byte) Presence {
+	frame := decodeFrame(t, payload)
+	require.Equal(t, FramePresence, frame.Type)
+	var presence []Presence
+	require.NoError(t, json.Unmarshal(frame.Data, &presence))
+	require.Len(t, presence, 1)
+	return presence[0]
+}
+
+func TestPresenceNotifiesSubscribersOnTransitions(t *testing.T) {
+	m := NewManager()
+	store := &memoryPresenceStore{lastSeen: map[uint]time.Time{}}
+	m.SetPresenceStore(store)
+
+	watcher := newConnection(1, "phone", nil)
+	m.register(watcher)
+	m.Subscribe(watcher, []uint{2})
+
+	// 第一个设备上线时通知，第二个设备上线不重复通知
+	phone := newConnection(2, "phone", nil)
+	laptop := newConnection(2, "laptop", nil)
+	m.register(phone)
+	m.register(laptop)
+	require.Len(t, watcher.Send, 1)
+	assert.Equal(t, Presence{UserID: 2, Online: true}, decodePresence(t, <-watcher.Send))
+
+	// 最后一个设备断开时保存最后在线时间并通知
+	m.unregister(phone)
+	assert.Empty(t, watcher.Send)
+	m.unregister(laptop)
+	offline := decodePresence(t, <-watcher.Send)
+	assert.False(t, offline.Online)
+	require.NotNil(t, offline.LastSeen)
+	assert.Contains(t, store.lastSeen, uint(2))
+
+	// 订阅者断开后不再接收通知
+	m.unregister(watcher)
+	assert.Empty(t, m.watchers)
+	m.register(newConnection(2, "phone", nil))
+	assert.Empty(t, watcher.Send)
+}
+
+func TestSubscribeReplacesPreviousSubscription(t *testing.T) {
+	m := NewManager()
+	watcher := newConnection(1, "phone", nil)
+	m.register(watcher)
+	m.Subscribe(watcher, []uint{2, 3})
+	m.Subscribe(watcher, []uint{3})
+
+	m.register(newConnection(2, "phone", nil))
+	assert.Empty(t, watcher.Send)
+	m.register(newConnection(3, "phone", nil))
+	assert.Equal(t, uint(3), decodePresence(t, <-watcher.Send).UserID)
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"encoding/json"
+	stderrors "errors"
+
+	"campus/internal/utils/errors"
+)
+
+// ProtocolVersion 当前协议版本，客户端帧的版本不一致时返回错误帧
+const ProtocolVersion = 1
+
+// 帧类型
+const (
+	FrameMessageSend      = "message.send"       // 客户端发送聊天消息，帧ID作为消息的客户端ID去重
+	FrameMessageAck       = "message.ack"        // 服务端确认消息已保存，帧ID与message.send相同
+	FrameMessage          = "message"            // 服务端推送新消息，包括聊天消息、系统通知和订单事件
+	FrameMessageDelivered = "message.delivered"  // 服务端通知发送者消息已推送到接收者的设备
+	FrameMessageWithdrawn = "message.withdrawn"  // 服务端通知会话双方消息已撤回
+	FrameMessageDeleted   = "message.deleted"    // 服务端通知会话双方消息已删除
+	FrameRead             = "read"               // 客户端标记已读；服务端向发送者推送已读回执
+	FrameTyping           = "typing"             // 客户端发送输入状态；服务端转发给会话的另一方
+	FramePresenceSub      = "presence.subscribe" // 客户端订阅联系人的在线状态
+	FramePresence         = "presence"           // 服务端推送在线状态，订阅时返回当前状态，之后推送变化
+	FrameError            = "error"              // 服务端处理客户端帧失败，帧ID与出错的帧相同
+)
+
+// Frame 协议帧
+type Frame struct {
+	Version int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"` // 客户端生成的帧ID，服务端的应答帧使用相同的ID
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// ErrorData 错误帧数据
+type ErrorData struct {
+	Code    errors.ErrorType `json:"code"`
+	Message string           `json:"message"`
+}
+
+// DeliveredData 送达回执数据
+type DeliveredData struct {
+	ReceiverID uint   `json:"receiver_id"`
+	MessageIDs []uint `json:"message_ids"`
+}
+
+// FrameHandler 处理客户端发送的帧，同一连接的帧按接收顺序依次处理
+type FrameHandler interface {
+	HandleFrame(c *Connection, frame *Frame)
+}
+
+// EncodeFrame 编码服务端帧
+func EncodeFrame(frameType, id string, data interface{}) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Frame{Version: ProtocolVersion, Type: frameType, ID: id, Data: raw})
+}
+
+// errorData 将错误转换为错误帧数据，内部错误不返回细节
+func errorData(err error) ErrorData {
+	var appErr *errors.AppError
+	if stderrors.As(err, &appErr) && appErr.Type != errors.ErrorTypeInternalServer {
+		return ErrorData{Code: appErr.Type, Message: appErr.Message}
+	}
+	return ErrorData{Code: errors.ErrorTypeInternalServer, Message: "服务器内部错误"}
+}
@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"campus/internal/utils/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingHandler struct {
+	frames []*Frame
+}
+
+func (h *recordingHandler) HandleFrame(c *Connection, frame *Frame) {
+	h.frames = append(h.frames, frame)
+}
+
+func decodeFrame(t *testing.T, payload []byte) Frame {
+	var frame Frame
+	require.NoError(t, json.Unmarshal(payload, &frame))
+	assert.Equal(t, ProtocolVersion, frame.Version)
+	return frame
+}
+
+func TestDispatchRejectsInvalidFrames(t *testing.T) {
+	m := NewManager()
+	handler := &recordingHandler{}
+	m.SetHandler(handler)
+	c := newConnection(1, "phone", nil)
+
+	m.dispatch(c, []byte("hello"))
+	m.dispatch(c, []byte(`{"v":2,"type":"message.send","id":"c1"}`))
+	require.Len(t, c.Send, 2)
+
+	frame := decodeFrame(t, <-c.Send)
+	assert.Equal(t, FrameError, frame.Type)
+	frame = decodeFrame(t, <-c.Send)
+	assert.Equal(t, FrameError, frame.Type)
+	assert.Equal(t, "c1", frame.ID)
+	var data ErrorData
+	require.NoError(t, json.Unmarshal(frame.Data, &data))
+	assert.Equal(t, errors.ErrorTypeBadRequest, data.Code)
+
+	m.dispatch(c, []byte(`{"v":1,"type":"read","id":"c2","data":{"contact_id":2}}`))
+	require.Len(t, handler.frames, 1)
+	assert.Equal(t, FrameRead, handler.frames[0].Type)
+	assert.JSONEq(t, `{"contact_id":2}`, string(handler.frames[0].Data))
+}
+
+func TestErrorFrameHidesInternalDetails(t *testing.T) {
+	c := newConnection(1, "phone", nil)
+	c.SendError("c1", errors.NewInternalServerError("消息保存失败", assert.AnError))
+
+	var data ErrorData
+	require.NoError(t, json.Unmarshal(decodeFrame(t, <-c.Send).Data, &data))
+	assert.Equal(t, ErrorData{Code: errors.ErrorTypeInternalServer, Message: "服务器内部错误"}, data)
+}
+
+func TestDeliverSendsDeliveredReceiptToSender(t *testing.T) {
+	m := NewManager()
+	receiver := newConnection(1, "phone", nil)
+	sender := newConnection(2, "laptop", nil)
+	m.register(receiver)
+	m.register(sender)
+
+	payload, err := EncodeFrame(FrameMessage, "", map[string]uint{"id": 10})
+	require.NoError(t, err)
+	d := Delivery{ID: 10, SenderID: 2, Payload: payload}
+
+	assert.True(t, m.Deliver(1, d))
+	assert.Equal(t, FrameMessage, decodeFrame(t, <-receiver.Send).Type)
+	receipt := decodeFrame(t, <-sender.Send)
+	assert.Equal(t, FrameMessageDelivered, receipt.Type)
+	assert.JSONEq(t, `{"receiver_id":1,"message_ids":[10]}`, string(receipt.Data))
+
+	// 同步到发送者自己的设备时不返回回执
+	assert.True(t, m.Deliver(2, d))
+	assert.Equal(t, FrameMessage, decodeFrame(t, <-sender.Send).Type)
+	assert.Empty(t, sender.Send)
+}